@@ -0,0 +1,280 @@
+package main
+
+import (
+	"alumnihub/internal/models"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// importColumns maps normalized header names to alumni fields
+var importColumns = map[string]string{
+	"nisn":           "nisn",
+	"nis":            "nis",
+	"name":           "name",
+	"nama":           "name",
+	"namalengkap":    "name",
+	"gender":         "gender",
+	"jeniskelamin":   "gender",
+	"jk":             "gender",
+	"phone":          "phone",
+	"telepon":        "phone",
+	"notelepon":      "phone",
+	"nohp":           "phone",
+	"nomorhp":        "phone",
+	"graduationyear": "graduation_year",
+	"tahunlulus":     "graduation_year",
+	"angkatan":       "graduation_year",
+	"year":           "graduation_year",
+	"class":          "class",
+	"kelas":          "class",
+}
+
+var requiredImportColumns = []string{"nisn", "nis", "name"}
+
+var (
+	digitsRegex = regexp.MustCompile(`^[0-9]+$`)
+	phoneRegex  = regexp.MustCompile(`^\+?[0-9]{8,15}$`)
+)
+
+// readImportRecords reads every row of an uploaded CSV or XLSX file
+func readImportRecords(file io.Reader, fileName string, sheet string) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return nil, err
+		}
+
+		reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+
+		// Excel in Indonesian locale exports CSV separated by semicolons
+		firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+		if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+			reader.Comma = ';'
+		}
+
+		return reader.ReadAll()
+	case ".xlsx", ".xlsm":
+		f, err := excelize.OpenReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		if sheet == "" {
+			sheets := f.GetSheetList()
+			if len(sheets) == 0 {
+				return nil, errors.New("workbook has no sheets")
+			}
+			sheet = sheets[0]
+		}
+
+		return f.GetRows(sheet)
+	default:
+		return nil, errors.New("file must be a .csv or .xlsx file")
+	}
+}
+
+// normalizeHeader lowercases a header and strips everything but letters and digits
+func normalizeHeader(header string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, header)
+}
+
+// mapImportHeader returns the column index of every known field in the header row
+func mapImportHeader(header []string) (map[string]int, error) {
+	columns := make(map[string]int)
+
+	for i, h := range header {
+		field, ok := importColumns[normalizeHeader(h)]
+		if !ok {
+			continue
+		}
+		if _, exists := columns[field]; !exists {
+			columns[field] = i
+		}
+	}
+
+	var missing []string
+	for _, field := range requiredImportColumns {
+		if _, ok := columns[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required columns: %s", strings.Join(missing, ", "))
+	}
+
+	return columns, nil
+}
+
+// parseImportRows turns the raw records into alumni rows and validates each of them
+func parseImportRows(records [][]string) ([]*models.AlumniImportRow, error) {
+	if len(records) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	columns, err := mapImportHeader(records[0])
+	if err != nil {
+		return nil, err
+	}
+
+	cell := func(record []string, field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []*models.AlumniImportRow
+
+	for i, record := range records[1:] {
+		// Skip completely empty lines
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		row := models.AlumniImportRow{
+			Row: i + 2, // 1-based and after the header
+			Alumni: models.Alumni{
+				NISN:   cell(record, "nisn"),
+				NIS:    cell(record, "nis"),
+				Name:   cell(record, "name"),
+				Gender: normalizeGender(cell(record, "gender")),
+				Phone:  strings.ReplaceAll(strings.ReplaceAll(cell(record, "phone"), " ", ""), "-", ""),
+				Class:  cell(record, "class"),
+			},
+		}
+
+		if year := cell(record, "graduation_year"); year != "" {
+			n, err := strconv.Atoi(year)
+			if err != nil {
+				row.Errors = append(row.Errors, "graduation year must be a number")
+			}
+			row.Alumni.Year = n
+		}
+
+		row.Errors = append(row.Errors, validateAlumni(row.Alumni)...)
+		rows = append(rows, &row)
+	}
+
+	return rows, nil
+}
+
+// normalizeGender accepts M/F as well as the Indonesian L/P and full words
+func normalizeGender(gender string) string {
+	switch strings.ToUpper(gender) {
+	case "M", "L", "MALE", "LAKI-LAKI", "LAKI LAKI":
+		return "M"
+	case "F", "P", "FEMALE", "PEREMPUAN", "WANITA":
+		return "F"
+	default:
+		return strings.ToUpper(gender)
+	}
+}
+
+// validateAlumni returns a message for every invalid field of an alumni record
+func validateAlumni(alumni models.Alumni) []string {
+	var errs []string
+
+	if len(alumni.NISN) != 10 || !digitsRegex.MatchString(alumni.NISN) {
+		errs = append(errs, "nisn must be 10 digits")
+	}
+
+	if alumni.NIS == "" || len(alumni.NIS) > 16 || !digitsRegex.MatchString(alumni.NIS) {
+		errs = append(errs, "nis must be 1 to 16 digits")
+	}
+
+	if alumni.Name == "" {
+		errs = append(errs, "name is required")
+	} else if len(alumni.Name) > 512 {
+		errs = append(errs, "name cannot be longer than 512 characters")
+	}
+
+	if alumni.Gender != "M" && alumni.Gender != "F" {
+		errs = append(errs, "gender must be M or F")
+	}
+
+	if alumni.Phone != "" && !phoneRegex.MatchString(alumni.Phone) {
+		errs = append(errs, "phone must be 8 to 15 digits")
+	}
+
+	if alumni.Year < 1950 || alumni.Year > time.Now().Year()+1 {
+		errs = append(errs, "graduation year is out of range")
+	}
+
+	if len(alumni.Class) > 32 {
+		errs = append(errs, "class cannot be longer than 32 characters")
+	}
+
+	return errs
+}
+
+// markImportDuplicates flags rows repeating a NISN or NIS within the file or a NISN already stored.
+// A NIS already stored for another NISN is an error, as saving the row would break its uniqueness
+// even when upserting.
+func markImportDuplicates(rows []*models.AlumniImportRow, existing map[string]bool, existingNIS map[string]string) {
+	seenNISN := make(map[string]int)
+	seenNIS := make(map[string]int)
+
+	for _, row := range rows {
+		if first, ok := seenNISN[row.Alumni.NISN]; ok && row.Alumni.NISN != "" {
+			row.Errors = append(row.Errors, fmt.Sprintf("nisn is repeated from row %d", first))
+		} else {
+			seenNISN[row.Alumni.NISN] = row.Row
+		}
+
+		if first, ok := seenNIS[row.Alumni.NIS]; ok && row.Alumni.NIS != "" {
+			row.Errors = append(row.Errors, fmt.Sprintf("nis is repeated from row %d", first))
+		} else {
+			seenNIS[row.Alumni.NIS] = row.Row
+		}
+
+		if nisn, ok := existingNIS[row.Alumni.NIS]; ok && row.Alumni.NIS != "" && nisn != row.Alumni.NISN {
+			row.Errors = append(row.Errors, fmt.Sprintf("nis is already used by the alumni with nisn %s", nisn))
+		}
+
+		row.Duplicate = existing[row.Alumni.NISN]
+	}
+}
+
+// buildImportPreview fills the summary counters of an import preview
+func buildImportPreview(rows []*models.AlumniImportRow) models.AlumniImportPreview {
+	preview := models.AlumniImportPreview{
+		Rows:  rows,
+		Total: len(rows),
+	}
+
+	for _, row := range rows {
+		if len(row.Errors) > 0 {
+			preview.Invalid++
+		} else {
+			preview.Valid++
+		}
+
+		if row.Duplicate {
+			preview.Duplicates++
+		}
+	}
+
+	return preview
+}
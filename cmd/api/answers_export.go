@@ -0,0 +1,216 @@
+package main
+
+import (
+	"alumnihub/internal/models"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// respondentHeaders are the columns describing the respondent in front of the answers
+var respondentHeaders = []string{"No", "Nama", "NISN", "Angkatan", "Kelas", "Username"}
+
+// exportColumn is one answer column of an export. Grid questions get a column per row.
+type exportColumn struct {
+	header     string
+	questionID int
+	row        string
+}
+
+func exportColumns(questions []*models.Question) []exportColumn {
+	var columns []exportColumn
+
+	for _, question := range questions {
+		if question.Type == models.QuestionGrid && len(question.Settings.Rows) > 0 {
+			for _, row := range question.Settings.Rows {
+				columns = append(columns, exportColumn{
+					header:     fmt.Sprintf("%s [%s]", question.Question, row),
+					questionID: question.ID,
+					row:        row,
+				})
+			}
+			continue
+		}
+
+		columns = append(columns, exportColumn{header: question.Question, questionID: question.ID})
+	}
+
+	return columns
+}
+
+// formulaPrefixes are the first characters that make spreadsheet applications read a cell as a formula
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeCell keeps a value from being run as a formula when the export is opened in a
+// spreadsheet by prefixing it with a quote
+func escapeCell(value string) string {
+	if value != "" && strings.ContainsRune(formulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+// exportHeader returns the header row of an export
+func exportHeader(columns []exportColumn) []string {
+	header := append([]string{}, respondentHeaders...)
+	for _, column := range columns {
+		header = append(header, escapeCell(column.header))
+	}
+
+	return header
+}
+
+// exportRecord returns the row of one respondent. Several values of the same
+// column, such as picked checkboxes, are joined with a semicolon.
+func exportRecord(number int, respondent *models.Respondent, columns []exportColumn) []string {
+	values := make(map[exportColumn][]string)
+	for _, answer := range respondent.Answers {
+		key := exportColumn{questionID: answer.QuestionID, row: answer.Row}
+		values[key] = append(values[key], answer.Answer)
+	}
+
+	year := ""
+	if respondent.Year != 0 {
+		year = fmt.Sprint(respondent.Year)
+	}
+
+	record := []string{fmt.Sprint(number), respondent.Name, respondent.NISN, year, respondent.Class, respondent.Username}
+	for _, column := range columns {
+		key := exportColumn{questionID: column.questionID, row: column.row}
+		record = append(record, strings.Join(values[key], "; "))
+	}
+
+	// Names and answers are typed by alumni, so none of them may become a formula
+	for i := range record {
+		record[i] = escapeCell(record[i])
+	}
+
+	return record
+}
+
+// writeAnswersCSV writes one row per respondent as CSV
+func writeAnswersCSV(out io.Writer, questions []*models.Question, respondents []*models.Respondent) error {
+	// The byte order mark makes Excel open the file as UTF-8
+	_, err := out.Write([]byte("\xef\xbb\xbf"))
+	if err != nil {
+		return err
+	}
+
+	columns := exportColumns(questions)
+
+	writer := csv.NewWriter(out)
+
+	err = writer.Write(exportHeader(columns))
+	if err != nil {
+		return err
+	}
+
+	for i, respondent := range respondents {
+		err = writer.Write(exportRecord(i+1, respondent, columns))
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// buildAnswersWorkbook builds a workbook with one row per respondent on the first sheet
+// and the answer counts of every choice question on a summary sheet
+func buildAnswersWorkbook(questions []*models.Question, respondents []*models.Respondent, summary map[int][]*models.GroupAnswer) (*excelize.File, error) {
+	xlsx := excelize.NewFile()
+
+	const answersSheet = "Jawaban"
+	const summarySheet = "Ringkasan"
+
+	err := xlsx.SetSheetName("Sheet1", answersSheet)
+	if err != nil {
+		return nil, err
+	}
+
+	headerStyle, err := xlsx.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Size: 11, Color: "000000"},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#60A5FA"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	columns := exportColumns(questions)
+
+	stream, err := xlsx.NewStreamWriter(answersSheet)
+	if err != nil {
+		return nil, err
+	}
+
+	err = stream.SetRow("A1", toCells(exportHeader(columns), headerStyle))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, respondent := range respondents {
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		if err != nil {
+			return nil, err
+		}
+
+		err = stream.SetRow(cell, toCells(exportRecord(i+1, respondent, columns), 0))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = stream.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = xlsx.NewSheet(summarySheet)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]interface{}{{"Pertanyaan", "Baris", "Jawaban", "Jumlah"}}
+	for _, question := range questions {
+		for _, group := range summary[question.ID] {
+			rows = append(rows, []interface{}{escapeCell(question.Question), escapeCell(group.Row), escapeCell(group.Answer), group.Count})
+		}
+	}
+
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return nil, err
+		}
+
+		err = xlsx.SetSheetRow(summarySheet, cell, &row)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = xlsx.SetCellStyle(summarySheet, "A1", "D1", headerStyle)
+	if err != nil {
+		return nil, err
+	}
+
+	xlsx.SetActiveSheet(0)
+
+	return xlsx, nil
+}
+
+// toCells turns a record into stream writer cells with the given style
+func toCells(record []string, style int) []interface{} {
+	cells := make([]interface{}, len(record))
+	for i, value := range record {
+		cells[i] = excelize.Cell{StyleID: style, Value: value}
+	}
+
+	return cells
+}
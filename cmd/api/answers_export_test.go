@@ -0,0 +1,64 @@
+package main
+
+import (
+	"alumnihub/internal/models"
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+)
+
+func TestEscapeCell(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"formula", `=HYPERLINK("https://x","y")`, `'=HYPERLINK("https://x","y")`},
+		{"plus", "+62812", "'+62812"},
+		{"minus", "-1+1", "'-1+1"},
+		{"at sign", "@cmd", "'@cmd"},
+		{"tab", "\t=1", "'\t=1"},
+		{"carriage return", "\r=1", "'\r=1"},
+		{"plain text", "Bekerja", "Bekerja"},
+		{"formula later in the text", "a=1", "a=1"},
+		{"quote", "'quoted", "'quoted"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapeCell(tt.input); got != tt.want {
+				t.Errorf("escapeCell(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportRecordEscapesCells(t *testing.T) {
+	questions := []*models.Question{{ID: 1, Question: "Pekerjaan"}}
+	respondent := &models.Respondent{
+		Name:     "=cmd|' /C calc'!A0",
+		Username: "@alumni",
+		Year:     2020,
+		Answers: []*models.Answer{
+			{QuestionID: 1, Answer: `=HYPERLINK("https://x","y")`},
+		},
+	}
+
+	var out bytes.Buffer
+	err := writeAnswersCSV(&out, questions, []*models.Respondent{respondent})
+	if err != nil {
+		t.Fatalf("writeAnswersCSV returned error: %v", err)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(out.Bytes(), []byte("\xef\xbb\xbf")))).ReadAll()
+	if err != nil {
+		t.Fatalf("reading the CSV returned error: %v", err)
+	}
+
+	want := []string{"1", "'=cmd|' /C calc'!A0", "", "2020", "", "'@alumni", `'=HYPERLINK("https://x","y")`}
+	if len(records) != 2 || !reflect.DeepEqual(records[1], want) {
+		t.Errorf("CSV records = %q, want the respondent row %q", records, want)
+	}
+}
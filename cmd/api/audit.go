@@ -0,0 +1,45 @@
+package main
+
+import (
+	"alumnihub/internal/models"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// audit records who did what to which entity. before and after are stored as JSON and may be nil.
+// A failure to write the entry is logged but never fails the request that was audited.
+func (app *application) audit(r *http.Request, action string, entityType string, entityID int, before interface{}, after interface{}) {
+	entry := models.AuditEntry{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		IP:         clientIP(r),
+		UserAgent:  r.UserAgent(),
+	}
+
+	if principal, err := currentUser(r); err == nil {
+		entry.ActorID = principal.UserID
+	}
+
+	var err error
+
+	if before != nil {
+		entry.Before, err = json.Marshal(before)
+		if err != nil {
+			log.Printf("error encoding audit entry for %s %s %d: %v", action, entityType, entityID, err)
+		}
+	}
+
+	if after != nil {
+		entry.After, err = json.Marshal(after)
+		if err != nil {
+			log.Printf("error encoding audit entry for %s %s %d: %v", action, entityType, entityID, err)
+		}
+	}
+
+	err = app.DB.InsertAuditEntry(entry)
+	if err != nil {
+		log.Printf("error writing audit entry for %s %s %d: %v", action, entityType, entityID, err)
+	}
+}
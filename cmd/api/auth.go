@@ -1,9 +1,12 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,7 +17,7 @@ import (
 type contextKey string
 
 // Definisikan tipe kunci khusus untuk nilai dalam konteks
-const userClaimsKey contextKey = "userClaims"
+const principalKey contextKey = "principal"
 
 type Auth struct {
 	Issuer        string
@@ -28,21 +31,88 @@ type Auth struct {
 }
 
 type jwtUser struct {
-	ID       int    `json:"id"`
-	Username string `json:"username"`
-	Role     bool   `json:"role"`
+	ID          int      `json:"id"`
+	Username    string   `json:"username"`
+	Role        bool     `json:"role"`
+	SessionID   int      `json:"session_id"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
 }
 
 type TokenPairs struct {
-	Token        string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	Token            string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshTokenID   string    `json:"-"`
+	RefreshExpiresAt time.Time `json:"-"`
 }
 
 type Claims struct {
 	jwt.RegisteredClaims
-	IsAdmin bool `json:"adm,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	IsAdmin     bool     `json:"adm,omitempty"`
+	Type        string   `json:"typ,omitempty"`
+	SessionID   int      `json:"sid,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"perms,omitempty"`
 }
 
+// Principal is the authenticated user a request is made by
+type Principal struct {
+	UserID      int
+	Username    string
+	IsAdmin     bool
+	SessionID   int
+	Roles       []string
+	Permissions []string
+}
+
+// HasPermission reports whether the principal was granted the given permission
+func (p *Principal) HasPermission(permission string) bool {
+	for _, perm := range p.Permissions {
+		if perm == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanModify reports whether the principal may change a resource owned by ownerID.
+// Owners may change their own resources, admins and holders of permission anything.
+func (p *Principal) CanModify(ownerID int, permission string) bool {
+	return p.UserID == ownerID || p.IsAdmin || p.HasPermission(permission)
+}
+
+// newPrincipal builds the principal of a verified access token
+func newPrincipal(claims *Claims) (*Principal, error) {
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, errors.New("invalid user ID in token")
+	}
+
+	return &Principal{
+		UserID:      userID,
+		Username:    claims.Name,
+		IsAdmin:     claims.IsAdmin,
+		SessionID:   claims.SessionID,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+	}, nil
+}
+
+// currentUser returns the principal stored in the context by authRequired
+func currentUser(r *http.Request) (*Principal, error) {
+	principal, ok := r.Context().Value(principalKey).(*Principal)
+	if !ok {
+		return nil, errors.New("no authenticated user in context")
+	}
+
+	return principal, nil
+}
+
+// refreshTokenType is the typ claim that tells refresh tokens apart from access tokens
+const refreshTokenType = "refresh"
+
 func (j *Auth) GenerateTokenPair(user *jwtUser) (TokenPairs, error) {
 	// Create a token
 	token := jwt.New(jwt.SigningMethodHS256)
@@ -56,6 +126,9 @@ func (j *Auth) GenerateTokenPair(user *jwtUser) (TokenPairs, error) {
 	claims["iat"] = time.Now().UTC().Unix()
 	claims["typ"] = "JWT"
 	claims["adm"] = user.Role
+	claims["sid"] = user.SessionID
+	claims["roles"] = user.Roles
+	claims["perms"] = user.Permissions
 
 	// Set the expiry for JWT
 	claims["exp"] = time.Now().UTC().Add(j.TokenExpiry).Unix()
@@ -66,14 +139,27 @@ func (j *Auth) GenerateTokenPair(user *jwtUser) (TokenPairs, error) {
 		return TokenPairs{}, err
 	}
 
+	// Every refresh token gets a unique id so it can be tracked and rotated server-side
+	jti, err := generateJTI()
+	if err != nil {
+		return TokenPairs{}, err
+	}
+
+	refreshExpiresAt := time.Now().Add(j.RefreshExpiry)
+
 	// Create a refresh token and set claims
 	refreshToken := jwt.New(jwt.SigningMethodHS256)
 	refreshTokenClaims := refreshToken.Claims.(jwt.MapClaims)
 	refreshTokenClaims["sub"] = fmt.Sprint(user.ID)
+	refreshTokenClaims["aud"] = j.Audience
+	refreshTokenClaims["iss"] = j.Issuer
 	refreshTokenClaims["iat"] = time.Now().UTC().Unix()
+	refreshTokenClaims["jti"] = jti
+	refreshTokenClaims["typ"] = refreshTokenType
+	refreshTokenClaims["sid"] = user.SessionID
 
 	// Set the expiry for refresh token
-	refreshTokenClaims["exp"] = time.Now().UTC().Add(j.RefreshExpiry).Unix()
+	refreshTokenClaims["exp"] = refreshExpiresAt.UTC().Unix()
 
 	// Create signed refresh token
 	signedRefreshToken, err := refreshToken.SignedString([]byte(j.Secret))
@@ -83,8 +169,10 @@ func (j *Auth) GenerateTokenPair(user *jwtUser) (TokenPairs, error) {
 
 	// Create TokenPairs and populate with signed tokens
 	var tokenPairs = TokenPairs{
-		Token:        signedAccessToken,
-		RefreshToken: signedRefreshToken,
+		Token:            signedAccessToken,
+		RefreshToken:     signedRefreshToken,
+		RefreshTokenID:   jti,
+		RefreshExpiresAt: refreshExpiresAt,
 	}
 
 	// Return TokenPairs
@@ -167,6 +255,52 @@ func (j *Auth) GetTokenFromHeaderAndVerify(w http.ResponseWriter, r *http.Reques
 		return "", nil, errors.New("invalid issuer")
 	}
 
+	// refresh tokens cannot be used to access the API
+	if claims.Type == refreshTokenType {
+		return "", nil, errors.New("invalid token type")
+	}
+
 	return token, claims, nil
 
 }
+
+// ParseRefreshToken verifies a refresh token and returns its claims
+func (j *Auth) ParseRefreshToken(token string) (*Claims, error) {
+	claims := &Claims{}
+
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(j.Secret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Type != refreshTokenType {
+		return nil, errors.New("invalid token type")
+	}
+
+	if claims.Issuer != j.Issuer {
+		return nil, errors.New("invalid issuer")
+	}
+
+	if claims.ID == "" || claims.SessionID == 0 {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	return claims, nil
+}
+
+// generateJTI returns a random identifier for a refresh token
+func generateJTI() (string, error) {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"alumnihub/internal/models"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// campaignBatchSize is the number of queued messages sent per round
+const campaignBatchSize = 50
+
+// campaignLease is how long a claimed message may stay unfinished before it is claimed
+// again, and campaignMaxAttempts how often that happens before it counts as failed
+const (
+	campaignLease       = 10 * time.Minute
+	campaignMaxAttempts = 3
+)
+
+// runCampaigns sends the invitations and reminders that are due, every interval
+func (app *application) runCampaigns(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		app.sendDueMessages()
+	}
+}
+
+// sendDueMessages sends queued messages until none are due. Recipients who answered the
+// form in the meantime are skipped.
+func (app *application) sendDueMessages() {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("error sending campaign messages:", err)
+		}
+	}()
+
+	for {
+		messages, err := app.DB.ClaimDueCampaignMessages(time.Now(), campaignBatchSize, campaignLease, campaignMaxAttempts)
+		if err != nil {
+			log.Println("error claiming campaign messages:", err)
+			return
+		}
+
+		for _, message := range messages {
+			status, sendErr := app.sendCampaignMessage(message)
+
+			err = app.DB.CompleteCampaignMessage(message, status, sendErr)
+			if err != nil {
+				log.Printf("error completing campaign message %d: %v", message.ID, err)
+			}
+		}
+
+		if len(messages) < campaignBatchSize {
+			return
+		}
+	}
+}
+
+// sendCampaignMessage delivers one message and returns its status and the delivery error
+func (app *application) sendCampaignMessage(message *models.CampaignMessage) (string, string) {
+	if message.Submitted {
+		return models.MessageSkipped, ""
+	}
+
+	notifier, ok := app.Notifiers[message.Channel]
+	if !ok {
+		return models.MessageFailed, fmt.Sprintf("no notifier for channel %s", message.Channel)
+	}
+
+	err := notifier.Notify(app.campaignNotification(message))
+	if err != nil {
+		return models.MessageFailed, err.Error()
+	}
+
+	return models.MessageSent, ""
+}
+
+// campaignNotification writes the invitation or reminder of a message. The link carries
+// the token of the recipient so the frontend can report that it was opened.
+func (app *application) campaignNotification(message *models.CampaignMessage) Notification {
+	subject := fmt.Sprintf("Undangan mengisi survei: %s", message.FormTitle)
+	if message.Kind == models.MessageReminder {
+		subject = fmt.Sprintf("Pengingat mengisi survei: %s", message.FormTitle)
+	}
+
+	var body strings.Builder
+
+	name := message.Recipient.Name
+	if name == "" {
+		name = "Alumni"
+	}
+
+	fmt.Fprintf(&body, "Halo %s,\n\n", name)
+	if message.Message != "" {
+		body.WriteString(message.Message + "\n\n")
+	} else {
+		fmt.Fprintf(&body, "Kami mengundang kamu untuk mengisi survei \"%s\".\n\n", message.FormTitle)
+	}
+	fmt.Fprintf(&body, "Isi survei melalui tautan berikut:\n\n%s/forms/%d?invite=%s\n", app.FrontendURL, message.FormID, message.Token)
+
+	return Notification{
+		Recipient: message.Recipient,
+		Subject:   subject,
+		Body:      body.String(),
+	}
+}
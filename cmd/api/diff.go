@@ -0,0 +1,121 @@
+package main
+
+import (
+	"alumnihub/internal/models"
+	"regexp"
+	"strings"
+)
+
+// maxDiffCells bounds the table used to diff the changed middle of two bodies. Larger
+// changes are shown as the old lines removed and the new lines inserted.
+const maxDiffCells = 1 << 20
+
+// blockBreak matches the end of block elements and line breaks. Editors often save an
+// article on a single line, so bodies are split after these to diff them line by line.
+var blockBreak = regexp.MustCompile(`(?i)(</(?:p|h[1-6]|li|ul|ol|blockquote|pre|figure|figcaption|table|tr|div)>|<br\s*/?>|<hr\s*/?>)`)
+
+// bodyLines splits an article body into the lines that are compared, blank lines are left out
+func bodyLines(body string) []string {
+	var lines []string
+
+	for _, line := range strings.Split(blockBreak.ReplaceAllString(body, "$1\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// diffLines returns the lines of a and b marked as kept, removed from a or inserted from b,
+// using the longest common subsequence of the lines that differ
+func diffLines(a, b []string) []*models.DiffLine {
+	var prefix, suffix []*models.DiffLine
+
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		prefix = append(prefix, &models.DiffLine{Op: models.DiffEqual, Text: a[0]})
+		a, b = a[1:], b[1:]
+	}
+
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		suffix = append([]*models.DiffLine{{Op: models.DiffEqual, Text: a[len(a)-1]}}, suffix...)
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	lines := prefix
+	n, m := len(a), len(b)
+
+	if (n+1)*(m+1) > maxDiffCells {
+		for _, line := range a {
+			lines = append(lines, &models.DiffLine{Op: models.DiffDelete, Text: line})
+		}
+		for _, line := range b {
+			lines = append(lines, &models.DiffLine{Op: models.DiffInsert, Text: line})
+		}
+
+		return append(lines, suffix...)
+	}
+
+	// lcs[i*(m+1)+j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([]int, (n+1)*(m+1))
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j+1] + 1
+			case lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]:
+				lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j]
+			default:
+				lcs[i*(m+1)+j] = lcs[i*(m+1)+j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			lines = append(lines, &models.DiffLine{Op: models.DiffEqual, Text: a[i]})
+			i++
+			j++
+		case j == m || (i < n && lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]):
+			lines = append(lines, &models.DiffLine{Op: models.DiffDelete, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, &models.DiffLine{Op: models.DiffInsert, Text: b[j]})
+			j++
+		}
+	}
+
+	return append(lines, suffix...)
+}
+
+// diffRevisions compares two revisions of an article, from may be nil for an empty article
+func diffRevisions(from, to *models.ArticleRevision) *models.ArticleDiff {
+	diff := &models.ArticleDiff{
+		ArticleID: to.ArticleID,
+		To:        to.Revision,
+		TitleTo:   to.Title,
+	}
+
+	var old []string
+	if from != nil {
+		diff.From = from.Revision
+		diff.TitleFrom = from.Title
+		old = bodyLines(from.Body)
+	}
+
+	diff.Lines = diffLines(old, bodyLines(to.Body))
+
+	for _, line := range diff.Lines {
+		switch line.Op {
+		case models.DiffInsert:
+			diff.Added++
+		case models.DiffDelete:
+			diff.Removed++
+		}
+	}
+
+	return diff
+}
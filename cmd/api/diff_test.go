@@ -0,0 +1,91 @@
+package main
+
+import (
+	"alumnihub/internal/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// diffOps renders diff lines as "op text" strings to compare them easily
+func diffOps(lines []*models.DiffLine) []string {
+	ops := []string{}
+	for _, line := range lines {
+		ops = append(ops, line.Op+" "+line.Text)
+	}
+
+	return ops
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a    []string
+		b    []string
+		want []string
+	}{
+		{"equal", []string{"a", "b"}, []string{"a", "b"}, []string{"equal a", "equal b"}},
+		{"from empty", nil, []string{"a", "b"}, []string{"insert a", "insert b"}},
+		{"to empty", []string{"a", "b"}, nil, []string{"delete a", "delete b"}},
+		{"both empty", nil, nil, []string{}},
+		{"changed line", []string{"a", "b", "c"}, []string{"a", "x", "c"}, []string{"equal a", "delete b", "insert x", "equal c"}},
+		{"appended line", []string{"a", "b"}, []string{"a", "b", "c"}, []string{"equal a", "equal b", "insert c"}},
+		{"moved line", []string{"a", "b", "c"}, []string{"c", "a"}, []string{"delete a", "delete b", "equal c", "insert a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffOps(diffLines(tt.a, tt.b))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffLines(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLinesLargeChange(t *testing.T) {
+	// Past maxDiffCells the changed middle is shown as removed and then inserted
+	var a, b []string
+	for i := 0; i < 1100; i++ {
+		a = append(a, "old "+strings.Repeat("x", i))
+		b = append(b, "new "+strings.Repeat("x", i))
+	}
+
+	lines := diffLines(append([]string{"same"}, a...), append([]string{"same"}, b...))
+
+	if len(lines) != 1+len(a)+len(b) {
+		t.Fatalf("got %d lines, want %d", len(lines), 1+len(a)+len(b))
+	}
+
+	if lines[0].Op != models.DiffEqual || lines[1].Op != models.DiffDelete || lines[len(lines)-1].Op != models.DiffInsert {
+		t.Errorf("unexpected ops %s, %s, %s", lines[0].Op, lines[1].Op, lines[len(lines)-1].Op)
+	}
+}
+
+func TestBodyLines(t *testing.T) {
+	got := bodyLines("<h2>Title</h2><p>One<br>Two</p>\n\n<ul><li>a</li><li>b</li></ul>")
+	want := []string{"<h2>Title</h2>", "<p>One<br>", "Two</p>", "<ul><li>a</li>", "<li>b</li>", "</ul>"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bodyLines = %q, want %q", got, want)
+	}
+}
+
+func TestDiffRevisions(t *testing.T) {
+	from := &models.ArticleRevision{ArticleID: 7, Revision: 1, Title: "Old", Body: "<p>a</p><p>b</p>"}
+	to := &models.ArticleRevision{ArticleID: 7, Revision: 2, Title: "New", Body: "<p>a</p><p>c</p><p>d</p>"}
+
+	diff := diffRevisions(from, to)
+	if diff.From != 1 || diff.To != 2 || diff.TitleFrom != "Old" || diff.TitleTo != "New" {
+		t.Errorf("unexpected revisions or titles: %+v", diff)
+	}
+
+	if diff.Added != 2 || diff.Removed != 1 {
+		t.Errorf("added %d and removed %d, want 2 and 1", diff.Added, diff.Removed)
+	}
+
+	first := diffRevisions(nil, from)
+	if first.From != 0 || first.Added != 2 || first.Removed != 0 {
+		t.Errorf("first revision diff = %+v, want everything inserted", first)
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"alumnihub/internal/models"
+	"errors"
+	"fmt"
+)
+
+// reachableQuestions walks the questions of a form in order and returns the ids of the ones
+// a respondent reaches with the given answers. A question targeted by "show" rules is only
+// reached when one of those rules fired, and a fired "skip_to" rule jumps over every
+// question up to its target question or to the start of its target section.
+func reachableQuestions(form *models.Form, answers map[int][]string) map[int]bool {
+	questions := form.Questions
+
+	index := make(map[int]int)
+	showTargets := make(map[int]bool)
+
+	for i, question := range questions {
+		index[question.ID] = i
+		for _, rule := range question.Rules {
+			if rule.Action == models.RuleShow {
+				showTargets[rule.FollowUpQuestion] = true
+			}
+		}
+	}
+
+	reachable := make(map[int]bool)
+	shown := make(map[int]bool)
+
+	for i := 0; i < len(questions); {
+		question := questions[i]
+		next := i + 1
+
+		if !showTargets[question.ID] || shown[question.ID] {
+			reachable[question.ID] = true
+
+			for _, rule := range question.Rules {
+				if !rule.Matches(answers[question.ID]) {
+					continue
+				}
+
+				switch rule.Action {
+				case models.RuleShow:
+					shown[rule.FollowUpQuestion] = true
+				case models.RuleSkipTo:
+					target, ok := index[rule.FollowUpQuestion]
+					if rule.FollowUpSection != 0 {
+						target, ok = sectionStart(questions, form.Sections, rule.FollowUpSection)
+					}
+
+					// Only the first fired jump counts and jumps never go backwards
+					if ok && target > i && next == i+1 {
+						next = target
+					}
+				}
+			}
+		}
+
+		i = next
+	}
+
+	return reachable
+}
+
+// sectionStart returns the index of the first question of a section, or of the first question
+// after it when the section is empty. Questions have to be in the order of sections.
+func sectionStart(questions []*models.Question, sections []*models.Section, sectionID int) (int, bool) {
+	rank := sectionRanks(sections)
+
+	target, ok := rank[sectionID]
+	if !ok || sectionID == 0 {
+		return 0, false
+	}
+
+	for i, question := range questions {
+		if rank[question.SectionID] >= target {
+			return i, true
+		}
+	}
+
+	return len(questions), true
+}
+
+// sectionRanks maps the sections of a form to their place in the form. Questions without a
+// section come first, so section 0 ranks before every section.
+func sectionRanks(sections []*models.Section) map[int]int {
+	rank := map[int]int{0: -1}
+	for i, section := range sections {
+		if section.ID != 0 {
+			rank[section.ID] = i
+		}
+	}
+
+	return rank
+}
+
+// validateQuestionRules checks the branching rules of a question against the other questions
+// and the sections of its form
+func validateQuestionRules(source *models.Question, options []string, questions []*models.Question, sections []*models.Section, rules []*models.Extension) error {
+	index := make(map[int]int)
+	for i, question := range questions {
+		index[question.ID] = i
+	}
+
+	sourceIndex, ok := index[source.ID]
+	if !ok {
+		return fmt.Errorf("question %d does not belong to its form", source.ID)
+	}
+
+	for _, rule := range rules {
+		switch rule.Operator {
+		case "":
+			rule.Operator = models.RuleEquals
+		case models.RuleEquals, models.RuleNotEquals, models.RuleAnswered:
+		default:
+			return fmt.Errorf("unknown rule operator %s", rule.Operator)
+		}
+
+		switch rule.Action {
+		case "":
+			rule.Action = models.RuleShow
+		case models.RuleShow, models.RuleSkipTo:
+		default:
+			return fmt.Errorf("unknown rule action %s", rule.Action)
+		}
+
+		if (rule.FollowUpQuestion == 0) == (rule.FollowUpSection == 0) {
+			return errors.New("a rule leads either to a followup_question_id or to a followup_section_id")
+		}
+
+		if rule.FollowUpSection != 0 {
+			if rule.Action != models.RuleSkipTo {
+				return errors.New("only skip_to rules can lead to a section")
+			}
+
+			rank := sectionRanks(sections)
+
+			target, ok := rank[rule.FollowUpSection]
+			if !ok {
+				return fmt.Errorf("section %d is not in the same form", rule.FollowUpSection)
+			}
+
+			if target <= rank[source.SectionID] {
+				return fmt.Errorf("rules can only lead to sections that come after the section of question %d", source.ID)
+			}
+		} else {
+			target, ok := index[rule.FollowUpQuestion]
+			if !ok {
+				return fmt.Errorf("question %d is not in the same form", rule.FollowUpQuestion)
+			}
+
+			if target <= sourceIndex {
+				return fmt.Errorf("rules can only lead to questions that come after question %d", source.ID)
+			}
+		}
+
+		if rule.Operator != models.RuleAnswered && source.HasOptions() && source.Type != models.QuestionGrid && !containsString(options, rule.FollowUpOption) {
+			return fmt.Errorf("%s is not an option of question %d", rule.FollowUpOption, source.ID)
+		}
+	}
+
+	return nil
+}
+
+// orderQuestions checks a new order of the sections and questions of a form and returns
+// the questions in that order. Every section and question of the form has to be listed
+// exactly once and the branching rules still have to lead forward. Questions without a
+// section are always shown first, so their group can only come first.
+func orderQuestions(form *models.Form, order []*models.QuestionOrder) ([]*models.Question, error) {
+	sections := make(map[int]bool)
+	for _, section := range form.Sections {
+		if section.ID != 0 {
+			sections[section.ID] = true
+		}
+	}
+
+	questions := make(map[int]*models.Question)
+	for _, question := range form.Questions {
+		questions[question.ID] = question
+	}
+
+	listedSections := make(map[int]bool)
+	var ordered []*models.Question
+
+	// group of every question and of every section, to check rules leading to sections
+	groupOf := make(map[int]int)
+	groupIndex := make(map[int]int)
+
+	for g, group := range order {
+		if group.SectionID != 0 && !sections[group.SectionID] {
+			return nil, fmt.Errorf("section %d is not in this form", group.SectionID)
+		}
+
+		if listedSections[group.SectionID] {
+			return nil, fmt.Errorf("section %d is listed more than once", group.SectionID)
+		}
+
+		if group.SectionID == 0 && g > 0 {
+			return nil, errors.New("questions without a section (section_id 0) have to be listed before every section")
+		}
+		listedSections[group.SectionID] = true
+		groupIndex[group.SectionID] = g
+
+		for _, questionID := range group.QuestionIDs {
+			question, ok := questions[questionID]
+			if !ok {
+				return nil, fmt.Errorf("question %d is not in this form or is listed more than once", questionID)
+			}
+			delete(questions, questionID)
+			groupOf[questionID] = g
+
+			ordered = append(ordered, question)
+		}
+	}
+
+	for sectionID := range sections {
+		if !listedSections[sectionID] {
+			return nil, fmt.Errorf("section %d is missing from the order", sectionID)
+		}
+	}
+
+	for questionID := range questions {
+		return nil, fmt.Errorf("question %d is missing from the order", questionID)
+	}
+
+	index := make(map[int]int)
+	for i, question := range ordered {
+		index[question.ID] = i
+	}
+
+	for i, question := range ordered {
+		for _, rule := range question.Rules {
+			if target, ok := index[rule.FollowUpQuestion]; ok && target <= i {
+				return nil, fmt.Errorf("question %d has a rule leading to question %d, which would come before it", question.ID, rule.FollowUpQuestion)
+			}
+
+			if target, ok := groupIndex[rule.FollowUpSection]; ok && rule.FollowUpSection != 0 && target <= groupOf[question.ID] {
+				return nil, fmt.Errorf("question %d has a rule leading to section %d, which would not come after it", question.ID, rule.FollowUpSection)
+			}
+		}
+	}
+
+	return ordered, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"alumnihub/internal/models"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// branchingForm builds a form with these questions and sections:
+//
+//	no section: 1 (yes/no/maybe) and 2 (shown when 1 is yes)
+//	section 10: 3 and 4 (required)
+//	section 20: 5
+//	section 30: empty
+//	section 40: 6
+//	section 50: empty
+//
+// Question 1 shows 2 on yes, skips to section 20 on no and to question 6 on anything but yes.
+// Question 3 skips to the empty section 30 once answered. Question 5 has a rule leading
+// backwards to question 1 and skips to the empty last section 50 on "end".
+func branchingForm() *models.Form {
+	return &models.Form{
+		ID: 1,
+		Sections: []*models.Section{
+			{ID: 10}, {ID: 20}, {ID: 30}, {ID: 40}, {ID: 50},
+		},
+		Questions: []*models.Question{
+			{
+				ID:      1,
+				Type:    models.QuestionMultipleChoice,
+				Options: []*models.Option{{Option: "yes"}, {Option: "no"}, {Option: "maybe"}},
+				Rules: []*models.Extension{
+					{FollowUpQuestion: 2, FollowUpOption: "yes", Operator: models.RuleEquals, Action: models.RuleShow},
+					{FollowUpSection: 20, FollowUpOption: "no", Operator: models.RuleEquals, Action: models.RuleSkipTo},
+					{FollowUpQuestion: 6, FollowUpOption: "yes", Operator: models.RuleNotEquals, Action: models.RuleSkipTo},
+				},
+			},
+			{ID: 2, Type: models.QuestionShortAnswer},
+			{
+				ID:        3,
+				Type:      models.QuestionShortAnswer,
+				SectionID: 10,
+				Rules: []*models.Extension{
+					{FollowUpSection: 30, Operator: models.RuleAnswered, Action: models.RuleSkipTo},
+				},
+			},
+			{ID: 4, Type: models.QuestionShortAnswer, SectionID: 10, Required: true},
+			{
+				ID:        5,
+				Type:      models.QuestionShortAnswer,
+				SectionID: 20,
+				Rules: []*models.Extension{
+					{FollowUpQuestion: 1, Operator: models.RuleAnswered, Action: models.RuleSkipTo},
+					{FollowUpSection: 50, FollowUpOption: "end", Operator: models.RuleEquals, Action: models.RuleSkipTo},
+				},
+			},
+			{ID: 6, Type: models.QuestionShortAnswer, SectionID: 40},
+		},
+	}
+}
+
+// reachableIDs returns the reachable question ids in ascending order
+func reachableIDs(reachable map[int]bool) []int {
+	ids := []int{}
+	for id, ok := range reachable {
+		if ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	return ids
+}
+
+func TestReachableQuestions(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers map[int][]string
+		want    []int
+	}{
+		{"no answers hide show targets", nil, []int{1, 3, 4, 5, 6}},
+		{"show rule reveals its target", map[int][]string{1: {"yes"}}, []int{1, 2, 3, 4, 5, 6}},
+		{"first jump wins", map[int][]string{1: {"no"}}, []int{1, 5, 6}},
+		{"jump to a question", map[int][]string{1: {"maybe"}}, []int{1, 6}},
+		{"jump to an empty section lands on the next section", map[int][]string{3: {"x"}}, []int{1, 3, 6}},
+		{"jump to an empty last section ends the form", map[int][]string{5: {"end"}}, []int{1, 3, 4, 5}},
+		{"backward rules are ignored", map[int][]string{5: {"x"}}, []int{1, 3, 4, 5, 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reachableIDs(reachableQuestions(branchingForm(), tt.answers))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reachableQuestions(%v) = %v, want %v", tt.answers, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSectionStart(t *testing.T) {
+	form := branchingForm()
+
+	tests := []struct {
+		name      string
+		sectionID int
+		want      int
+		wantOK    bool
+	}{
+		{"first question of the section", 10, 2, true},
+		{"empty section starts at the next section", 30, 5, true},
+		{"empty last section starts past the end", 50, 6, true},
+		{"no section", 0, 0, false},
+		{"unknown section", 99, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := sectionStart(form.Questions, form.Sections, tt.sectionID)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("sectionStart(%d) = %d, %v, want %d, %v", tt.sectionID, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestValidateSubmissionBranching(t *testing.T) {
+	tests := []struct {
+		name      string
+		answers   []*models.Answer
+		wantErrs  map[int]string
+		wantSaved int
+	}{
+		{
+			"required question on the path",
+			[]*models.Answer{{QuestionID: 1, Answer: "yes"}},
+			map[int]string{4: "this question is required"},
+			1,
+		},
+		{
+			"required question skipped by a jump",
+			[]*models.Answer{{QuestionID: 1, Answer: "no"}, {QuestionID: 5, Answer: "x"}},
+			map[int]string{},
+			2,
+		},
+		{
+			"answer to a skipped question",
+			[]*models.Answer{{QuestionID: 1, Answer: "no"}, {QuestionID: 3, Answer: "x"}},
+			map[int]string{3: "question is not reachable with the given answers"},
+			1,
+		},
+		{
+			"answer to a hidden show target",
+			[]*models.Answer{{QuestionID: 2, Answer: "x"}, {QuestionID: 4, Answer: "x"}},
+			map[int]string{2: "question is not reachable with the given answers"},
+			1,
+		},
+		{
+			"answer to a revealed show target",
+			[]*models.Answer{{QuestionID: 1, Answer: "yes"}, {QuestionID: 2, Answer: "x"}, {QuestionID: 4, Answer: "x"}},
+			map[int]string{},
+			3,
+		},
+		{
+			"question of another form",
+			[]*models.Answer{{QuestionID: 4, Answer: "x"}, {QuestionID: 99, Answer: "x"}},
+			map[int]string{99: "question does not belong to this form"},
+			1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved, errs := validateSubmission(branchingForm(), tt.answers)
+			if !reflect.DeepEqual(errs, tt.wantErrs) {
+				t.Errorf("errors = %v, want %v", errs, tt.wantErrs)
+			}
+
+			if len(saved) != tt.wantSaved {
+				t.Errorf("saved %d answers, want %d", len(saved), tt.wantSaved)
+			}
+		})
+	}
+}
+
+// orderForm has question 1 without a section, questions 2 and 3 in section 10 and question 4
+// in section 20. Question 2 skips to section 20 and question 3 shows question 4.
+func orderForm() *models.Form {
+	return &models.Form{
+		ID:       1,
+		Sections: []*models.Section{{ID: 10}, {ID: 20}},
+		Questions: []*models.Question{
+			{ID: 1},
+			{ID: 2, SectionID: 10, Rules: []*models.Extension{{FollowUpSection: 20, Action: models.RuleSkipTo}}},
+			{ID: 3, SectionID: 10, Rules: []*models.Extension{{FollowUpQuestion: 4, Action: models.RuleShow}}},
+			{ID: 4, SectionID: 20},
+		},
+	}
+}
+
+func TestOrderQuestions(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   []*models.QuestionOrder
+		want    []int
+		wantErr string
+	}{
+		{
+			"same order",
+			[]*models.QuestionOrder{{SectionID: 0, QuestionIDs: []int{1}}, {SectionID: 10, QuestionIDs: []int{2, 3}}, {SectionID: 20, QuestionIDs: []int{4}}},
+			[]int{1, 2, 3, 4},
+			"",
+		},
+		{
+			"questions swapped within a section",
+			[]*models.QuestionOrder{{SectionID: 0, QuestionIDs: []int{1}}, {SectionID: 10, QuestionIDs: []int{3, 2}}, {SectionID: 20, QuestionIDs: []int{4}}},
+			[]int{1, 3, 2, 4},
+			"",
+		},
+		{
+			"question moved into a section",
+			[]*models.QuestionOrder{{SectionID: 10, QuestionIDs: []int{1, 2, 3}}, {SectionID: 20, QuestionIDs: []int{4}}},
+			[]int{1, 2, 3, 4},
+			"",
+		},
+		{
+			"section 0 not first",
+			[]*models.QuestionOrder{{SectionID: 10, QuestionIDs: []int{2, 3}}, {SectionID: 0, QuestionIDs: []int{1}}, {SectionID: 20, QuestionIDs: []int{4}}},
+			nil,
+			"questions without a section (section_id 0) have to be listed before every section",
+		},
+		{
+			"section listed twice",
+			[]*models.QuestionOrder{{SectionID: 0, QuestionIDs: []int{1}}, {SectionID: 10, QuestionIDs: []int{2}}, {SectionID: 10, QuestionIDs: []int{3}}, {SectionID: 20, QuestionIDs: []int{4}}},
+			nil,
+			"section 10 is listed more than once",
+		},
+		{
+			"section of another form",
+			[]*models.QuestionOrder{{SectionID: 0, QuestionIDs: []int{1}}, {SectionID: 99, QuestionIDs: []int{2, 3, 4}}},
+			nil,
+			"section 99 is not in this form",
+		},
+		{
+			"section missing",
+			[]*models.QuestionOrder{{SectionID: 0, QuestionIDs: []int{1}}, {SectionID: 10, QuestionIDs: []int{2, 3, 4}}},
+			nil,
+			"section 20 is missing from the order",
+		},
+		{
+			"question listed twice",
+			[]*models.QuestionOrder{{SectionID: 0, QuestionIDs: []int{1, 1}}, {SectionID: 10, QuestionIDs: []int{2, 3}}, {SectionID: 20, QuestionIDs: []int{4}}},
+			nil,
+			"question 1 is not in this form or is listed more than once",
+		},
+		{
+			"question of another form",
+			[]*models.QuestionOrder{{SectionID: 0, QuestionIDs: []int{1, 99}}, {SectionID: 10, QuestionIDs: []int{2, 3}}, {SectionID: 20, QuestionIDs: []int{4}}},
+			nil,
+			"question 99 is not in this form or is listed more than once",
+		},
+		{
+			"question missing",
+			[]*models.QuestionOrder{{SectionID: 0, QuestionIDs: []int{1}}, {SectionID: 10, QuestionIDs: []int{2, 3}}, {SectionID: 20}},
+			nil,
+			"question 4 is missing from the order",
+		},
+		{
+			"rule would lead back to a question",
+			[]*models.QuestionOrder{{SectionID: 0, QuestionIDs: []int{1}}, {SectionID: 10, QuestionIDs: []int{2}}, {SectionID: 20, QuestionIDs: []int{4, 3}}},
+			nil,
+			"question 3 has a rule leading to question 4, which would come before it",
+		},
+		{
+			"rule would lead back to a section",
+			[]*models.QuestionOrder{{SectionID: 0, QuestionIDs: []int{1}}, {SectionID: 20, QuestionIDs: []int{4}}, {SectionID: 10, QuestionIDs: []int{2, 3}}},
+			nil,
+			"question 2 has a rule leading to section 20, which would not come after it",
+		},
+		{
+			"rule would lead to its own section",
+			[]*models.QuestionOrder{{SectionID: 0, QuestionIDs: []int{1}}, {SectionID: 10, QuestionIDs: []int{3}}, {SectionID: 20, QuestionIDs: []int{2, 4}}},
+			nil,
+			"question 2 has a rule leading to section 20, which would not come after it",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ordered, err := orderQuestions(orderForm(), tt.order)
+
+			gotErr := ""
+			if err != nil {
+				gotErr = err.Error()
+			}
+
+			if gotErr != tt.wantErr {
+				t.Fatalf("orderQuestions error = %q, want %q", gotErr, tt.wantErr)
+			}
+
+			var got []int
+			for _, question := range ordered {
+				got = append(got, question.ID)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("orderQuestions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
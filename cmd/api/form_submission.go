@@ -0,0 +1,292 @@
+package main
+
+import (
+	"alumnihub/internal/models"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxShortAnswerLength = 255
+	maxLongAnswerLength  = 5000
+	defaultScaleMin      = 1
+	defaultScaleMax      = 5
+	maxScalePoints       = 11
+)
+
+// submittedAnswer is the cleaned up answer to one question of a submission
+type submittedAnswer struct {
+	values []string
+	grid   map[string]string
+}
+
+// readSubmittedAnswer collects the trimmed, non-empty values of an answer
+func readSubmittedAnswer(question *models.Question, answer *models.Answer) submittedAnswer {
+	var submitted submittedAnswer
+
+	switch question.Type {
+	case models.QuestionCheckboxes:
+		values := answer.Values
+		if len(values) == 0 && answer.Answer != "" {
+			values = []string{answer.Answer}
+		}
+		for _, value := range values {
+			if value = strings.TrimSpace(value); value != "" {
+				submitted.values = append(submitted.values, value)
+			}
+		}
+	case models.QuestionGrid:
+		submitted.grid = make(map[string]string)
+		for row, value := range answer.Grid {
+			if value = strings.TrimSpace(value); value != "" {
+				submitted.grid[strings.TrimSpace(row)] = value
+				submitted.values = append(submitted.values, value)
+			}
+		}
+	default:
+		if value := strings.TrimSpace(answer.Answer); value != "" {
+			submitted.values = []string{value}
+		}
+	}
+
+	return submitted
+}
+
+// validateDraft checks that the answers of a draft belong to the form. Drafts are not
+// complete yet, so required questions and the answers themselves are checked on submit.
+func validateDraft(form *models.Form, answers []*models.Answer) map[int]string {
+	errs := make(map[int]string)
+
+	questions := make(map[int]bool)
+	for _, question := range form.Questions {
+		questions[question.ID] = true
+	}
+
+	given := make(map[int]bool)
+	for _, answer := range answers {
+		if !questions[answer.QuestionID] {
+			errs[answer.QuestionID] = "question does not belong to this form"
+			continue
+		}
+
+		if given[answer.QuestionID] {
+			errs[answer.QuestionID] = "question is answered more than once"
+			continue
+		}
+		given[answer.QuestionID] = true
+	}
+
+	return errs
+}
+
+// validateSubmission checks the answers against the questions of the form. It returns the
+// answers to save and an error message per question id. Questions the branching rules do not
+// reach are never required and cannot be answered.
+func validateSubmission(form *models.Form, answers []*models.Answer) ([]*models.Answer, map[int]string) {
+	errs := make(map[int]string)
+
+	questions := make(map[int]*models.Question)
+	for _, question := range form.Questions {
+		questions[question.ID] = question
+	}
+
+	given := make(map[int]submittedAnswer)
+	for _, answer := range answers {
+		question, ok := questions[answer.QuestionID]
+		if !ok {
+			errs[answer.QuestionID] = "question does not belong to this form"
+			continue
+		}
+
+		if _, ok := given[answer.QuestionID]; ok {
+			errs[answer.QuestionID] = "question is answered more than once"
+			continue
+		}
+
+		given[answer.QuestionID] = readSubmittedAnswer(question, answer)
+	}
+
+	values := make(map[int][]string)
+	for questionID, answer := range given {
+		values[questionID] = answer.values
+	}
+
+	reachable := reachableQuestions(form, values)
+
+	var valid []*models.Answer
+
+	for _, question := range form.Questions {
+		answer := given[question.ID]
+
+		if !reachable[question.ID] {
+			if len(answer.values) > 0 {
+				errs[question.ID] = "question is not reachable with the given answers"
+			}
+			continue
+		}
+
+		if len(answer.values) == 0 {
+			if question.Required {
+				errs[question.ID] = "this question is required"
+			}
+			continue
+		}
+
+		err := validateAnswer(question, answer)
+		if err != nil {
+			errs[question.ID] = err.Error()
+			continue
+		}
+
+		if question.Type == models.QuestionGrid {
+			for _, row := range question.Settings.Rows {
+				if value, ok := answer.grid[row]; ok {
+					valid = append(valid, &models.Answer{FormID: form.ID, QuestionID: question.ID, Answer: value, Row: row})
+				}
+			}
+			continue
+		}
+
+		for _, value := range answer.values {
+			valid = append(valid, &models.Answer{FormID: form.ID, QuestionID: question.ID, Answer: value})
+		}
+	}
+
+	return valid, errs
+}
+
+// validateAnswer checks a non-empty answer against the type and settings of its question
+func validateAnswer(question *models.Question, answer submittedAnswer) error {
+	isOption := func(value string) bool {
+		for _, option := range question.Options {
+			if option.Option == value {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch question.Type {
+	case models.QuestionCheckboxes:
+		seen := make(map[string]bool)
+		for _, value := range answer.values {
+			if !isOption(value) {
+				return fmt.Errorf("%s is not one of the options", value)
+			}
+			if seen[value] {
+				return fmt.Errorf("%s is picked more than once", value)
+			}
+			seen[value] = true
+		}
+		return nil
+	case models.QuestionGrid:
+		for row, value := range answer.grid {
+			if !containsString(question.Settings.Rows, row) {
+				return fmt.Errorf("%s is not a row of this question", row)
+			}
+			if !isOption(value) {
+				return fmt.Errorf("%s is not one of the columns", value)
+			}
+		}
+		if question.Required && len(answer.grid) < len(question.Settings.Rows) {
+			return errors.New("every row must be answered")
+		}
+		return nil
+	}
+
+	if len(answer.values) != 1 {
+		return errors.New("only one answer is allowed")
+	}
+	value := answer.values[0]
+
+	switch question.Type {
+	case models.QuestionMultipleChoice, models.QuestionDropdown:
+		if !isOption(value) {
+			return errors.New("answer is not one of the options")
+		}
+	case models.QuestionShortAnswer:
+		if utf8.RuneCountInString(value) > maxShortAnswerLength {
+			return fmt.Errorf("answer cannot be longer than %d characters", maxShortAnswerLength)
+		}
+	case models.QuestionLongAnswer:
+		if utf8.RuneCountInString(value) > maxLongAnswerLength {
+			return fmt.Errorf("answer cannot be longer than %d characters", maxLongAnswerLength)
+		}
+	case models.QuestionLinearScale:
+		n, err := strconv.Atoi(value)
+		min, max := scaleBounds(question.Settings)
+		if err != nil || n < min || n > max {
+			return fmt.Errorf("answer must be a whole number from %d to %d", min, max)
+		}
+	case models.QuestionNumber:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return errors.New("answer must be a number")
+		}
+		if question.Settings.Min != nil && n < *question.Settings.Min {
+			return fmt.Errorf("answer cannot be less than %v", *question.Settings.Min)
+		}
+		if question.Settings.Max != nil && n > *question.Settings.Max {
+			return fmt.Errorf("answer cannot be more than %v", *question.Settings.Max)
+		}
+	case models.QuestionDate:
+		_, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return errors.New("answer must be a date formatted as YYYY-MM-DD")
+		}
+	}
+
+	return nil
+}
+
+// scaleBounds returns the first and last point of a linear scale
+func scaleBounds(settings models.QuestionSettings) (int, int) {
+	min, max := defaultScaleMin, defaultScaleMax
+	if settings.Min != nil {
+		min = int(*settings.Min)
+	}
+	if settings.Max != nil {
+		max = int(*settings.Max)
+	}
+
+	return min, max
+}
+
+// validateQuestion checks that a question is complete for its type before it is saved
+func validateQuestion(question *models.Question) error {
+	switch question.Type {
+	case models.QuestionMultipleChoice, models.QuestionCheckboxes, models.QuestionDropdown, models.QuestionShortAnswer,
+		models.QuestionLongAnswer, models.QuestionLinearScale, models.QuestionDate, models.QuestionNumber, models.QuestionGrid:
+	default:
+		return fmt.Errorf("unknown question type %s", question.Type)
+	}
+
+	if question.HasOptions() && len(question.OptionsArray) == 0 {
+		return errors.New("question needs at least one option")
+	}
+
+	settings := question.Settings
+
+	switch question.Type {
+	case models.QuestionGrid:
+		if len(settings.Rows) == 0 {
+			return errors.New("grid question needs at least one row")
+		}
+	case models.QuestionLinearScale:
+		min, max := scaleBounds(settings)
+		if min >= max || max-min+1 > maxScalePoints {
+			return fmt.Errorf("linear scale needs between 2 and %d points", maxScalePoints)
+		}
+	case models.QuestionNumber:
+		if settings.Min != nil && settings.Max != nil && *settings.Min > *settings.Max {
+			return errors.New("minimum cannot be more than maximum")
+		}
+	}
+
+	return nil
+}
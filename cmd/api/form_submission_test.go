@@ -0,0 +1,127 @@
+package main
+
+import (
+	"alumnihub/internal/models"
+	"testing"
+)
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func optionsOf(values ...string) []*models.Option {
+	var opts []*models.Option
+	for _, value := range values {
+		opts = append(opts, &models.Option{Option: value})
+	}
+
+	return opts
+}
+
+func TestValidateAnswer(t *testing.T) {
+	scale := &models.Question{Type: models.QuestionLinearScale}
+	wideScale := &models.Question{Type: models.QuestionLinearScale, Settings: models.QuestionSettings{Min: floatPtr(0), Max: floatPtr(10)}}
+	number := &models.Question{Type: models.QuestionNumber, Settings: models.QuestionSettings{Min: floatPtr(-1), Max: floatPtr(100)}}
+	date := &models.Question{Type: models.QuestionDate}
+	checkboxes := &models.Question{Type: models.QuestionCheckboxes, Options: optionsOf("a", "b")}
+	grid := &models.Question{
+		Type:     models.QuestionGrid,
+		Options:  optionsOf("good", "bad"),
+		Settings: models.QuestionSettings{Rows: []string{"food", "place"}},
+	}
+	requiredGrid := &models.Question{
+		Type:     models.QuestionGrid,
+		Required: true,
+		Options:  optionsOf("good", "bad"),
+		Settings: models.QuestionSettings{Rows: []string{"food", "place"}},
+	}
+
+	tests := []struct {
+		name     string
+		question *models.Question
+		answer   *models.Answer
+		wantErr  string
+	}{
+		{"scale within default bounds", scale, &models.Answer{Answer: "5"}, ""},
+		{"scale below default bounds", scale, &models.Answer{Answer: "0"}, "answer must be a whole number from 1 to 5"},
+		{"scale above default bounds", scale, &models.Answer{Answer: "6"}, "answer must be a whole number from 1 to 5"},
+		{"scale with a fraction", scale, &models.Answer{Answer: "2.5"}, "answer must be a whole number from 1 to 5"},
+		{"scale within set bounds", wideScale, &models.Answer{Answer: "0"}, ""},
+		{"scale above set bounds", wideScale, &models.Answer{Answer: "11"}, "answer must be a whole number from 0 to 10"},
+		{"number", number, &models.Answer{Answer: "12.5"}, ""},
+		{"number at the minimum", number, &models.Answer{Answer: "-1"}, ""},
+		{"number below the minimum", number, &models.Answer{Answer: "-1.5"}, "answer cannot be less than -1"},
+		{"number above the maximum", number, &models.Answer{Answer: "101"}, "answer cannot be more than 100"},
+		{"number that is not a number", number, &models.Answer{Answer: "twelve"}, "answer must be a number"},
+		{"NaN", number, &models.Answer{Answer: "NaN"}, "answer must be a number"},
+		{"infinity", number, &models.Answer{Answer: "Inf"}, "answer must be a number"},
+		{"overflow to infinity", number, &models.Answer{Answer: "1e400"}, "answer must be a number"},
+		{"date", date, &models.Answer{Answer: "2024-02-29"}, ""},
+		{"date that does not exist", date, &models.Answer{Answer: "2023-02-29"}, "answer must be a date formatted as YYYY-MM-DD"},
+		{"date in another format", date, &models.Answer{Answer: "29/02/2024"}, "answer must be a date formatted as YYYY-MM-DD"},
+		{"date with a time", date, &models.Answer{Answer: "2024-02-29T10:00:00Z"}, "answer must be a date formatted as YYYY-MM-DD"},
+		{"checkboxes", checkboxes, &models.Answer{Values: []string{"a", "b"}}, ""},
+		{"checkbox picked twice", checkboxes, &models.Answer{Values: []string{"a", " a"}}, "a is picked more than once"},
+		{"checkbox that is not an option", checkboxes, &models.Answer{Values: []string{"c"}}, "c is not one of the options"},
+		{"grid", grid, &models.Answer{Grid: map[string]string{"food": "good", "place": "bad"}}, ""},
+		{"optional grid with a row left out", grid, &models.Answer{Grid: map[string]string{"food": "good"}}, ""},
+		{"grid with a column as row", grid, &models.Answer{Grid: map[string]string{"good": "good"}}, "good is not a row of this question"},
+		{"grid with a row as column", grid, &models.Answer{Grid: map[string]string{"food": "place"}}, "place is not one of the columns"},
+		{"required grid", requiredGrid, &models.Answer{Grid: map[string]string{"food": "good", "place": "good"}}, ""},
+		{"required grid with a row left out", requiredGrid, &models.Answer{Grid: map[string]string{"food": "good"}}, "every row must be answered"},
+		{"required grid with a blank row", requiredGrid, &models.Answer{Grid: map[string]string{"food": "good", "place": " "}}, "every row must be answered"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAnswer(tt.question, readSubmittedAnswer(tt.question, tt.answer))
+
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+
+			if got != tt.wantErr {
+				t.Errorf("validateAnswer = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateQuestion(t *testing.T) {
+	tests := []struct {
+		name     string
+		question *models.Question
+		wantErr  string
+	}{
+		{"unknown type", &models.Question{Type: "file_upload"}, "unknown question type file_upload"},
+		{"short answer", &models.Question{Type: models.QuestionShortAnswer}, ""},
+		{"choice without options", &models.Question{Type: models.QuestionDropdown}, "question needs at least one option"},
+		{"choice with options", &models.Question{Type: models.QuestionDropdown, OptionsArray: []string{"a"}}, ""},
+		{"grid without columns", &models.Question{Type: models.QuestionGrid, Settings: models.QuestionSettings{Rows: []string{"r"}}}, "question needs at least one option"},
+		{"grid without rows", &models.Question{Type: models.QuestionGrid, OptionsArray: []string{"c"}}, "grid question needs at least one row"},
+		{"grid", &models.Question{Type: models.QuestionGrid, OptionsArray: []string{"c"}, Settings: models.QuestionSettings{Rows: []string{"r"}}}, ""},
+		{"default scale", &models.Question{Type: models.QuestionLinearScale}, ""},
+		{"scale from 0 to 10", &models.Question{Type: models.QuestionLinearScale, Settings: models.QuestionSettings{Min: floatPtr(0), Max: floatPtr(10)}}, ""},
+		{"scale with too many points", &models.Question{Type: models.QuestionLinearScale, Settings: models.QuestionSettings{Min: floatPtr(0), Max: floatPtr(11)}}, "linear scale needs between 2 and 11 points"},
+		{"scale with one point", &models.Question{Type: models.QuestionLinearScale, Settings: models.QuestionSettings{Min: floatPtr(3), Max: floatPtr(3)}}, "linear scale needs between 2 and 11 points"},
+		{"scale upside down", &models.Question{Type: models.QuestionLinearScale, Settings: models.QuestionSettings{Min: floatPtr(5), Max: floatPtr(1)}}, "linear scale needs between 2 and 11 points"},
+		{"number bounds", &models.Question{Type: models.QuestionNumber, Settings: models.QuestionSettings{Min: floatPtr(1), Max: floatPtr(1)}}, ""},
+		{"number bounds upside down", &models.Question{Type: models.QuestionNumber, Settings: models.QuestionSettings{Min: floatPtr(2), Max: floatPtr(1)}}, "minimum cannot be more than maximum"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateQuestion(tt.question)
+
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+
+			if got != tt.wantErr {
+				t.Errorf("validateQuestion = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
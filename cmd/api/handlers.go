@@ -2,7 +2,9 @@ package main
 
 import (
 	"alumnihub/internal/models"
+	"alumnihub/internal/repository"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -15,8 +17,6 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/golang-jwt/jwt/v4"
-	"github.com/xuri/excelize/v2"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -66,6 +66,32 @@ func (app *application) Dashboard(w http.ResponseWriter, r *http.Request) {
 	_ = app.writeJSON(w, http.StatusOK, payload)
 }
 
+func (app *application) search(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if len([]rune(query)) < 2 {
+		app.errorJSON(w, errors.New("search query must be at least 2 characters"))
+		return
+	}
+
+	limit := 5
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 || n > 20 {
+			app.errorJSON(w, errors.New("limit must be between 1 and 20"))
+			return
+		}
+		limit = n
+	}
+
+	result, err := app.DB.Search(query, limit)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, result)
+}
+
 func (app *application) profile(w http.ResponseWriter, r *http.Request) {
 	username := chi.URLParam(r, "username")
 
@@ -135,23 +161,16 @@ func (app *application) profile(w http.ResponseWriter, r *http.Request) {
 }
 
 func (app *application) myProfile(w http.ResponseWriter, r *http.Request) {
-	// Ambil klaim dari konteks menggunakan tipe kunci khusus
-	claims, ok := r.Context().Value(userClaimsKey).(*Claims)
-	if !ok {
-		app.errorJSON(w, errors.New("no claims in context"))
-		return
-	}
-
-	// Konversi userID dari string ke int
-	userID, err := strconv.Atoi(claims.Subject)
+	principal, err := currentUser(r)
 	if err != nil {
-		app.errorJSON(w, errors.New("invalid user ID in token"))
+		app.errorJSON(w, err, http.StatusUnauthorized)
 		return
 	}
+	userID := principal.UserID
 
 	var profile models.Profile
 
-	if !claims.IsAdmin {
+	if !principal.IsAdmin {
 		profileAlumni, err := app.DB.GetProfileByUserID(userID)
 		if err != nil {
 			app.errorJSON(w, err)
@@ -209,19 +228,12 @@ func (app *application) myProfile(w http.ResponseWriter, r *http.Request) {
 }
 
 func (app *application) updateProfile(w http.ResponseWriter, r *http.Request) {
-	// Ambil klaim dari konteks menggunakan tipe kunci khusus
-	claims, ok := r.Context().Value(userClaimsKey).(*Claims)
-	if !ok {
-		app.errorJSON(w, errors.New("no claims in context"))
-		return
-	}
-
-	// Konversi userID dari string ke int
-	userID, err := strconv.Atoi(claims.Subject)
+	principal, err := currentUser(r)
 	if err != nil {
-		app.errorJSON(w, errors.New("invalid user ID in token"))
+		app.errorJSON(w, err, http.StatusUnauthorized)
 		return
 	}
+	userID := principal.UserID
 
 	var payload models.Profile
 	var profile models.Profile
@@ -232,7 +244,7 @@ func (app *application) updateProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !claims.IsAdmin {
+	if !principal.IsAdmin {
 		profileAlumni, err := app.DB.GetProfileByUserID(userID)
 		if err != nil {
 			app.errorJSON(w, err)
@@ -265,7 +277,7 @@ func (app *application) updateProfile(w http.ResponseWriter, r *http.Request) {
 	profile.Tiktok = payload.Tiktok
 	profile.Photo = payload.Photo
 
-	if !claims.IsAdmin {
+	if !principal.IsAdmin {
 		err = app.DB.UpdateProfile(profile)
 		if err != nil {
 			app.errorJSON(w, err)
@@ -288,19 +300,12 @@ func (app *application) updateProfile(w http.ResponseWriter, r *http.Request) {
 }
 
 func (app *application) insertAlumniEducation(w http.ResponseWriter, r *http.Request) {
-	// Ambil klaim dari konteks menggunakan tipe kunci khusus
-	claims, ok := r.Context().Value(userClaimsKey).(*Claims)
-	if !ok {
-		app.errorJSON(w, errors.New("no claims in context"))
-		return
-	}
-
-	// Konversi userID dari string ke int
-	userID, err := strconv.Atoi(claims.Subject)
+	principal, err := currentUser(r)
 	if err != nil {
-		app.errorJSON(w, errors.New("invalid user ID in token"))
+		app.errorJSON(w, err, http.StatusUnauthorized)
 		return
 	}
+	userID := principal.UserID
 
 	var education models.AlumniEducation
 
@@ -329,17 +334,9 @@ func (app *application) insertAlumniEducation(w http.ResponseWriter, r *http.Req
 }
 
 func (app *application) deleteAlumniEducation(w http.ResponseWriter, r *http.Request) {
-	// Ambil klaim dari konteks menggunakan tipe kunci khusus
-	claims, ok := r.Context().Value(userClaimsKey).(*Claims)
-	if !ok {
-		app.errorJSON(w, errors.New("no claims in context"))
-		return
-	}
-
-	// Konversi userID dari string ke int
-	userID, err := strconv.Atoi(claims.Subject)
+	principal, err := currentUser(r)
 	if err != nil {
-		app.errorJSON(w, errors.New("invalid user ID in token"))
+		app.errorJSON(w, err, http.StatusUnauthorized)
 		return
 	}
 
@@ -352,12 +349,16 @@ func (app *application) deleteAlumniEducation(w http.ResponseWriter, r *http.Req
 
 	education, err := app.DB.GetAlumniEducation(educationID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("education section not found"), http.StatusNotFound)
+			return
+		}
 		app.errorJSON(w, err)
 		return
 	}
 
-	if userID != education.UserID {
-		app.errorJSON(w, errors.New("user have no permissions"))
+	if !principal.CanModify(education.UserID, models.PermUsersManage) {
+		app.errorJSON(w, errors.New("you can only delete your own education sections"), http.StatusForbidden)
 		return
 	}
 
@@ -376,19 +377,12 @@ func (app *application) deleteAlumniEducation(w http.ResponseWriter, r *http.Req
 }
 
 func (app *application) insertAlumniJob(w http.ResponseWriter, r *http.Request) {
-	// Ambil klaim dari konteks menggunakan tipe kunci khusus
-	claims, ok := r.Context().Value(userClaimsKey).(*Claims)
-	if !ok {
-		app.errorJSON(w, errors.New("no claims in context"))
-		return
-	}
-
-	// Konversi userID dari string ke int
-	userID, err := strconv.Atoi(claims.Subject)
+	principal, err := currentUser(r)
 	if err != nil {
-		app.errorJSON(w, errors.New("invalid user ID in token"))
+		app.errorJSON(w, err, http.StatusUnauthorized)
 		return
 	}
+	userID := principal.UserID
 
 	var alumnijob models.AlumniJob
 
@@ -417,17 +411,9 @@ func (app *application) insertAlumniJob(w http.ResponseWriter, r *http.Request)
 }
 
 func (app *application) deleteAlumniJob(w http.ResponseWriter, r *http.Request) {
-	// Ambil klaim dari konteks menggunakan tipe kunci khusus
-	claims, ok := r.Context().Value(userClaimsKey).(*Claims)
-	if !ok {
-		app.errorJSON(w, errors.New("no claims in context"))
-		return
-	}
-
-	// Konversi userID dari string ke int
-	userID, err := strconv.Atoi(claims.Subject)
+	principal, err := currentUser(r)
 	if err != nil {
-		app.errorJSON(w, errors.New("invalid user ID in token"))
+		app.errorJSON(w, err, http.StatusUnauthorized)
 		return
 	}
 
@@ -440,12 +426,16 @@ func (app *application) deleteAlumniJob(w http.ResponseWriter, r *http.Request)
 
 	alumnijob, err := app.DB.GetAlumniJob(jobID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("job section not found"), http.StatusNotFound)
+			return
+		}
 		app.errorJSON(w, err)
 		return
 	}
 
-	if userID != alumnijob.UserID {
-		app.errorJSON(w, errors.New("user have no permissions"))
+	if !principal.CanModify(alumnijob.UserID, models.PermUsersManage) {
+		app.errorJSON(w, errors.New("you can only delete your own job sections"), http.StatusForbidden)
 		return
 	}
 
@@ -467,13 +457,25 @@ func (app *application) deleteAlumniJob(w http.ResponseWriter, r *http.Request)
 // Handler Alumni
 // //////////////////
 func (app *application) AllAlumni(w http.ResponseWriter, r *http.Request) {
-	alumni, err := app.DB.AllAlumni()
+	opts, err := app.readQueryOptions(r, "graduation_year", "class", "gender", "has_account")
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	_ = app.writeJSON(w, http.StatusOK, alumni)
+	alumni, total, err := app.DB.AllAlumni(opts)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	resp := JSONResponse{
+		Error: false,
+		Data:  alumni,
+		Meta:  models.NewPagination(opts, total),
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, resp)
 }
 
 func (app *application) Alumni(w http.ResponseWriter, r *http.Request) {
@@ -518,12 +520,14 @@ func (app *application) insertAlumni(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = app.DB.InsertAlumni(alumni)
+	alumni.ID, err = app.DB.InsertAlumni(alumni)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
+	app.audit(r, models.AuditCreate, "alumni", alumni.ID, nil, alumni)
+
 	message := "New alumni has been successfully added"
 
 	resp := JSONResponse{
@@ -535,73 +539,128 @@ func (app *application) insertAlumni(w http.ResponseWriter, r *http.Request) {
 }
 
 func (app *application) importAlumni(w http.ResponseWriter, r *http.Request) {
-	file, _, err := r.FormFile("file")
+	err := r.ParseMultipartForm(10 << 20) // 10 MB max file size
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	file, handler, err := r.FormFile("file")
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 	defer file.Close()
 
-	// Read the Excel file
-	f, err := excelize.OpenReader(file)
+	records, err := readImportRecords(file, handler.Filename, r.FormValue("sheet"))
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	rows, err := f.GetRows("Sheet1") // Assuming data is in Sheet1
+	rows, err := parseImportRows(records)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	var alumniList []models.Alumni
+	preview, err := app.checkImportRows(rows)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
 
-	for _, row := range rows[1:] { // Skipping header row
-		year, err := strconv.Atoi(row[5])
-		if err != nil {
-			log.Printf("Skipping row due to invalid graduation year: %v", row)
-			continue
-		}
+	app.writeJSON(w, http.StatusAccepted, preview)
+}
 
-		alumni := models.Alumni{
-			NISN:   row[0],
-			NIS:    row[1],
-			Name:   row[2],
-			Gender: row[3],
-			Phone:  row[4],
-			Year:   year,
-			Class:  row[6],
-		}
+// checkImportRows marks duplicates against the stored NISNs and NIS and summarizes the rows
+func (app *application) checkImportRows(rows []*models.AlumniImportRow) (models.AlumniImportPreview, error) {
+	nisns := make([]string, 0, len(rows))
+	nis := make([]string, 0, len(rows))
+	for _, row := range rows {
+		nisns = append(nisns, row.Alumni.NISN)
+		nis = append(nis, row.Alumni.NIS)
+	}
+
+	existing, err := app.DB.GetExistingNISNs(nisns)
+	if err != nil {
+		return models.AlumniImportPreview{}, err
+	}
 
-		alumniList = append(alumniList, alumni)
+	existingNIS, err := app.DB.GetExistingNIS(nis)
+	if err != nil {
+		return models.AlumniImportPreview{}, err
 	}
 
-	app.writeJSON(w, http.StatusAccepted, alumniList)
+	markImportDuplicates(rows, existing, existingNIS)
+
+	return buildImportPreview(rows), nil
 }
 
 func (app *application) insertImportAlumni(w http.ResponseWriter, r *http.Request) {
 	var alumniList []models.Alumni
 
-	err := app.readJSON(w, r, &alumniList)
+	// An import can be far bigger than the 1 MB accepted by readJSON
+	r.Body = http.MaxBytesReader(w, r.Body, 10<<20)
+	err := json.NewDecoder(r.Body).Decode(&alumniList)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	count := 0
+	if len(alumniList) == 0 {
+		app.errorJSON(w, errors.New("there is no alumni to import"))
+		return
+	}
+
+	upsert := r.URL.Query().Get("mode") == "upsert"
 
-	for _, alumni := range alumniList {
-		err = app.DB.InsertAlumni(alumni)
-		if err != nil {
-			app.errorJSON(w, err)
-			return
+	// Validate again, the preview could have been edited by the client
+	rows := make([]*models.AlumniImportRow, 0, len(alumniList))
+	for i, alumni := range alumniList {
+		alumni.Gender = normalizeGender(alumni.Gender)
+		alumniList[i] = alumni
+
+		rows = append(rows, &models.AlumniImportRow{
+			Row:    i + 1,
+			Alumni: alumni,
+			Errors: validateAlumni(alumni),
+		})
+	}
+
+	preview, err := app.checkImportRows(rows)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if preview.Invalid > 0 || (!upsert && preview.Duplicates > 0) {
+		resp := JSONResponse{
+			Error:   true,
+			Message: fmt.Sprintf("%d alumni tidak valid dan %d alumni sudah terdaftar, tidak ada data yang disimpan", preview.Invalid, preview.Duplicates),
+			Data:    preview,
 		}
+		app.writeJSON(w, http.StatusUnprocessableEntity, resp)
+		return
+	}
 
-		count++
+	inserted, updated, err := app.DB.ImportAlumni(alumniList, upsert)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
 	}
 
-	message := fmt.Sprintf("%d alumni berhasil ditambahkan", count)
+	app.audit(r, models.AuditImport, "alumni", 0, nil, map[string]interface{}{
+		"upsert":   upsert,
+		"inserted": inserted,
+		"updated":  updated,
+		"alumni":   alumniList,
+	})
+
+	message := fmt.Sprintf("%d alumni berhasil ditambahkan", inserted)
+	if upsert {
+		message = fmt.Sprintf("%d alumni berhasil ditambahkan, %d alumni diperbarui", inserted, updated)
+	}
 
 	resp := JSONResponse{
 		Error:   false,
@@ -633,6 +692,8 @@ func (app *application) updateAlumni(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	before := *alumni
+
 	alumni.Name = payload.Name
 	alumni.Gender = payload.Gender
 	alumni.Phone = payload.Phone
@@ -647,6 +708,8 @@ func (app *application) updateAlumni(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	app.audit(r, models.AuditUpdate, "alumni", alumniID, before, alumni)
+
 	resp := JSONResponse{
 		Error:   false,
 		Message: "Alumni has been successfully updated",
@@ -662,12 +725,24 @@ func (app *application) deleteAlumni(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	before, err := app.DB.Alumni(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("alumni not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
 	err = app.DB.DeleteAlumni(id)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
+	app.audit(r, models.AuditDelete, "alumni", id, before, nil)
+
 	resp := JSONResponse{
 		Error:   false,
 		Message: "Alumni has been permanently deleted",
@@ -679,8 +754,98 @@ func (app *application) deleteAlumni(w http.ResponseWriter, r *http.Request) {
 // //////////////////
 // Handler Articles
 // //////////////////
+
+// isArticleEditor reports whether the principal sees unpublished articles
+func isArticleEditor(principal *Principal) bool {
+	return principal.IsAdmin || principal.HasPermission(models.PermArticlesWrite)
+}
+
+// articleSlug turns base into a slug no other article uses, excluding the article being edited
+func (app *application) articleSlug(base string, articleID int) (string, error) {
+	slug := slugify(base)
+	if slug == "" {
+		return "", errors.New("the title or slug needs letters or digits to build a slug from")
+	}
+
+	return app.DB.UniqueArticleSlug(slug, articleID)
+}
+
+// maxSlugAttempts is how often a new article is saved with the next free slug when
+// another article took its slug at the same time
+const maxSlugAttempts = 3
+
+// relatedArticlesLimit is the number of related articles shown below an article
+const relatedArticlesLimit = 5
+
+// maxTagLength matches the size of the name and slug columns of tags
+const maxTagLength = 64
+
+// articleTaxonomy checks the categories and tags sent with an article and turns the tag
+// names into tags, the same tag given twice is kept once
+func (app *application) articleTaxonomy(payload models.Article) ([]*models.Tag, error) {
+	if len(payload.CategoryIDs) > 0 {
+		categories, err := app.DB.AllCategories()
+		if err != nil {
+			return nil, err
+		}
+
+		known := make(map[int]bool, len(categories))
+		for _, category := range categories {
+			known[category.ID] = true
+		}
+
+		for _, id := range payload.CategoryIDs {
+			if !known[id] {
+				return nil, fmt.Errorf("category %d does not exist", id)
+			}
+		}
+	}
+
+	var tags []*models.Tag
+	seen := make(map[string]bool)
+
+	for _, name := range payload.TagNames {
+		tag, err := newTag(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[tag.Slug] {
+			continue
+		}
+		seen[tag.Slug] = true
+
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+// newTag trims a tag name and derives its slug
+func newTag(name string) (*models.Tag, error) {
+	name = strings.Join(strings.Fields(name), " ")
+
+	slug := slugify(name)
+	if slug == "" {
+		return nil, fmt.Errorf("tag %q needs letters or digits", name)
+	}
+
+	if len(name) > maxTagLength || len(slug) > maxTagLength {
+		return nil, fmt.Errorf("tag %q is longer than %d characters", name, maxTagLength)
+	}
+
+	return &models.Tag{Name: name, Slug: slug}, nil
+}
+
 func (app *application) allArticles(w http.ResponseWriter, r *http.Request) {
-	article, err := app.DB.AllArticles()
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	// Alumni only see published articles, editors see everything
+	article, err := app.DB.AllArticles(!isArticleEditor(principal), r.URL.Query().Get("category"), r.URL.Query().Get("tag"))
 	if err != nil {
 		app.errorJSON(w, err)
 		return
@@ -690,9 +855,34 @@ func (app *application) allArticles(w http.ResponseWriter, r *http.Request) {
 }
 
 func (app *application) article(w http.ResponseWriter, r *http.Request) {
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+
 	slug := chi.URLParam(r, "slug")
 
-	article, err := app.DB.ArticleBySlug(slug)
+	publishedOnly := !isArticleEditor(principal)
+
+	article, err := app.DB.ArticleBySlug(slug, publishedOnly)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// Links to a slug the article had before lead to its current slug
+			current, err := app.DB.ArticleSlugRedirect(slug, publishedOnly)
+			if err == nil {
+				http.Redirect(w, r, "/articles/"+current, http.StatusMovedPermanently)
+				return
+			}
+
+			app.errorJSON(w, errors.New("article not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	article.Related, err = app.DB.RelatedArticles(article.ID, relatedArticlesLimit)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
@@ -719,9 +909,21 @@ func (app *application) showArticle(w http.ResponseWriter, r *http.Request) {
 }
 
 func (app *application) insertArticle(w http.ResponseWriter, r *http.Request) {
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+
 	var article models.Article
 
-	err := app.readJSON(w, r, &article)
+	err = app.readJSON(w, r, &article)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	article.Body, err = articlePolicy.Sanitize(article.Body)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
@@ -733,19 +935,68 @@ func (app *application) insertArticle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	loc, _ := time.LoadLocation("Asia/Jakarta")
+	// The slug is derived from the title unless the editor picked one
+	base := article.Slug
+	if strings.TrimSpace(base) == "" {
+		base = article.Title
+	}
+
+	article.Slug, err = app.articleSlug(base, 0)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	// New articles start as drafts unless they are published or scheduled right away
+	status := article.Status
+	if status == "" {
+		status = models.ArticleDraft
+	}
+	publishAt := article.PublishedAt
+	article.Status = models.ArticleDraft
+
+	err = article.Transition(status, publishAt, time.Now().UTC())
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	tags, err := app.articleTaxonomy(article)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnprocessableEntity)
+		return
+	}
 
 	article.Image = imgSrc
-	article.CreatedAt = time.Now().In(loc)
-	article.UpdatedAt = time.Now().In(loc)
-	article.PublishedAt = time.Now().In(loc)
+	article.Tags = tags
+	article.CreatedAt = time.Now()
+	article.UpdatedAt = time.Now()
+
+	articleID, err := app.DB.InsertArticle(article, principal.UserID)
+
+	// Another article can take the slug between picking and saving it, the next free one is used then
+	for attempt := 1; errors.Is(err, repository.ErrSlugTaken) && attempt < maxSlugAttempts; attempt++ {
+		article.Slug, err = app.articleSlug(base, 0)
+		if err != nil {
+			app.errorJSON(w, err, http.StatusUnprocessableEntity)
+			return
+		}
+
+		articleID, err = app.DB.InsertArticle(article, principal.UserID)
+	}
 
-	_, err = app.DB.InsertArticle(article)
 	if err != nil {
+		if errors.Is(err, repository.ErrSlugTaken) {
+			app.errorJSON(w, err, http.StatusConflict)
+			return
+		}
 		app.errorJSON(w, err)
 		return
 	}
 
+	article.ID = articleID
+	app.audit(r, models.AuditCreate, "article", articleID, nil, article)
+
 	resp := JSONResponse{
 		Error:   false,
 		Message: "New article has been successfully created",
@@ -755,6 +1006,12 @@ func (app *application) insertArticle(w http.ResponseWriter, r *http.Request) {
 }
 
 func (app *application) updateArticle(w http.ResponseWriter, r *http.Request) {
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+
 	id := chi.URLParam(r, "id")
 	articleID, err := strconv.Atoi(id)
 	if err != nil {
@@ -781,28 +1038,68 @@ func (app *application) updateArticle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	before := *article
+
+	payload.Body, err = articlePolicy.Sanitize(payload.Body)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
 	imgSrc, err := app.getFirstImageFromHtml(payload.Body)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
+	// Slugs stay the same when the title changes, only an explicitly new slug replaces it
+	if payload.Slug != "" && slugify(payload.Slug) != article.Slug {
+		article.Slug, err = app.articleSlug(payload.Slug, article.ID)
+		if err != nil {
+			app.errorJSON(w, err, http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	tags, err := app.articleTaxonomy(payload)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
 	article.Image = imgSrc
 	article.Title = payload.Title
-	article.Slug = payload.Slug
 	article.Body = payload.Body
-	article.Status = payload.Status
+	article.CategoryIDs = payload.CategoryIDs
+	article.TagNames = payload.TagNames
+	article.Tags = tags
 	article.UpdatedAt = time.Now()
-	if article.Status != "published" && payload.Status == "published" {
-		article.PublishedAt = time.Now()
+
+	if payload.Status != "" && (payload.Status != article.Status || payload.PublishedAt != nil) {
+		err = article.Transition(payload.Status, payload.PublishedAt, time.Now().UTC())
+		if err != nil {
+			app.errorJSON(w, err, http.StatusUnprocessableEntity)
+			return
+		}
 	}
 
-	err = app.DB.UpdateArticle(*article)
+	err = app.DB.UpdateArticle(*article, principal.UserID)
 	if err != nil {
+		if errors.Is(err, repository.ErrSlugTaken) {
+			app.errorJSON(w, err, http.StatusConflict)
+			return
+		}
 		app.errorJSON(w, err)
 		return
 	}
 
+	// Reload the article so the audit shows its new categories and tags
+	if after, err := app.DB.Article(article.ID); err == nil {
+		article = after
+	}
+
+	app.audit(r, models.AuditUpdate, "article", articleID, before, article)
+
 	resp := JSONResponse{
 		Error:   false,
 		Message: "Article has been successfully updated",
@@ -811,237 +1108,359 @@ func (app *application) updateArticle(w http.ResponseWriter, r *http.Request) {
 	app.writeJSON(w, http.StatusOK, resp)
 }
 
-func (app *application) deleteArticle(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+func (app *application) setArticleStatus(w http.ResponseWriter, r *http.Request) {
+	articleID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	err = app.DB.DeleteArticle(id)
+	var payload struct {
+		Status      string     `json:"status"`
+		PublishedAt *time.Time `json:"published_at"`
+	}
+
+	err = app.readJSON(w, r, &payload)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	resp := JSONResponse{
-		Error:   false,
-		Message: "Article has been permanently deleted",
-	}
-
-	app.writeJSON(w, http.StatusOK, resp)
+	app.transitionArticle(w, r, articleID, payload.Status, payload.PublishedAt)
 }
 
-// //////////////////
-// Handler Forms
-// //////////////////
-func (app *application) allForms(w http.ResponseWriter, r *http.Request) {
-	form, err := app.DB.AllForms()
+func (app *application) unpublishArticle(w http.ResponseWriter, r *http.Request) {
+	articleID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	_ = app.writeJSON(w, http.StatusOK, form)
+	app.transitionArticle(w, r, articleID, models.ArticleDraft, nil)
 }
 
-func (app *application) form(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	formID, err := strconv.Atoi(id)
+// transitionArticle moves an article through the publishing workflow and responds
+func (app *application) transitionArticle(w http.ResponseWriter, r *http.Request, articleID int, status string, publishAt *time.Time) {
+	principal, err := currentUser(r)
 	if err != nil {
-		app.errorJSON(w, err)
+		app.errorJSON(w, err, http.StatusUnauthorized)
 		return
 	}
 
-	form, err := app.DB.Form(formID)
+	article, err := app.DB.Article(articleID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("article not found"), http.StatusNotFound)
+			return
+		}
 		app.errorJSON(w, err)
 		return
 	}
 
-	_ = app.writeJSON(w, http.StatusOK, form)
-}
+	before := *article
 
-func (app *application) showForm(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	formID, err := strconv.Atoi(id)
+	err = article.Transition(status, publishAt, time.Now().UTC())
 	if err != nil {
-		app.errorJSON(w, err)
+		app.errorJSON(w, err, http.StatusUnprocessableEntity)
 		return
 	}
 
-	form, err := app.DB.ShowForm(formID)
+	article.UpdatedAt = time.Now()
+
+	err = app.DB.UpdateArticle(*article, principal.UserID)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	_ = app.writeJSON(w, http.StatusOK, form)
-}
+	app.audit(r, models.AuditUpdate, "article", articleID, before, article)
 
-func (app *application) insertForm(w http.ResponseWriter, r *http.Request) {
-	var form models.Form
+	resp := JSONResponse{
+		Error:   false,
+		Message: fmt.Sprintf("Article is now %s", article.Status),
+		Data:    article,
+	}
 
-	err := app.readJSON(w, r, &form)
+	app.writeJSON(w, http.StatusOK, resp)
+}
+
+func (app *application) deleteArticle(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	form.CreatedAt = time.Now()
-	form.UpdatedAt = time.Now()
+	before, err := app.DB.Article(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("article not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
 
-	surveyID, err := app.DB.InsertForm(form)
+	err = app.DB.DeleteArticle(id)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
+	app.audit(r, models.AuditDelete, "article", id, before, nil)
+
 	resp := JSONResponse{
 		Error:   false,
-		Message: fmt.Sprintf("New survey has been successfully created with id %d", surveyID),
+		Message: "Article has been permanently deleted",
 	}
 
-	app.writeJSON(w, http.StatusCreated, resp)
+	app.writeJSON(w, http.StatusOK, resp)
 }
 
-func (app *application) updateForm(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	formID, err := strconv.Atoi(id)
+func (app *application) articleRevisions(w http.ResponseWriter, r *http.Request) {
+	articleID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	var payload models.Form
+	_, err = app.DB.Article(articleID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("article not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
 
-	err = app.readJSON(w, r, &payload)
+	revisions, err := app.DB.ArticleRevisions(articleID)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	if formID != payload.ID {
-		app.errorJSON(w, errors.New("invalid request"), http.StatusBadRequest)
+	_ = app.writeJSON(w, http.StatusOK, revisions)
+}
+
+// articleRevisionDiff shows the changes a revision made. It is compared with the revision
+// before it unless ?against= names another one, and the first revision with an empty article.
+func (app *application) articleRevisionDiff(w http.ResponseWriter, r *http.Request) {
+	articleID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
 		return
 	}
 
-	form, err := app.DB.Form(payload.ID)
+	rev, err := strconv.Atoi(chi.URLParam(r, "rev"))
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	form.Title = payload.Title
-	form.Description = payload.Description
-	form.HasTimeLimit = payload.HasTimeLimit
-	form.StartDate = payload.StartDate
-	form.UpdatedAt = time.Now()
+	against := rev - 1
+	if value := r.URL.Query().Get("against"); value != "" {
+		against, err = strconv.Atoi(value)
+		if err != nil {
+			app.errorJSON(w, errors.New("against must be a revision number"))
+			return
+		}
+	}
 
-	err = app.DB.UpdateForm(*form)
+	to, err := app.DB.ArticleRevision(articleID, rev)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("revision not found"), http.StatusNotFound)
+			return
+		}
 		app.errorJSON(w, err)
 		return
 	}
 
-	resp := JSONResponse{
-		Error:   false,
-		Message: "Survey has been successfully updated",
+	var from *models.ArticleRevision
+	if against > 0 {
+		from, err = app.DB.ArticleRevision(articleID, against)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				app.errorJSON(w, fmt.Errorf("revision %d not found", against), http.StatusNotFound)
+				return
+			}
+			app.errorJSON(w, err)
+			return
+		}
 	}
 
-	app.writeJSON(w, http.StatusOK, resp)
+	_ = app.writeJSON(w, http.StatusOK, diffRevisions(from, to))
 }
 
-func (app *application) deleteForm(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+// restoreArticleRevision brings back the title and body of a revision, which is saved as a
+// new revision. The slug and the publishing status of the article stay as they are.
+func (app *application) restoreArticleRevision(w http.ResponseWriter, r *http.Request) {
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	articleID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	err = app.DB.DeleteForm(id)
+	rev, err := strconv.Atoi(chi.URLParam(r, "rev"))
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	resp := JSONResponse{
-		Error:   false,
-		Message: "Survey has been permanently deleted",
+	article, err := app.DB.Article(articleID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("article not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
 	}
 
-	app.writeJSON(w, http.StatusOK, resp)
-}
+	revision, err := app.DB.ArticleRevision(articleID, rev)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("revision not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
 
-func (app *application) question(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	qID, err := strconv.Atoi(id)
+	before := *article
+
+	// Revisions from before sanitizing was added may hold unsafe HTML
+	body, err := articlePolicy.Sanitize(revision.Body)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	question, err := app.DB.Question(qID)
+	imgSrc, err := app.getFirstImageFromHtml(body)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	groupAnswers, err := app.DB.GroupAnswersByQuestion(question.FormID, qID)
+	article.Title = revision.Title
+	article.Body = body
+	article.Image = imgSrc
+	article.UpdatedAt = time.Now()
+
+	err = app.DB.UpdateArticle(*article, principal.UserID)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	question.GroupAnswer = groupAnswers
+	app.audit(r, models.AuditUpdate, "article", articleID, before, article)
 
-	_ = app.writeJSON(w, http.StatusOK, question)
+	resp := JSONResponse{
+		Error:   false,
+		Message: fmt.Sprintf("Article has been restored to revision %d", rev),
+		Data:    article,
+	}
+
+	app.writeJSON(w, http.StatusOK, resp)
 }
 
-func (app *application) insertQuestion(w http.ResponseWriter, r *http.Request) {
-	var question models.Question
+// //////////////////
+// Handler Taxonomy
+// //////////////////
 
-	err := app.readJSON(w, r, &question)
+func (app *application) allCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := app.DB.AllCategories()
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	question.CreatedAt = time.Now()
-	question.UpdatedAt = time.Now()
+	_ = app.writeJSON(w, http.StatusOK, categories)
+}
 
-	newID, err := app.DB.InsertQuestion(question)
+// validateCategory trims a category and derives its slug, which no other category may use
+func (app *application) validateCategory(category *models.Category) (int, error) {
+	category.Name = strings.TrimSpace(category.Name)
+	category.Description = strings.TrimSpace(category.Description)
+
+	if category.Name == "" {
+		return http.StatusUnprocessableEntity, errors.New("category name is required")
+	}
+
+	base := category.Slug
+	if strings.TrimSpace(base) == "" {
+		base = category.Name
+	}
+
+	category.Slug = slugify(base)
+	if category.Slug == "" {
+		return http.StatusUnprocessableEntity, errors.New("the name or slug needs letters or digits to build a slug from")
+	}
+
+	taken, err := app.DB.CategorySlugTaken(category.Slug, category.ID)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if taken {
+		return http.StatusUnprocessableEntity, fmt.Errorf("a category with the slug %s already exists", category.Slug)
+	}
+
+	return http.StatusOK, nil
+}
+
+func (app *application) insertCategory(w http.ResponseWriter, r *http.Request) {
+	var category models.Category
+
+	err := app.readJSON(w, r, &category)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	// handle options when the type is multiple_choice
-	if question.Type == "multiple_choice" {
-		err = app.DB.UpdateQuestionOptions(newID, question.OptionsArray)
-		if err != nil {
-			app.errorJSON(w, err)
-			return
-		}
+	category.ID = 0
+
+	status, err := app.validateCategory(&category)
+	if err != nil {
+		app.errorJSON(w, err, status)
+		return
+	}
+
+	category.CreatedAt = time.Now()
+	category.UpdatedAt = time.Now()
+
+	newID, err := app.DB.InsertCategory(category)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
 	}
 
+	category.ID = newID
+	app.audit(r, models.AuditCreate, "category", newID, nil, category)
+
 	resp := JSONResponse{
 		Error:   false,
-		Message: "Question has been successfully created",
+		Message: fmt.Sprintf("Category has been successfully created with id %d", newID),
+		Data:    category,
 	}
 
 	app.writeJSON(w, http.StatusCreated, resp)
 }
 
-func (app *application) updateQuestion(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	questionID, err := strconv.Atoi(id)
+func (app *application) updateCategory(w http.ResponseWriter, r *http.Request) {
+	categoryID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	var payload models.Question
+	var payload models.Category
 
 	err = app.readJSON(w, r, &payload)
 	if err != nil {
@@ -1049,811 +1468,2811 @@ func (app *application) updateQuestion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if questionID != payload.ID {
-		app.errorJSON(w, errors.New("invalid request"), http.StatusBadRequest)
+	category, err := app.DB.Category(categoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("category not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
 		return
 	}
 
-	question, err := app.DB.Question(payload.ID)
+	before := *category
+
+	// The slug stays the same unless a new one is given, so category links keep working
+	category.Name = payload.Name
+	category.Description = payload.Description
+	if strings.TrimSpace(payload.Slug) != "" {
+		category.Slug = payload.Slug
+	}
+
+	status, err := app.validateCategory(category)
 	if err != nil {
-		app.errorJSON(w, err)
+		app.errorJSON(w, err, status)
 		return
 	}
 
-	question.Question = payload.Question
-	question.Type = payload.Type
-	question.Extension = payload.Extension
-	question.UpdatedAt = time.Now()
-	question.ID = payload.ID
-	question.OptionsArray = payload.OptionsArray
+	category.UpdatedAt = time.Now()
 
-	err = app.DB.UpdateQuestion(*question)
+	err = app.DB.UpdateCategory(*category)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	// handle options when the type is multiple_choice
-	if question.Type == "multiple_choice" {
-		err = app.DB.UpdateQuestionOptions(payload.ID, question.OptionsArray)
-		if err != nil {
-			app.errorJSON(w, err)
-			return
-		}
-	} else {
-		err = app.DB.DeleteQuestionOptions(payload.ID)
-		if err != nil {
-			app.errorJSON(w, err)
-			return
-		}
-	}
-
-	if question.Extension && payload.QuestionExtension != nil {
-		err = app.DB.UpdateQuestionExtension(payload.QuestionExtension)
-		if err != nil {
-			app.errorJSON(w, err)
-			return
-		}
-	} else {
-		err = app.DB.DeleteQuestionExtension(payload.ID)
-		if err != nil {
-			app.errorJSON(w, err)
-			return
-		}
-	}
+	app.audit(r, models.AuditUpdate, "category", categoryID, before, category)
 
 	resp := JSONResponse{
 		Error:   false,
-		Message: "Question has been successfully updated",
+		Message: "Category has been successfully updated",
+		Data:    category,
 	}
 
 	app.writeJSON(w, http.StatusOK, resp)
 }
 
-func (app *application) deleteQuestion(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+func (app *application) deleteCategory(w http.ResponseWriter, r *http.Request) {
+	categoryID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	err = app.DB.DeleteQuestion(id)
+	before, err := app.DB.Category(categoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("category not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	err = app.DB.DeleteCategory(categoryID)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
+	app.audit(r, models.AuditDelete, "category", categoryID, before, nil)
+
 	resp := JSONResponse{
 		Error:   false,
-		Message: "Question has been permanently deleted",
+		Message: "Category has been permanently deleted",
 	}
 
 	app.writeJSON(w, http.StatusOK, resp)
 }
 
-func (app *application) showFormAnswers(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	formID, err := strconv.Atoi(id)
+func (app *application) allTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := app.DB.AllTags()
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	form, err := app.DB.ShowFormAnswers(formID)
+	_ = app.writeJSON(w, http.StatusOK, tags)
+}
+
+func (app *application) insertTag(w http.ResponseWriter, r *http.Request) {
+	var payload models.Tag
+
+	err := app.readJSON(w, r, &payload)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	_ = app.writeJSON(w, http.StatusOK, form)
-}
-
-func (app *application) insertAnswers(w http.ResponseWriter, r *http.Request) {
-	var answers []*models.Answer
+	tag, err := newTag(payload.Name)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnprocessableEntity)
+		return
+	}
 
-	err := app.readJSON(w, r, &answers)
+	taken, err := app.DB.TagSlugTaken(tag.Slug, 0)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
+	if taken {
+		app.errorJSON(w, fmt.Errorf("a tag with the slug %s already exists", tag.Slug), http.StatusUnprocessableEntity)
+		return
+	}
+
+	tag.CreatedAt = time.Now()
 
-	err = app.DB.InsertAnswers(answers)
+	newID, err := app.DB.InsertTag(*tag)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
+	tag.ID = newID
+	app.audit(r, models.AuditCreate, "tag", newID, nil, tag)
+
 	resp := JSONResponse{
 		Error:   false,
-		Message: "Answers has been successfully saved",
+		Message: fmt.Sprintf("Tag has been successfully created with id %d", newID),
+		Data:    tag,
 	}
 
 	app.writeJSON(w, http.StatusCreated, resp)
 }
 
-func (app *application) showQuestionAnswers(w http.ResponseWriter, r *http.Request) {
-	fID := chi.URLParam(r, "fid")
-	formID, err := strconv.Atoi(fID)
+// updateTag renames a tag. Its slug follows the new name, so a rename onto an existing
+// tag is refused instead of merging the two.
+func (app *application) updateTag(w http.ResponseWriter, r *http.Request) {
+	tagID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	qID := chi.URLParam(r, "qid")
-	questionID, err := strconv.Atoi(qID)
+	var payload models.Tag
+
+	err = app.readJSON(w, r, &payload)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	groupAnswers, err := app.DB.GroupAnswersByQuestion(formID, questionID)
+	tag, err := app.DB.Tag(tagID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("tag not found"), http.StatusNotFound)
+			return
+		}
 		app.errorJSON(w, err)
 		return
 	}
 
-	_ = app.writeJSON(w, http.StatusOK, groupAnswers)
-}
+	before := *tag
 
-func (app *application) exportAnswers(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	formID, err := strconv.Atoi(id)
+	renamed, err := newTag(payload.Name)
 	if err != nil {
-		app.errorJSON(w, err)
+		app.errorJSON(w, err, http.StatusUnprocessableEntity)
 		return
 	}
 
-	// Get survey data
-	form, err := app.DB.Form(formID)
+	taken, err := app.DB.TagSlugTaken(renamed.Slug, tagID)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
+	if taken {
+		app.errorJSON(w, fmt.Errorf("a tag with the slug %s already exists", renamed.Slug), http.StatusUnprocessableEntity)
+		return
+	}
 
-	xlsx := excelize.NewFile()
+	tag.Name = renamed.Name
+	tag.Slug = renamed.Slug
 
-	// Iterate
-	for _, formQuestion := range form.Questions {
-		sheetName := fmt.Sprintf("Question %d", formQuestion.ID)
-		index, err := xlsx.NewSheet(sheetName)
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
+	err = app.DB.UpdateTag(*tag)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
 
-		question, err := app.DB.Question(formQuestion.ID)
-		if err != nil {
-			app.errorJSON(w, err)
-			return
-		}
-
-		xlsx.MergeCell(sheetName, "A1", "G1")
-		xlsx.SetCellValue(sheetName, "A1", question.Question)
-		style, err := xlsx.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Size: 11, Color: "000000"}})
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-		xlsx.SetCellStyle(sheetName, "A1", "A1", style)
-
-		// Set the header for the sheet
-		xlsx.SetCellValue(sheetName, "A3", "No")
-		xlsx.SetCellValue(sheetName, "B3", "User ID")
-		xlsx.SetCellValue(sheetName, "C3", "Jawaban")
-		style, err = xlsx.NewStyle(&excelize.Style{
-			Font: &excelize.Font{Bold: true, Size: 11, Color: "000000"},
-			Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#60A5FA"}},
-			Border: []excelize.Border{
-				excelize.Border{Type: "top", Color: "000000", Style: 1},
-				excelize.Border{Type: "left", Color: "000000", Style: 1},
-				excelize.Border{Type: "bottom", Color: "000000", Style: 1},
-				excelize.Border{Type: "right", Color: "000000", Style: 1},
-			},
-		})
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-		xlsx.SetCellStyle(sheetName, "A3", "C3", style)
-
-		xlsx.SetColWidth(sheetName, "A", "A", 4)
-		xlsx.SetColWidth(sheetName, "B", "B", 12)
-		xlsx.SetColWidth(sheetName, "C", "C", 32)
-
-		// Populate the sheet with answers
-		for i, answer := range question.Answers {
-			cellRow := i + 4 // starting from row 2
-			xlsx.SetCellValue(sheetName, fmt.Sprintf("A%d", cellRow), i+1)
-			xlsx.SetCellValue(sheetName, fmt.Sprintf("B%d", cellRow), answer.UserID)
-			xlsx.SetCellValue(sheetName, fmt.Sprintf("C%d", cellRow), answer.Answer)
-			style, err := xlsx.NewStyle(&excelize.Style{
-				Border: []excelize.Border{
-					excelize.Border{Type: "top", Color: "000000", Style: 1},
-					excelize.Border{Type: "left", Color: "000000", Style: 1},
-					excelize.Border{Type: "bottom", Color: "000000", Style: 1},
-					excelize.Border{Type: "right", Color: "000000", Style: 1},
-				},
-			})
-			if err != nil {
-				fmt.Println(err)
-				return
-			}
-
-			xlsx.SetCellStyle(sheetName, fmt.Sprintf("A%d", cellRow), fmt.Sprintf("C%d", cellRow), style)
-		}
+	app.audit(r, models.AuditUpdate, "tag", tagID, before, tag)
 
-		// Set the created sheet as the active one
-		xlsx.SetActiveSheet(index)
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Tag has been successfully updated",
+		Data:    tag,
 	}
 
-	currentTime := time.Now().Format("2006-01-02_15-04-05")
-	fileName := fmt.Sprintf("survei_%s_%s.xlsx", app.sanitizeFileName(form.Title), currentTime)
-
-	err = xlsx.SaveAs("/home/ikramzaidann/alumnihub/public/excel/workbook.xlsx")
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-	w.Header().Set("Content-Type", "application/octet-stream")
-	w.Header().Set("Content-Disposition", "attachment; filename="+fileName)
-	w.Header().Set("Content-Transfer-Encoding", "binary")
-	w.Header().Set("Expires", "0")
-	xlsx.Write(w)
+	app.writeJSON(w, http.StatusOK, resp)
 }
 
-func (app *application) userAnswers(w http.ResponseWriter, r *http.Request) {
-	// Ambil klaim dari konteks menggunakan tipe kunci khusus
-	claims, ok := r.Context().Value(userClaimsKey).(*Claims)
-	if !ok {
-		app.errorJSON(w, errors.New("no claims in context"))
+func (app *application) deleteTag(w http.ResponseWriter, r *http.Request) {
+	tagID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
 		return
 	}
 
-	// Konversi userID dari string ke int
-	userID, err := strconv.Atoi(claims.Subject)
+	before, err := app.DB.Tag(tagID)
 	if err != nil {
-		app.errorJSON(w, errors.New("invalid user ID in token"))
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("tag not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
 		return
 	}
 
-	answers, err := app.DB.GetAnswersByUser(userID)
+	err = app.DB.DeleteTag(tagID)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	_ = app.writeJSON(w, http.StatusOK, answers)
+	app.audit(r, models.AuditDelete, "tag", tagID, before, nil)
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Tag has been permanently deleted",
+	}
+
+	app.writeJSON(w, http.StatusOK, resp)
 }
 
 // //////////////////
-// Handler Forums
+// Handler Forms
 // //////////////////
-func (app *application) allForums(w http.ResponseWriter, r *http.Request) {
-	forums, err := app.DB.AllForums()
+func (app *application) allForms(w http.ResponseWriter, r *http.Request) {
+	// ?template=true lists the template library instead of the live forms
+	templates, _ := strconv.ParseBool(r.URL.Query().Get("template"))
+
+	form, err := app.DB.AllForms(templates)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	_ = app.writeJSON(w, http.StatusOK, forums)
+	_ = app.writeJSON(w, http.StatusOK, form)
 }
 
-func (app *application) allUserForums(w http.ResponseWriter, r *http.Request) {
-	username := chi.URLParam(r, "username")
-
-	userID, err := app.DB.GetUserIDByUsername(username)
+func (app *application) form(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	formID, err := strconv.Atoi(id)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	forums, err := app.DB.GetForumsByUser(userID)
+	form, err := app.DB.Form(formID)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	_ = app.writeJSON(w, http.StatusOK, forums)
+	_ = app.writeJSON(w, http.StatusOK, form)
 }
 
-func (app *application) forum(w http.ResponseWriter, r *http.Request) {
+func (app *application) showForm(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	forumID, err := strconv.Atoi(id)
+	formID, err := strconv.Atoi(id)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	form, err := app.DB.Forum(forumID)
+	form, err := app.DB.ShowForm(formID)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
+	// The questions are nested in their sections and still listed flat for older clients
 	_ = app.writeJSON(w, http.StatusOK, form)
 }
 
-func (app *application) insertForum(w http.ResponseWriter, r *http.Request) {
-	var payload struct {
-		Forum string `json:"forum_text"`
-	}
+func (app *application) insertForm(w http.ResponseWriter, r *http.Request) {
+	var form models.Form
 
-	err := app.readJSON(w, r, &payload)
+	err := app.readJSON(w, r, &form)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	// Ambil klaim dari konteks menggunakan tipe kunci khusus
-	claims, ok := r.Context().Value(userClaimsKey).(*Claims)
-	if !ok {
-		app.errorJSON(w, errors.New("no claims in context"))
-		return
-	}
+	form.StartDate = form.StartDate.UTC()
+	form.EndDate = form.EndDate.UTC()
+	form.CreatedAt = time.Now()
+	form.UpdatedAt = time.Now()
 
-	// Konversi userID dari string ke int
-	userID, err := strconv.Atoi(claims.Subject)
+	surveyID, err := app.DB.InsertForm(form)
 	if err != nil {
-		app.errorJSON(w, errors.New("invalid user ID in token"))
+		app.errorJSON(w, err)
 		return
 	}
 
-	var forum models.Forum
+	form.ID = surveyID
+	app.audit(r, models.AuditCreate, "form", surveyID, nil, form)
 
-	forum.Forum = payload.Forum
-	forum.UserID = userID
-	forum.PublishedAt = time.Now()
+	resp := JSONResponse{
+		Error:   false,
+		Message: fmt.Sprintf("New survey has been successfully created with id %d", surveyID),
+	}
 
-	_, err = app.DB.InsertForum(forum)
+	app.writeJSON(w, http.StatusCreated, resp)
+}
+
+func (app *application) updateForm(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	formID, err := strconv.Atoi(id)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	resp := JSONResponse{
-		Error:   false,
-		Message: "New forum has been successfully created",
+	var payload models.Form
+
+	err = app.readJSON(w, r, &payload)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
 	}
 
-	app.writeJSON(w, http.StatusCreated, resp)
-}
+	if formID != payload.ID {
+		app.errorJSON(w, errors.New("invalid request"), http.StatusBadRequest)
+		return
+	}
 
-func (app *application) deleteForum(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	forumID, err := strconv.Atoi(id)
+	form, err := app.DB.Form(payload.ID)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	err = app.DB.DeleteForum(forumID)
+	before := *form
+
+	form.Title = payload.Title
+	form.Description = payload.Description
+	form.HasTimeLimit = payload.HasTimeLimit
+	form.StartDate = payload.StartDate.UTC()
+	form.EndDate = payload.EndDate.UTC()
+	form.IsTemplate = payload.IsTemplate
+	form.AllowEdits = payload.AllowEdits
+	if payload.Hidden != "" {
+		form.Hidden = payload.Hidden
+	}
+	form.UpdatedAt = time.Now()
+
+	err = app.DB.UpdateForm(*form)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
+	app.audit(r, models.AuditUpdate, "form", formID, before, form)
+
 	resp := JSONResponse{
 		Error:   false,
-		Message: "Forum has been successfully deleted",
+		Message: "Survey has been successfully updated",
 	}
 
 	app.writeJSON(w, http.StatusOK, resp)
 }
 
-func (app *application) insertComment(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	forumID, err := strconv.Atoi(id)
+func (app *application) deleteForm(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	var payload struct {
-		ForumID int    `json:"forum_id"`
-		Comment string `json:"reply_text"`
+	before, err := app.DB.Form(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("survey not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
 	}
 
-	err = app.readJSON(w, r, &payload)
+	err = app.DB.DeleteForm(id)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	if forumID != payload.ForumID {
-		app.errorJSON(w, errors.New("invalid request"), http.StatusBadRequest)
-		return
+	app.audit(r, models.AuditDelete, "form", id, before, nil)
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Survey has been permanently deleted",
 	}
 
-	// Ambil klaim dari konteks menggunakan tipe kunci khusus
-	claims, ok := r.Context().Value(userClaimsKey).(*Claims)
-	if !ok {
-		app.errorJSON(w, errors.New("no claims in context"))
+	app.writeJSON(w, http.StatusOK, resp)
+}
+
+func (app *application) duplicateForm(w http.ResponseWriter, r *http.Request) {
+	formID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
 		return
 	}
 
-	// Konversi userID dari string ke int
-	userID, err := strconv.Atoi(claims.Subject)
+	var payload struct {
+		Title      string `json:"title"`
+		IsTemplate bool   `json:"is_template"`
+	}
+
+	// The body is optional, an empty one copies the form as a live form
+	if r.ContentLength != 0 {
+		err = app.readJSON(w, r, &payload)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+	}
+
+	form, err := app.DB.Form(formID)
 	if err != nil {
-		app.errorJSON(w, errors.New("invalid user ID in token"))
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("survey not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
 		return
 	}
 
-	var comment models.Comment
-
-	comment.Comment = payload.Comment
-	comment.UserID = userID
-	comment.ForumID = payload.ForumID
-	comment.PublishedAt = time.Now()
+	title := strings.TrimSpace(payload.Title)
+	if title == "" {
+		title = form.Title + " (Salinan)"
+	}
 
-	_, err = app.DB.InsertComment(comment)
+	newID, err := app.DB.DuplicateForm(formID, title, payload.IsTemplate)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
+	app.audit(r, models.AuditCreate, "form", newID, nil, map[string]interface{}{
+		"duplicated_from": formID,
+		"title":           title,
+		"is_template":     payload.IsTemplate,
+	})
+
 	resp := JSONResponse{
 		Error:   false,
-		Message: "New comment has been succesfully created",
+		Message: fmt.Sprintf("Survey has been successfully duplicated with id %d", newID),
+		Data:    map[string]int{"id": newID},
 	}
 
 	app.writeJSON(w, http.StatusCreated, resp)
 }
 
-func (app *application) userLikes(w http.ResponseWriter, r *http.Request) {
-	// Ambil klaim dari konteks menggunakan tipe kunci khusus
-	claims, ok := r.Context().Value(userClaimsKey).(*Claims)
-	if !ok {
-		app.errorJSON(w, errors.New("no claims in context"))
-		return
+// validateSection checks that a question of formID may be put into sectionID, 0 is no section
+func (app *application) validateSection(formID int, sectionID int) error {
+	if sectionID == 0 {
+		return nil
 	}
 
-	// Konversi userID dari string ke int
-	userID, err := strconv.Atoi(claims.Subject)
+	section, err := app.DB.Section(sectionID)
 	if err != nil {
-		app.errorJSON(w, errors.New("invalid user ID in token"))
-		return
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("section %d does not exist", sectionID)
+		}
+		return err
 	}
 
-	likes, err := app.DB.GetLikesByUser(userID)
-	if err != nil {
-		app.errorJSON(w, err)
-		return
+	if section.FormID != formID {
+		return fmt.Errorf("section %d is not in the same form", sectionID)
 	}
 
-	_ = app.writeJSON(w, http.StatusOK, likes)
+	return nil
 }
 
-func (app *application) insertLike(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	forumID, err := strconv.Atoi(id)
+func (app *application) insertSection(w http.ResponseWriter, r *http.Request) {
+	formID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	// Ambil klaim dari konteks menggunakan tipe kunci khusus
-	claims, ok := r.Context().Value(userClaimsKey).(*Claims)
-	if !ok {
-		app.errorJSON(w, errors.New("no claims in context"))
+	var section models.Section
+
+	err = app.readJSON(w, r, &section)
+	if err != nil {
+		app.errorJSON(w, err)
 		return
 	}
 
-	// Konversi userID dari string ke int
-	userID, err := strconv.Atoi(claims.Subject)
+	_, err = app.DB.Form(formID)
 	if err != nil {
-		app.errorJSON(w, errors.New("invalid user ID in token"))
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("survey not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
 		return
 	}
 
-	var like models.Like
+	section.Title = strings.TrimSpace(section.Title)
+	if section.Title == "" {
+		app.errorJSON(w, errors.New("section title is required"), http.StatusUnprocessableEntity)
+		return
+	}
 
-	like.ForumID = forumID
-	like.UserID = userID
-	like.CreatedAt = time.Now()
+	section.FormID = formID
+	section.CreatedAt = time.Now()
+	section.UpdatedAt = time.Now()
 
-	err = app.DB.InsertLike(like)
+	newID, err := app.DB.InsertSection(section)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
+	section.ID = newID
+	app.audit(r, models.AuditCreate, "section", newID, nil, section)
+
 	resp := JSONResponse{
 		Error:   false,
-		Message: "Like has been succesfully added",
+		Message: fmt.Sprintf("Section has been successfully created with id %d", newID),
+		Data:    map[string]int{"id": newID},
 	}
 
 	app.writeJSON(w, http.StatusCreated, resp)
 }
 
-func (app *application) deleteLike(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	forumID, err := strconv.Atoi(id)
+func (app *application) updateSection(w http.ResponseWriter, r *http.Request) {
+	sectionID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	// Ambil klaim dari konteks menggunakan tipe kunci khusus
-	claims, ok := r.Context().Value(userClaimsKey).(*Claims)
-	if !ok {
-		app.errorJSON(w, errors.New("no claims in context"))
+	var payload models.Section
+
+	err = app.readJSON(w, r, &payload)
+	if err != nil {
+		app.errorJSON(w, err)
 		return
 	}
 
-	// Konversi userID dari string ke int
-	userID, err := strconv.Atoi(claims.Subject)
+	section, err := app.DB.Section(sectionID)
 	if err != nil {
-		app.errorJSON(w, errors.New("invalid user ID in token"))
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("section not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
 		return
 	}
 
-	err = app.DB.DeleteLike(userID, forumID)
+	before := *section
+
+	section.Title = strings.TrimSpace(payload.Title)
+	section.Description = payload.Description
+	section.UpdatedAt = time.Now()
+
+	if section.Title == "" {
+		app.errorJSON(w, errors.New("section title is required"), http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = app.DB.UpdateSection(*section)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
+	app.audit(r, models.AuditUpdate, "section", sectionID, before, section)
+
 	resp := JSONResponse{
 		Error:   false,
-		Message: "Like has been successfully deleted",
+		Message: "Section has been successfully updated",
 	}
 
 	app.writeJSON(w, http.StatusOK, resp)
 }
 
-// //////////////////
-// Handler Jobs
-// //////////////////
-func (app *application) allJobs(w http.ResponseWriter, r *http.Request) {
-	jobs, err := app.DB.AllJobs()
+func (app *application) deleteSection(w http.ResponseWriter, r *http.Request) {
+	sectionID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	_ = app.writeJSON(w, http.StatusOK, jobs)
-}
-
-func (app *application) job(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	jobID, err := strconv.Atoi(id)
+	before, err := app.DB.Section(sectionID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("section not found"), http.StatusNotFound)
+			return
+		}
 		app.errorJSON(w, err)
 		return
 	}
 
-	job, err := app.DB.Job(jobID)
+	err = app.DB.DeleteSection(sectionID)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	_ = app.writeJSON(w, http.StatusOK, job)
-}
+	app.audit(r, models.AuditDelete, "section", sectionID, before, nil)
 
-func (app *application) insertJob(w http.ResponseWriter, r *http.Request) {
-	var job models.Job
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Section has been deleted, its questions were moved out of it",
+	}
 
-	err := app.readJSON(w, r, &job)
+	app.writeJSON(w, http.StatusOK, resp)
+}
+
+func (app *application) reorderQuestions(w http.ResponseWriter, r *http.Request) {
+	formID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	// Ambil klaim dari konteks menggunakan tipe kunci khusus
-	claims, ok := r.Context().Value(userClaimsKey).(*Claims)
-	if !ok {
-		app.errorJSON(w, errors.New("no claims in context"))
-		return
+	var payload struct {
+		Sections []*models.QuestionOrder `json:"sections"`
 	}
 
-	// Konversi userID dari string ke int
-	userID, err := strconv.Atoi(claims.Subject)
+	err = app.readJSON(w, r, &payload)
 	if err != nil {
-		app.errorJSON(w, errors.New("invalid user ID in token"))
+		app.errorJSON(w, err)
 		return
 	}
 
-	job.UserID = userID
-	job.CreatedAt = time.Now()
-	job.UpdatedAt = time.Now()
-
-	_, err = app.DB.InsertJob(job)
+	form, err := app.DB.ShowForm(formID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("survey not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	ordered, err := orderQuestions(form, payload.Sections)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = app.DB.ReorderQuestions(formID, payload.Sections)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
+	before := make([]int, 0, len(form.Questions))
+	for _, question := range form.Questions {
+		before = append(before, question.ID)
+	}
+
+	after := make([]int, 0, len(ordered))
+	for _, question := range ordered {
+		after = append(after, question.ID)
+	}
+
+	app.audit(r, models.AuditUpdate, "form", formID, map[string][]int{"questions": before}, map[string][]int{"questions": after})
+
 	resp := JSONResponse{
 		Error:   false,
-		Message: "New job has been successfully posted",
+		Message: "Questions have been successfully reordered",
+	}
+
+	app.writeJSON(w, http.StatusOK, resp)
+}
+
+// readCampaignFilter checks the recipient filter of a campaign
+func readCampaignFilter(filter *models.RecipientFilter) error {
+	if filter.GraduationYearFrom != 0 && filter.GraduationYearTo != 0 && filter.GraduationYearFrom > filter.GraduationYearTo {
+		return errors.New("graduation_year_from cannot be after graduation_year_to")
+	}
+
+	filter.Gender = strings.TrimSpace(filter.Gender)
+	filter.Location = strings.TrimSpace(filter.Location)
+
+	return nil
+}
+
+func (app *application) previewCampaign(w http.ResponseWriter, r *http.Request) {
+	formID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	var filter models.RecipientFilter
+
+	err = app.readJSON(w, r, &filter)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	err = readCampaignFilter(&filter)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	recipients, err := app.DB.CampaignRecipients(formID, filter)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: fmt.Sprintf("%d alumni would be invited", len(recipients)),
+		Data:    recipients,
+	}
+
+	app.writeJSON(w, http.StatusOK, resp)
+}
+
+func (app *application) insertCampaign(w http.ResponseWriter, r *http.Request) {
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	formID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	var campaign models.Campaign
+
+	err = app.readJSON(w, r, &campaign)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	form, err := app.DB.Form(formID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("survey not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	if form.IsTemplate {
+		app.errorJSON(w, errors.New("templates cannot be sent to alumni"), http.StatusUnprocessableEntity)
+		return
+	}
+
+	// Invitations to a form alumni cannot open or answer would only lead to an error page
+	if form.IsHidden() {
+		app.errorJSON(w, errors.New("hidden surveys cannot be sent to alumni, publish the survey first"), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if !form.IsOpen(time.Now().UTC()) {
+		app.errorJSON(w, errors.New("the survey is not open for submissions"), http.StatusUnprocessableEntity)
+		return
+	}
+
+	campaign.Name = strings.TrimSpace(campaign.Name)
+	if campaign.Name == "" {
+		app.errorJSON(w, errors.New("campaign name is required"), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if _, ok := app.Notifiers[campaign.Channel]; !ok {
+		app.errorJSON(w, fmt.Errorf("channel %s is not available", campaign.Channel), http.StatusUnprocessableEntity)
+		return
+	}
+
+	for _, days := range campaign.ReminderDays {
+		if days < 1 || days > 365 {
+			app.errorJSON(w, errors.New("reminders must be sent between 1 and 365 days after the invitation"), http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	err = readCampaignFilter(&campaign.Filter)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	campaign.FormID = formID
+	campaign.CreatedBy = principal.UserID
+	campaign.CreatedAt = time.Now()
+	if campaign.StartAt.IsZero() {
+		campaign.StartAt = campaign.CreatedAt
+	}
+
+	campaignID, recipients, err := app.DB.InsertCampaign(campaign)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	campaign.ID = campaignID
+	app.audit(r, models.AuditCreate, "campaign", campaignID, nil, campaign)
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: fmt.Sprintf("Campaign has been successfully created for %d alumni", recipients),
+		Data:    map[string]int{"id": campaignID, "recipients": recipients},
 	}
 
 	app.writeJSON(w, http.StatusCreated, resp)
 }
 
-func (app *application) updateJob(w http.ResponseWriter, r *http.Request) {
+func (app *application) formCampaigns(w http.ResponseWriter, r *http.Request) {
+	formID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	campaigns, err := app.DB.Campaigns(formID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, campaigns)
+}
+
+func (app *application) campaign(w http.ResponseWriter, r *http.Request) {
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	campaign, err := app.DB.Campaign(campaignID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("campaign not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	recipients, err := app.DB.CampaignRecipientStatus(campaignID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	resp := struct {
+		*models.Campaign
+		Recipients []*models.CampaignRecipient `json:"recipients"`
+	}{campaign, recipients}
+
+	_ = app.writeJSON(w, http.StatusOK, resp)
+}
+
+func (app *application) openInvitation(w http.ResponseWriter, r *http.Request) {
+	formID, err := app.DB.OpenInvitation(chi.URLParam(r, "token"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("invitation not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Invitation has been opened",
+		Data:    map[string]int{"form_id": formID},
+	}
+
+	app.writeJSON(w, http.StatusOK, resp)
+}
+
+func (app *application) question(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	jobID, err := strconv.Atoi(id)
+	qID, err := strconv.Atoi(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	question, err := app.DB.Question(qID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	groupAnswers, err := app.DB.GroupAnswersByQuestion(question.FormID, qID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	question.GroupAnswer = groupAnswers
+
+	_ = app.writeJSON(w, http.StatusOK, question)
+}
+
+func (app *application) insertQuestion(w http.ResponseWriter, r *http.Request) {
+	var question models.Question
+
+	err := app.readJSON(w, r, &question)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	err = validateQuestion(&question)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = app.validateSection(question.FormID, question.SectionID)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	question.CreatedAt = time.Now()
+	question.UpdatedAt = time.Now()
+
+	newID, err := app.DB.InsertQuestion(question)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	// handle options when the type picks from options
+	if question.HasOptions() {
+		err = app.DB.UpdateQuestionOptions(newID, question.OptionsArray)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+	}
+
+	question.ID = newID
+	app.audit(r, models.AuditCreate, "question", newID, nil, question)
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Question has been successfully created",
+	}
+
+	app.writeJSON(w, http.StatusCreated, resp)
+}
+
+func (app *application) updateQuestion(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	questionID, err := strconv.Atoi(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	var payload models.Question
+
+	err = app.readJSON(w, r, &payload)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if questionID != payload.ID {
+		app.errorJSON(w, errors.New("invalid request"), http.StatusBadRequest)
+		return
+	}
+
+	question, err := app.DB.Question(payload.ID)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	var payload models.Job
-
+	before := *question
+
+	question.Question = payload.Question
+	question.Type = payload.Type
+	question.Extension = payload.Extension
+	question.Required = payload.Required
+	question.UpdatedAt = time.Now()
+	question.ID = payload.ID
+	question.OptionsArray = payload.OptionsArray
+	question.Settings = payload.Settings
+	question.SectionID = payload.SectionID
+
+	err = validateQuestion(question)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = app.validateSection(question.FormID, question.SectionID)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	// A single question_extension is still accepted from older clients
+	rules := payload.Rules
+	if rules == nil && payload.QuestionExtension != nil {
+		rules = []*models.Extension{payload.QuestionExtension}
+	}
+
+	// Rules are checked before anything is saved
+	if question.Extension && len(rules) > 0 {
+		questions, err := app.DB.QuestionsByForm(question.FormID)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+
+		sections, err := app.DB.FormSections(question.FormID)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+
+		err = validateQuestionRules(question, question.OptionsArray, questions, sections, rules)
+		if err != nil {
+			app.errorJSON(w, err, http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	err = app.DB.UpdateQuestion(*question)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	// handle options when the type picks from options
+	if question.HasOptions() {
+		err = app.DB.UpdateQuestionOptions(payload.ID, question.OptionsArray)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+	} else {
+		err = app.DB.DeleteQuestionOptions(payload.ID)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+	}
+
+	if question.Extension && len(rules) > 0 {
+		err = app.DB.SetQuestionRules(payload.ID, rules)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+	} else {
+		err = app.DB.DeleteQuestionExtension(payload.ID)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+	}
+
+	app.audit(r, models.AuditUpdate, "question", questionID, before, question)
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Question has been successfully updated",
+	}
+
+	app.writeJSON(w, http.StatusOK, resp)
+}
+
+func (app *application) deleteQuestion(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	before, err := app.DB.Question(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("question not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	err = app.DB.DeleteQuestion(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditDelete, "question", id, before, nil)
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Question has been permanently deleted",
+	}
+
+	app.writeJSON(w, http.StatusOK, resp)
+}
+
+func (app *application) showFormAnswers(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	formID, err := strconv.Atoi(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	form, err := app.DB.ShowFormAnswers(formID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, form)
+}
+
+func (app *application) insertAnswers(w http.ResponseWriter, r *http.Request) {
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	formID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	var answers []*models.Answer
+
+	// An empty body submits the saved draft
+	if r.ContentLength == 0 {
+		draft, err := app.DB.Draft(formID, principal.UserID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				app.errorJSON(w, errors.New("there are no answers and no saved draft to submit"), http.StatusUnprocessableEntity)
+				return
+			}
+			app.errorJSON(w, err)
+			return
+		}
+		answers = draft.Answers
+	} else {
+		err = app.readJSON(w, r, &answers)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+	}
+
+	form, status, err := app.answerableForm(formID)
+	if err != nil {
+		app.errorJSON(w, err, status)
+		return
+	}
+
+	// The user and form always come from the token and the URL, never from the payload
+	valid, errs := validateSubmission(form, answers)
+	if len(errs) > 0 {
+		resp := JSONResponse{
+			Error:   true,
+			Message: "Some answers are invalid",
+			Data:    errs,
+		}
+		app.writeJSON(w, http.StatusUnprocessableEntity, resp)
+		return
+	}
+
+	_, err = app.DB.InsertSubmission(models.FormSubmission{
+		FormID:      formID,
+		UserID:      principal.UserID,
+		SubmittedAt: time.Now(),
+		Answers:     valid,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrAlreadySubmitted) {
+			app.errorJSON(w, err, http.StatusConflict)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Answers has been successfully saved",
+	}
+
+	app.writeJSON(w, http.StatusCreated, resp)
+}
+
+func (app *application) updateSubmission(w http.ResponseWriter, r *http.Request) {
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	formID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	var answers []*models.Answer
+
+	err = app.readJSON(w, r, &answers)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	form, status, err := app.answerableForm(formID)
+	if err != nil {
+		app.errorJSON(w, err, status)
+		return
+	}
+
+	if !form.CanEditSubmissions(time.Now().UTC()) {
+		app.errorJSON(w, errors.New("answers to this survey can no longer be changed"), http.StatusForbidden)
+		return
+	}
+
+	valid, errs := validateSubmission(form, answers)
+	if len(errs) > 0 {
+		resp := JSONResponse{
+			Error:   true,
+			Message: "Some answers are invalid",
+			Data:    errs,
+		}
+		app.writeJSON(w, http.StatusUnprocessableEntity, resp)
+		return
+	}
+
+	_, err = app.DB.ReplaceSubmission(models.FormSubmission{
+		FormID:    formID,
+		UserID:    principal.UserID,
+		UpdatedAt: time.Now(),
+		Answers:   valid,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("you have not submitted this survey"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Answers has been successfully updated",
+	}
+
+	app.writeJSON(w, http.StatusOK, resp)
+}
+
+func (app *application) withdrawSubmission(w http.ResponseWriter, r *http.Request) {
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	formID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	form, status, err := app.answerableForm(formID)
+	if err != nil {
+		app.errorJSON(w, err, status)
+		return
+	}
+
+	if !form.CanEditSubmissions(time.Now().UTC()) {
+		app.errorJSON(w, errors.New("answers to this survey can no longer be withdrawn"), http.StatusForbidden)
+		return
+	}
+
+	err = app.DB.WithdrawSubmission(formID, principal.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("you have not submitted this survey"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Answers has been successfully withdrawn",
+	}
+
+	app.writeJSON(w, http.StatusOK, resp)
+}
+
+func (app *application) submissionVersions(w http.ResponseWriter, r *http.Request) {
+	formID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	userID, err := strconv.Atoi(chi.URLParam(r, "uid"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	versions, err := app.DB.SubmissionVersions(formID, userID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if len(versions) == 0 {
+		app.errorJSON(w, errors.New("this user has no submission history for the survey"), http.StatusNotFound)
+		return
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, versions)
+}
+
+// answerableForm loads a form that alumni can currently answer, with the status to respond
+// with when they cannot
+func (app *application) answerableForm(formID int) (*models.Form, int, error) {
+	form, err := app.DB.ShowForm(formID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusNotFound, errors.New("survey not found")
+		}
+		return nil, http.StatusBadRequest, err
+	}
+
+	if form.IsHidden() || form.IsTemplate {
+		return nil, http.StatusForbidden, errors.New("this survey is not available")
+	}
+
+	if !form.IsOpen(time.Now().UTC()) {
+		return nil, http.StatusForbidden, errors.New("this survey is not accepting answers")
+	}
+
+	return form, http.StatusOK, nil
+}
+
+func (app *application) draft(w http.ResponseWriter, r *http.Request) {
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	formID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	draft, err := app.DB.Draft(formID, principal.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("no draft saved for this survey"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, draft)
+}
+
+func (app *application) saveDraft(w http.ResponseWriter, r *http.Request) {
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	formID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	var answers []*models.Answer
+
+	err = app.readJSON(w, r, &answers)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	form, status, err := app.answerableForm(formID)
+	if err != nil {
+		app.errorJSON(w, err, status)
+		return
+	}
+
+	// Drafts may be incomplete, they only have to answer questions of this form
+	errs := validateDraft(form, answers)
+	if len(errs) > 0 {
+		resp := JSONResponse{
+			Error:   true,
+			Message: "Some answers are invalid",
+			Data:    errs,
+		}
+		app.writeJSON(w, http.StatusUnprocessableEntity, resp)
+		return
+	}
+
+	err = app.DB.SaveDraft(models.FormDraft{
+		FormID:    formID,
+		UserID:    principal.UserID,
+		Answers:   answers,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrAlreadySubmitted) {
+			app.errorJSON(w, err, http.StatusConflict)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Draft has been successfully saved",
+	}
+
+	app.writeJSON(w, http.StatusOK, resp)
+}
+
+func (app *application) showQuestionAnswers(w http.ResponseWriter, r *http.Request) {
+	fID := chi.URLParam(r, "fid")
+	formID, err := strconv.Atoi(fID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	qID := chi.URLParam(r, "qid")
+	questionID, err := strconv.Atoi(qID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	groupAnswers, err := app.DB.GroupAnswersByQuestion(formID, questionID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, groupAnswers)
+}
+
+func (app *application) formAnalytics(w http.ResponseWriter, r *http.Request) {
+	formID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	form, err := app.DB.Form(formID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("survey not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	analytics, err := app.DB.FormAnalytics(formID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	totalAlumni, err := app.DB.CountAlumni()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	analytics.TotalAlumni = totalAlumni
+	analytics.ResponseRate = models.Rate(analytics.Respondents, totalAlumni)
+
+	// ?row=<question id>&column=<question id> adds a cross-tab of two choice questions
+	qs := r.URL.Query()
+	if qs.Get("row") != "" || qs.Get("column") != "" {
+		rowID, rowErr := strconv.Atoi(qs.Get("row"))
+		columnID, columnErr := strconv.Atoi(qs.Get("column"))
+		if rowErr != nil || columnErr != nil || rowID == columnID {
+			app.errorJSON(w, errors.New("row and column must be two different question ids"))
+			return
+		}
+
+		isChoice := func(questionID int) bool {
+			for _, question := range form.Questions {
+				if question.ID == questionID {
+					return question.HasOptions() && question.Type != models.QuestionGrid
+				}
+			}
+			return false
+		}
+
+		if !isChoice(rowID) || !isChoice(columnID) {
+			app.errorJSON(w, errors.New("cross-tabs need two choice questions of this survey"))
+			return
+		}
+
+		analytics.CrossTab, err = app.DB.CrossTab(formID, rowID, columnID)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, analytics)
+}
+
+func (app *application) exportAnswers(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	formID, err := strconv.Atoi(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "xlsx"
+	}
+	if format != "xlsx" && format != "csv" {
+		app.errorJSON(w, errors.New("format must be csv or xlsx"))
+		return
+	}
+
+	// Get survey data
+	form, err := app.DB.Form(formID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("survey not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	respondents, err := app.DB.FormRespondents(formID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	currentTime := time.Now().Format("2006-01-02_15-04-05")
+	fileName := fmt.Sprintf("survei_%s_%s.%s", app.sanitizeFileName(form.Title), currentTime, format)
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", "attachment; filename="+fileName)
+
+		err = writeAnswersCSV(w, form.Questions, respondents)
+		if err != nil {
+			log.Println("error writing csv export:", err)
+		}
+		return
+	}
+
+	summary := make(map[int][]*models.GroupAnswer)
+	for _, question := range form.Questions {
+		if !question.HasOptions() && question.Type != models.QuestionLinearScale {
+			continue
+		}
+
+		summary[question.ID], err = app.DB.GroupAnswersByQuestion(formID, question.ID)
+		if err != nil {
+			app.errorJSON(w, err)
+			return
+		}
+	}
+
+	xlsx, err := buildAnswersWorkbook(form.Questions, respondents, summary)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	defer xlsx.Close()
+
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", "attachment; filename="+fileName)
+	w.Header().Set("Expires", "0")
+
+	err = xlsx.Write(w)
+	if err != nil {
+		log.Println("error writing xlsx export:", err)
+	}
+}
+
+func (app *application) userAnswers(w http.ResponseWriter, r *http.Request) {
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+	userID := principal.UserID
+
+	answers, err := app.DB.GetAnswersByUser(userID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, answers)
+}
+
+// //////////////////
+// Handler Forums
+// //////////////////
+func (app *application) allForums(w http.ResponseWriter, r *http.Request) {
+	forums, err := app.DB.AllForums()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, forums)
+}
+
+func (app *application) allUserForums(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+
+	userID, err := app.DB.GetUserIDByUsername(username)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	forums, err := app.DB.GetForumsByUser(userID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, forums)
+}
+
+func (app *application) forum(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	forumID, err := strconv.Atoi(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	form, err := app.DB.Forum(forumID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, form)
+}
+
+func (app *application) insertForum(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Forum string `json:"forum_text"`
+	}
+
+	err := app.readJSON(w, r, &payload)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+	userID := principal.UserID
+
+	text, err := postPolicy.Sanitize(payload.Forum)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if strings.TrimSpace(text) == "" {
+		app.errorJSON(w, errors.New("forum text cannot be empty"), http.StatusUnprocessableEntity)
+		return
+	}
+
+	var forum models.Forum
+
+	forum.Forum = text
+	forum.UserID = userID
+	forum.PublishedAt = time.Now()
+
+	_, err = app.DB.InsertForum(forum)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "New forum has been successfully created",
+	}
+
+	app.writeJSON(w, http.StatusCreated, resp)
+}
+
+func (app *application) deleteForum(w http.ResponseWriter, r *http.Request) {
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	forumID, err := strconv.Atoi(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	forum, err := app.DB.Forum(forumID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("forum not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	if !principal.CanModify(forum.UserID, models.PermForumsModerate) {
+		app.errorJSON(w, errors.New("you can only delete your own forums"), http.StatusForbidden)
+		return
+	}
+
+	err = app.DB.DeleteForum(forumID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditDelete, "forum", forumID, forum, nil)
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Forum has been successfully deleted",
+	}
+
+	app.writeJSON(w, http.StatusOK, resp)
+}
+
+func (app *application) insertComment(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	forumID, err := strconv.Atoi(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	var payload struct {
+		ForumID int    `json:"forum_id"`
+		Comment string `json:"reply_text"`
+	}
+
+	err = app.readJSON(w, r, &payload)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if forumID != payload.ForumID {
+		app.errorJSON(w, errors.New("invalid request"), http.StatusBadRequest)
+		return
+	}
+
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+	userID := principal.UserID
+
+	text, err := postPolicy.Sanitize(payload.Comment)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if strings.TrimSpace(text) == "" {
+		app.errorJSON(w, errors.New("reply text cannot be empty"), http.StatusUnprocessableEntity)
+		return
+	}
+
+	var comment models.Comment
+
+	comment.Comment = text
+	comment.UserID = userID
+	comment.ForumID = payload.ForumID
+	comment.PublishedAt = time.Now()
+
+	_, err = app.DB.InsertComment(comment)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "New comment has been succesfully created",
+	}
+
+	app.writeJSON(w, http.StatusCreated, resp)
+}
+
+func (app *application) deleteComment(w http.ResponseWriter, r *http.Request) {
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	forumID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	commentID, err := strconv.Atoi(chi.URLParam(r, "rid"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	comment, err := app.DB.Comment(commentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("reply not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	if comment.ForumID != forumID {
+		app.errorJSON(w, errors.New("reply not found"), http.StatusNotFound)
+		return
+	}
+
+	if !principal.CanModify(comment.UserID, models.PermForumsModerate) {
+		app.errorJSON(w, errors.New("you can only delete your own replies"), http.StatusForbidden)
+		return
+	}
+
+	err = app.DB.DeleteComment(commentID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditDelete, "reply", commentID, comment, nil)
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Reply has been successfully deleted",
+	}
+
+	app.writeJSON(w, http.StatusOK, resp)
+}
+
+func (app *application) userLikes(w http.ResponseWriter, r *http.Request) {
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+	userID := principal.UserID
+
+	likes, err := app.DB.GetLikesByUser(userID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, likes)
+}
+
+func (app *application) insertLike(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	forumID, err := strconv.Atoi(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+	userID := principal.UserID
+
+	var like models.Like
+
+	like.ForumID = forumID
+	like.UserID = userID
+	like.CreatedAt = time.Now()
+
+	err = app.DB.InsertLike(like)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Like has been succesfully added",
+	}
+
+	app.writeJSON(w, http.StatusCreated, resp)
+}
+
+func (app *application) deleteLike(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	forumID, err := strconv.Atoi(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+	userID := principal.UserID
+
+	err = app.DB.DeleteLike(userID, forumID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Like has been successfully deleted",
+	}
+
+	app.writeJSON(w, http.StatusOK, resp)
+}
+
+// //////////////////
+// Handler Jobs
+// //////////////////
+func (app *application) allJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := app.DB.AllJobs()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, jobs)
+}
+
+func (app *application) job(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	jobID, err := strconv.Atoi(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	job, err := app.DB.Job(jobID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, job)
+}
+
+func (app *application) insertJob(w http.ResponseWriter, r *http.Request) {
+	var job models.Job
+
+	err := app.readJSON(w, r, &job)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+	userID := principal.UserID
+
+	job.UserID = userID
+	job.CreatedAt = time.Now()
+	job.UpdatedAt = time.Now()
+
+	_, err = app.DB.InsertJob(job)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "New job has been successfully posted",
+	}
+
+	app.writeJSON(w, http.StatusCreated, resp)
+}
+
+func (app *application) updateJob(w http.ResponseWriter, r *http.Request) {
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	jobID, err := strconv.Atoi(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	var payload models.Job
+
+	err = app.readJSON(w, r, &payload)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	if jobID != payload.ID {
+		app.errorJSON(w, errors.New("invalid request"))
+		return
+	}
+
+	job, err := app.DB.Job(payload.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("job not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	if !principal.CanModify(job.UserID, models.PermJobsModerate) {
+		app.errorJSON(w, errors.New("you can only update your own job postings"), http.StatusForbidden)
+		return
+	}
+
+	before := *job
+
+	job.JobPosition = payload.JobPosition
+	job.Company = payload.Company
+	job.JobLocation = payload.JobLocation
+	job.JobType = payload.JobType
+	job.MinSalary = payload.MinSalary
+	job.MaxSalary = payload.MaxSalary
+	job.Description = payload.Description
+	job.Closed = payload.Closed
+	job.UpdatedAt = time.Now()
+
+	err = app.DB.UpdateJob(*job)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditUpdate, "job", jobID, before, job)
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Job has been successfully updated",
+	}
+
+	app.writeJSON(w, http.StatusOK, resp)
+}
+
+func (app *application) deleteJob(w http.ResponseWriter, r *http.Request) {
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	jobID, err := strconv.Atoi(id)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	job, err := app.DB.Job(jobID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("job not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	if !principal.CanModify(job.UserID, models.PermJobsModerate) {
+		app.errorJSON(w, errors.New("you can only delete your own job postings"), http.StatusForbidden)
+		return
+	}
+
+	err = app.DB.DeleteJob(jobID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditDelete, "job", jobID, job, nil)
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Job has been successfully deleted",
+	}
+
+	app.writeJSON(w, http.StatusOK, resp)
+}
+
+func (app *application) uploadImage(w http.ResponseWriter, r *http.Request) {
+	// Parse multipart form data
+	err := r.ParseMultipartForm(10 << 20) // 10 MB max file size
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	// Get the file from the form data
+	file, handler, err := r.FormFile("image")
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	defer file.Close()
+
+	// Determine the file extension
+	ext := filepath.Ext(handler.Filename)
+
+	// Create a temporary file to store the uploaded image
+	tempFile, err := os.CreateTemp("/home/ikramzaidann/alumnihub/public", "upload-*"+ext)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+	defer tempFile.Close()
+
+	// Write the file content to the temporary file
+	_, err = io.Copy(tempFile, file)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	// Set file permissions to be readable by everyone (0644)
+	err = os.Chmod(tempFile.Name(), 0644)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	// Generate the file path for response
+	fileName := filepath.Base(tempFile.Name())
+	filePath := filepath.Join("public", fileName)
+	filePath = strings.ReplaceAll(filePath, "\\", "/")
+
+	// Create an image model (assuming you have this struct)
+	image := models.Image{
+		FilePath: filePath,
+		FileName: fileName,
+	}
+
+	// Send response back to client
+	app.writeJSON(w, http.StatusAccepted, image)
+}
+
+func (app *application) serveImage(w http.ResponseWriter, r *http.Request) {
+	// Mendapatkan path file dari URL
+	imagePath := chi.URLParam(r, "image_path")
+
+	// Gabungkan path file dengan direktori "public"
+	imageFile := filepath.Join("public", imagePath)
+
+	// Buka file gambar
+	http.ServeFile(w, r, imageFile)
+}
+
+// Matching NISN before register
+func (app *application) registerCheck(w http.ResponseWriter, r *http.Request) {
+	var requestPayload struct {
+		NISN string `json:"nisn"`
+	}
+
+	err := app.readJSON(w, r, &requestPayload)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	// Failed checks are counted per NISN as well as per IP, so guessing from many IPs is throttled
+	accountKey := registerAccountKey("nisn", requestPayload.NISN)
+
+	allowed, wait, err := app.loginLimiter.Allow(accountKey)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		app.tooManyRequests(w, wait)
+		return
+	}
+
+	// Validate NISN
+	alumni, err := app.DB.GetAlumniByNISN(requestPayload.NISN)
+	if err != nil {
+		_ = app.loginLimiter.Hit(accountKey)
+		app.errorJSON(w, errors.New("nisn doesn't match any record"), http.StatusBadRequest)
+		return
+	}
+
+	// Check if user already exist
+	_, err = app.DB.GetProfileByAlumniID(alumni.ID)
+	if err == nil {
+		_ = app.loginLimiter.Hit(accountKey)
+		app.errorJSON(w, errors.New("account already registered"), http.StatusBadRequest)
+		return
+	}
+
+	// if other error occured, return internal server error
+	if err != nil && err != sql.ErrNoRows {
+		app.errorJSON(w, errors.New("error checking profile"), http.StatusInternalServerError)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, alumni)
+
+}
+
+func (app *application) register(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Username string `json:"username"`
+		Email    string `json:"email"`
+		Password string `json:"password"`
+		AlumniID int    `json:"alumni_id"`
+	}
+
+	err := app.readJSON(w, r, &payload)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	// Validasi payload untuk nilai kosong
+	if payload.Username == "" || payload.Email == "" || payload.Password == "" || payload.AlumniID == 0 {
+		app.errorJSON(w, errors.New("all fields are required"), http.StatusBadRequest)
+		return
+	}
+
+	// Failed registrations are counted per email as well as per IP
+	accountKey := registerAccountKey("email", payload.Email)
+
+	allowed, wait, err := app.loginLimiter.Allow(accountKey)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		app.tooManyRequests(w, wait)
+		return
+	}
+
+	// Cek apakah alumni sudah terdaftar?
+	_, err = app.DB.GetProfileByAlumniID(payload.AlumniID)
+	if err == nil {
+		_ = app.loginLimiter.Hit(accountKey)
+		app.errorJSON(w, errors.New("account already registered"), http.StatusBadRequest)
+		return
+	}
+
+	var user models.User
+
+	// Hash the password
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	user.Username = payload.Username
+	user.Email = payload.Email
+	user.Password = string(hashedPassword)
+	user.IsAdmin = false
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+
+	userID, err := app.DB.InsertUser(user)
+	if err != nil {
+		// Most often the email or username is taken
+		_ = app.loginLimiter.Hit(accountKey)
+		app.errorJSON(w, err)
+		return
+	}
+
+	var profile models.Profile
+	profile.AlumniID = payload.AlumniID
+	profile.UserID = userID
+
+	_, err = app.DB.InsertProfile(profile)
+	if err != nil {
+		_ = app.DB.DeleteUser(userID)
+		app.errorJSON(w, err)
+		return
+	}
+
+	err = app.DB.SetUserRoles(userID, []string{models.RoleAlumni})
+	if err != nil {
+		log.Printf("error assigning alumni role to user %d: %v", userID, err)
+	}
+
+	err = app.sendVerificationEmail(userID, user.Email)
+	if err != nil {
+		log.Printf("error creating verification token for user %d: %v", userID, err)
+	}
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Register success",
+	}
+
+	app.writeJSON(w, http.StatusCreated, resp)
+
+}
+
+func (app *application) authenticate(w http.ResponseWriter, r *http.Request) {
+	// read JSON Payload
+	var requestPayload struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+
+	err := app.readJSON(w, r, &requestPayload)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	// Failed logins are counted per account, whether the account exists or not
+	accountKey := loginAccountKey(requestPayload.Email)
+
+	allowed, wait, err := app.loginLimiter.Allow(accountKey)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		app.tooManyRequests(w, wait)
+		return
+	}
+
+	// Validate, with the same answer for an unknown email and a wrong password
+	invalidCredentials := errors.New("invalid email or password")
+
+	user, err := app.DB.GetUserByEmail(requestPayload.Email)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Println("error finding user on login:", err)
+		}
+		_ = app.loginLimiter.Hit(accountKey)
+		app.errorJSON(w, invalidCredentials, http.StatusBadRequest)
+		return
+	}
+
+	valid, err := user.PasswordMatches(requestPayload.Password)
+	if err != nil || !valid {
+		_ = app.loginLimiter.Hit(accountKey)
+		app.errorJSON(w, invalidCredentials, http.StatusBadRequest)
+		return
+	}
+
+	_ = app.loginLimiter.Reset(accountKey)
+
+	if app.RequireVerifiedEmail && !user.Verified {
+		app.errorJSON(w, errors.New("email has not been verified"), http.StatusForbidden)
+		return
+	}
+
+	// Every login starts a new session, refresh tokens are rotated within it
+	now := time.Now()
+	sessionID, err := app.DB.InsertSession(models.Session{
+		UserID:     user.ID,
+		UserAgent:  r.UserAgent(),
+		IP:         clientIP(r),
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(app.auth.RefreshExpiry),
+	})
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	// create a jwt user
+	u, err := app.newJWTUser(user, sessionID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	// Generate token
+	tokens, err := app.auth.GenerateTokenPair(&u)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	err = app.DB.InsertRefreshToken(models.RefreshToken{
+		JTI:       tokens.RefreshTokenID,
+		SessionID: sessionID,
+		UserID:    user.ID,
+		CreatedAt: now,
+		ExpiresAt: tokens.RefreshExpiresAt,
+	})
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	refreshCookie := app.auth.GetRefreshCookie(tokens.RefreshToken)
+	http.SetCookie(w, refreshCookie)
+
+	app.writeJSON(w, http.StatusOK, tokens)
+}
+
+// newJWTUser loads the roles and permissions that go into the tokens of a user
+func (app *application) newJWTUser(user *models.User, sessionID int) (jwtUser, error) {
+	roles, err := app.DB.GetUserRoles(user.ID)
+	if err != nil {
+		return jwtUser{}, err
+	}
+
+	permissions, err := app.DB.GetUserPermissions(user.ID)
+	if err != nil {
+		return jwtUser{}, err
+	}
+
+	return jwtUser{
+		ID:          user.ID,
+		Username:    user.Username,
+		Role:        user.IsAdmin,
+		SessionID:   sessionID,
+		Roles:       roles,
+		Permissions: permissions,
+	}, nil
+}
+
+// loginAccountKey is the limiter key used to count failed logins of an account
+func loginAccountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// registerAccountKey is the key of the registration attempts for one NISN or email. It is kept
+// apart from the login key so failed registrations do not lock the account out of logging in.
+func registerAccountKey(field string, value string) string {
+	return "register:" + field + ":" + strings.ToLower(strings.TrimSpace(value))
+}
+
+func (app *application) unlockUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	user, err := app.DB.GetUserByID(userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("user not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
+		return
+	}
+
+	err = app.loginLimiter.Reset(loginAccountKey(user.Email))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	app.audit(r, models.AuditUnlock, "user", userID, nil, nil)
+
+	resp := JSONResponse{
+		Error:   false,
+		Message: "User has been successfully unlocked",
+	}
+
+	app.writeJSON(w, http.StatusOK, resp)
+}
+
+func (app *application) allRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := app.DB.AllRoles()
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, roles)
+}
+
+func (app *application) userRoles(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	roles, err := app.DB.GetUserRoles(userID)
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, roles)
+}
+
+func (app *application) updateUserRoles(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
+		return
+	}
+
+	var payload struct {
+		Roles []string `json:"roles"`
+	}
+
 	err = app.readJSON(w, r, &payload)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	if jobID != payload.ID {
-		app.errorJSON(w, errors.New("invalid request"))
+	_, err = app.DB.GetUserByID(userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("user not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
 		return
 	}
 
-	job, err := app.DB.Job(payload.ID)
+	previous, err := app.DB.GetUserRoles(userID)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	job.JobPosition = payload.JobPosition
-	job.Company = payload.Company
-	job.JobLocation = payload.JobLocation
-	job.JobType = payload.JobType
-	job.MinSalary = payload.MinSalary
-	job.MaxSalary = payload.MaxSalary
-	job.Description = payload.Description
-	job.Closed = payload.Closed
-	job.UpdatedAt = time.Now()
+	seen := make(map[string]bool)
+	var roles []string
+	for _, role := range payload.Roles {
+		role = strings.TrimSpace(role)
+		if role != "" && !seen[role] {
+			seen[role] = true
+			roles = append(roles, role)
+		}
+	}
 
-	err = app.DB.UpdateJob(*job)
+	err = app.DB.SetUserRoles(userID, roles)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
+	app.audit(r, models.AuditRoles, "user", userID, previous, roles)
+
 	resp := JSONResponse{
 		Error:   false,
-		Message: "Job has been successfully updated",
+		Message: "User roles have been successfully updated",
 	}
 
 	app.writeJSON(w, http.StatusOK, resp)
 }
 
-func (app *application) deleteJob(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	jobID, err := strconv.Atoi(id)
+func (app *application) auditLog(w http.ResponseWriter, r *http.Request) {
+	opts, err := app.readQueryOptions(r, "actor", "entity_type", "entity_id", "from", "to")
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	err = app.DB.DeleteJob(jobID)
+	entries, total, err := app.DB.AuditLog(opts)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
 	resp := JSONResponse{
-		Error:   false,
-		Message: "Job has been successfully deleted",
+		Error: false,
+		Data:  entries,
+		Meta:  models.NewPagination(opts, total),
 	}
 
-	app.writeJSON(w, http.StatusOK, resp)
+	_ = app.writeJSON(w, http.StatusOK, resp)
 }
 
-func (app *application) uploadImage(w http.ResponseWriter, r *http.Request) {
-	// Parse multipart form data
-	err := r.ParseMultipartForm(10 << 20) // 10 MB max file size
+func (app *application) refreshToken(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(app.auth.CookieName)
 	if err != nil {
-		app.errorJSON(w, err)
+		app.errorJSON(w, errors.New("unauthorized"), http.StatusUnauthorized)
 		return
 	}
 
-	// Get the file from the form data
-	file, handler, err := r.FormFile("image")
+	// parse the token to get the claims
+	claims, err := app.auth.ParseRefreshToken(cookie.Value)
 	if err != nil {
-		app.errorJSON(w, err)
+		app.errorJSON(w, errors.New("unauthorized"), http.StatusUnauthorized)
 		return
 	}
-	defer file.Close()
 
-	// Determine the file extension
-	ext := filepath.Ext(handler.Filename)
+	// get the user id from the token claims
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		app.errorJSON(w, errors.New("unknown user"), http.StatusUnauthorized)
+		return
+	}
 
-	// Create a temporary file to store the uploaded image
-	tempFile, err := os.CreateTemp("/home/ikramzaidann/alumnihub/public", "upload-*"+ext)
+	user, err := app.DB.GetUserByID(userID)
 	if err != nil {
-		app.errorJSON(w, err)
+		app.errorJSON(w, errors.New("unknown user"), http.StatusUnauthorized)
 		return
 	}
-	defer tempFile.Close()
 
-	// Write the file content to the temporary file
-	_, err = io.Copy(tempFile, file)
+	u, err := app.newJWTUser(user, claims.SessionID)
 	if err != nil {
-		app.errorJSON(w, err)
+		app.errorJSON(w, errors.New("error generating tokens"), http.StatusUnauthorized)
 		return
 	}
 
-	// Set file permissions to be readable by everyone (0644)
-	err = os.Chmod(tempFile.Name(), 0644)
+	tokenPairs, err := app.auth.GenerateTokenPair(&u)
 	if err != nil {
-		app.errorJSON(w, err)
+		app.errorJSON(w, errors.New("error generating tokens"), http.StatusUnauthorized)
 		return
 	}
 
-	// Generate the file path for response
-	fileName := filepath.Base(tempFile.Name())
-	filePath := filepath.Join("public", fileName)
-	filePath = strings.ReplaceAll(filePath, "\\", "/")
+	err = app.DB.RotateRefreshToken(claims.ID, models.RefreshToken{
+		JTI:       tokenPairs.RefreshTokenID,
+		SessionID: claims.SessionID,
+		UserID:    user.ID,
+		CreatedAt: time.Now(),
+		ExpiresAt: tokenPairs.RefreshExpiresAt,
+	})
+	if err != nil {
+		http.SetCookie(w, app.auth.GetExpiredRefreshCookie())
 
-	// Create an image model (assuming you have this struct)
-	image := models.Image{
-		FilePath: filePath,
-		FileName: fileName,
+		switch {
+		case errors.Is(err, repository.ErrRefreshTokenReused):
+			log.Printf("refresh token reuse detected for user %d, session %d revoked", user.ID, claims.SessionID)
+			app.errorJSON(w, errors.New("refresh token reuse detected, please log in again"), http.StatusUnauthorized)
+		case errors.Is(err, repository.ErrSessionRevoked), errors.Is(err, sql.ErrNoRows):
+			app.errorJSON(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		default:
+			app.errorJSON(w, err, http.StatusInternalServerError)
+		}
+		return
 	}
 
-	// Send response back to client
-	app.writeJSON(w, http.StatusAccepted, image)
-}
+	http.SetCookie(w, app.auth.GetRefreshCookie(tokenPairs.RefreshToken))
 
-func (app *application) serveImage(w http.ResponseWriter, r *http.Request) {
-	// Mendapatkan path file dari URL
-	imagePath := chi.URLParam(r, "image_path")
+	app.writeJSON(w, http.StatusOK, tokenPairs)
+}
 
-	// Gabungkan path file dengan direktori "public"
-	imageFile := filepath.Join("public", imagePath)
+func (app *application) logout(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(app.auth.CookieName)
+	if err == nil {
+		claims, err := app.auth.ParseRefreshToken(cookie.Value)
+		if err == nil {
+			err = app.DB.RevokeSessionByRefreshToken(claims.ID)
+			if err != nil {
+				log.Println("error revoking session on logout:", err)
+			}
+		}
+	}
 
-	// Buka file gambar
-	http.ServeFile(w, r, imageFile)
+	http.SetCookie(w, app.auth.GetExpiredRefreshCookie())
+	w.WriteHeader(http.StatusNoContent)
 }
 
-// Matching NISN before register
-func (app *application) registerCheck(w http.ResponseWriter, r *http.Request) {
-	var requestPayload struct {
-		NISN string `json:"nisn"`
+func (app *application) sessions(w http.ResponseWriter, r *http.Request) {
+	principal, err := currentUser(r)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusUnauthorized)
+		return
 	}
+	userID := principal.UserID
 
-	err := app.readJSON(w, r, &requestPayload)
+	sessions, err := app.DB.GetActiveSessions(userID)
 	if err != nil {
-		app.errorJSON(w, err, http.StatusBadRequest)
+		app.errorJSON(w, err)
 		return
 	}
 
-	// Validate NISN
-	alumni, err := app.DB.GetAlumniByNISN(requestPayload.NISN)
+	for _, session := range sessions {
+		session.Current = session.ID == principal.SessionID
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, sessions)
+}
+
+func (app *application) deleteSession(w http.ResponseWriter, r *http.Request) {
+	principal, err := currentUser(r)
 	if err != nil {
-		app.errorJSON(w, errors.New("nisn doesn't match any record"), http.StatusBadRequest)
+		app.errorJSON(w, err, http.StatusUnauthorized)
 		return
 	}
+	userID := principal.UserID
 
-	// Check if user already exist
-	_, err = app.DB.GetProfileByAlumniID(alumni.ID)
-	if err == nil {
-		app.errorJSON(w, errors.New("account already registered"), http.StatusBadRequest)
+	sessionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.errorJSON(w, err)
 		return
 	}
 
-	// if other error occured, return internal server error
-	if err != nil && err != sql.ErrNoRows {
-		app.errorJSON(w, errors.New("error checking profile"), http.StatusInternalServerError)
+	err = app.DB.RevokeSession(sessionID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("session not found"), http.StatusNotFound)
+			return
+		}
+		app.errorJSON(w, err)
 		return
 	}
 
-	app.writeJSON(w, http.StatusOK, alumni)
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Session has been successfully ended",
+	}
 
+	app.writeJSON(w, http.StatusOK, resp)
 }
 
-func (app *application) register(w http.ResponseWriter, r *http.Request) {
+func (app *application) sendVerificationEmail(userID int, email string) error {
+	plain, token, err := generateUserToken(userID, emailVerificationExpiry, models.ScopeEmailVerification)
+	if err != nil {
+		return err
+	}
+
+	err = app.DB.InsertUserToken(token)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Halo,\n\nKonfirmasi alamat email akun Alumnihub kamu melalui tautan berikut:\n\n%s/email/verify?token=%s\n\nTautan ini berlaku selama %d jam.\n",
+		app.FrontendURL, plain, int(emailVerificationExpiry.Hours()))
+
+	app.sendEmail(email, "Verifikasi email Alumnihub", body)
+
+	return nil
+}
+
+func (app *application) forgotPassword(w http.ResponseWriter, r *http.Request) {
 	var payload struct {
-		Username string `json:"username"`
-		Email    string `json:"email"`
-		Password string `json:"password"`
-		AlumniID int    `json:"alumni_id"`
+		Email string `json:"email"`
 	}
 
 	err := app.readJSON(w, r, &payload)
@@ -1862,156 +4281,148 @@ func (app *application) register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validasi payload untuk nilai kosong
-	if payload.Username == "" || payload.Email == "" || payload.Password == "" || payload.AlumniID == 0 {
-		app.errorJSON(w, errors.New("all fields are required"), http.StatusBadRequest)
-		return
+	// The response is the same whether the email is registered or not
+	resp := JSONResponse{
+		Error:   false,
+		Message: "If the email is registered, a password reset link has been sent",
 	}
 
-	// Cek apakah alumni sudah terdaftar?
-	_, err = app.DB.GetProfileByAlumniID(payload.AlumniID)
-	if err == nil {
-		app.errorJSON(w, errors.New("account already registered"), http.StatusBadRequest)
+	user, err := app.DB.GetUserByEmail(strings.TrimSpace(payload.Email))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Println("error finding user for password reset:", err)
+		}
+		app.writeJSON(w, http.StatusAccepted, resp)
 		return
 	}
 
-	var user models.User
+	plain, token, err := generateUserToken(user.ID, passwordResetExpiry, models.ScopePasswordReset)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
 
-	// Hash the password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
+	err = app.DB.InsertUserToken(token)
 	if err != nil {
-		app.errorJSON(w, err)
+		app.errorJSON(w, err, http.StatusInternalServerError)
 		return
 	}
 
-	user.Username = payload.Username
-	user.Email = payload.Email
-	user.Password = string(hashedPassword)
-	user.IsAdmin = false
-	user.CreatedAt = time.Now()
-	user.UpdatedAt = time.Now()
+	body := fmt.Sprintf("Halo %s,\n\nKami menerima permintaan untuk mengatur ulang kata sandi akun Alumnihub kamu. Buka tautan berikut untuk membuat kata sandi baru:\n\n%s/password/reset?token=%s\n\nTautan ini berlaku selama %d menit. Abaikan email ini jika kamu tidak merasa memintanya.\n",
+		user.Username, app.FrontendURL, plain, int(passwordResetExpiry.Minutes()))
 
-	userID, err := app.DB.InsertUser(user)
+	app.sendEmail(user.Email, "Atur ulang kata sandi Alumnihub", body)
+
+	app.writeJSON(w, http.StatusAccepted, resp)
+}
+
+func (app *application) resetPassword(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	err := app.readJSON(w, r, &payload)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	var profile models.Profile
-	profile.AlumniID = payload.AlumniID
-	profile.UserID = userID
+	if payload.Token == "" {
+		app.errorJSON(w, errors.New("token is required"))
+		return
+	}
 
-	_, err = app.DB.InsertProfile(profile)
+	if len(payload.Password) < 8 {
+		app.errorJSON(w, errors.New("password must be at least 8 characters"))
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
 	if err != nil {
-		_ = app.DB.DeleteUser(userID)
 		app.errorJSON(w, err)
 		return
 	}
 
+	_, err = app.DB.ResetUserPassword(hashToken(payload.Token), string(hashedPassword))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("invalid or expired token"))
+			return
+		}
+		app.errorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
+
 	resp := JSONResponse{
 		Error:   false,
-		Message: "Register success",
+		Message: "Password has been successfully reset",
 	}
 
-	app.writeJSON(w, http.StatusCreated, resp)
-
+	app.writeJSON(w, http.StatusOK, resp)
 }
 
-func (app *application) authenticate(w http.ResponseWriter, r *http.Request) {
-	// read JSON Payload
-	var requestPayload struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+func (app *application) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Token string `json:"token"`
 	}
 
-	err := app.readJSON(w, r, &requestPayload)
+	err := app.readJSON(w, r, &payload)
 	if err != nil {
-		app.errorJSON(w, err, http.StatusBadRequest)
+		app.errorJSON(w, err)
 		return
 	}
 
-	// Validate
-	user, err := app.DB.GetUserByEmail(requestPayload.Email)
-	if err != nil {
-		app.errorJSON(w, errors.New("invalid email"), http.StatusBadRequest)
+	if payload.Token == "" {
+		app.errorJSON(w, errors.New("token is required"))
 		return
 	}
 
-	valid, err := user.PasswordMatches(requestPayload.Password)
-	if err != nil || !valid {
-		app.errorJSON(w, errors.New("invalid password"), http.StatusBadRequest)
+	_, err = app.DB.VerifyUserEmail(hashToken(payload.Token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			app.errorJSON(w, errors.New("invalid or expired token"))
+			return
+		}
+		app.errorJSON(w, err, http.StatusInternalServerError)
 		return
 	}
 
-	// create a jwt user
-	u := jwtUser{
-		ID:       user.ID,
-		Username: user.Username,
-		Role:     user.IsAdmin,
+	resp := JSONResponse{
+		Error:   false,
+		Message: "Email has been successfully verified",
 	}
 
-	// Generate token
-	tokens, err := app.auth.GenerateTokenPair(&u)
+	app.writeJSON(w, http.StatusOK, resp)
+}
+
+func (app *application) resendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Email string `json:"email"`
+	}
+
+	err := app.readJSON(w, r, &payload)
 	if err != nil {
 		app.errorJSON(w, err)
 		return
 	}
 
-	refreshCookie := app.auth.GetRefreshCookie(tokens.RefreshToken)
-	http.SetCookie(w, refreshCookie)
-
-	app.writeJSON(w, http.StatusOK, tokens)
-}
-
-func (app *application) refreshToken(w http.ResponseWriter, r *http.Request) {
-	for _, cookie := range r.Cookies() {
-		if cookie.Name == app.auth.CookieName {
-			claims := &Claims{}
-			refreshToken := cookie.Value
-
-			// parse the token to get the claims
-			_, err := jwt.ParseWithClaims(refreshToken, claims, func(token *jwt.Token) (interface{}, error) {
-				return []byte(app.JWTSecret), nil
-			})
-			if err != nil {
-				app.errorJSON(w, errors.New("unauthorized"), http.StatusUnauthorized)
-				return
-			}
-
-			// get the user id from the token claims
-			userID, err := strconv.Atoi(claims.Subject)
-			if err != nil {
-				app.errorJSON(w, errors.New("unknown user"), http.StatusUnauthorized)
-				return
-			}
-
-			user, err := app.DB.GetUserByID(userID)
-			if err != nil {
-				app.errorJSON(w, errors.New("unknown user"), http.StatusUnauthorized)
-				return
-			}
-
-			u := jwtUser{
-				ID:       user.ID,
-				Username: user.Username,
-				Role:     user.IsAdmin,
-			}
-
-			tokenPairs, err := app.auth.GenerateTokenPair(&u)
-			if err != nil {
-				app.errorJSON(w, errors.New("error generating tokens"), http.StatusUnauthorized)
-				return
-			}
-
-			http.SetCookie(w, app.auth.GetRefreshCookie(tokenPairs.RefreshToken))
+	resp := JSONResponse{
+		Error:   false,
+		Message: "If the email is registered and not verified yet, a verification link has been sent",
+	}
 
-			app.writeJSON(w, http.StatusOK, tokenPairs)
+	user, err := app.DB.GetUserByEmail(strings.TrimSpace(payload.Email))
+	if err != nil || user.Verified {
+		app.writeJSON(w, http.StatusAccepted, resp)
+		return
+	}
 
-		}
+	err = app.sendVerificationEmail(user.ID, user.Email)
+	if err != nil {
+		app.errorJSON(w, err, http.StatusInternalServerError)
+		return
 	}
-}
 
-func (app *application) logout(w http.ResponseWriter, r *http.Request) {
-	http.SetCookie(w, app.auth.GetExpiredRefreshCookie())
-	w.WriteHeader(http.StatusNoContent)
+	app.writeJSON(w, http.StatusAccepted, resp)
 }
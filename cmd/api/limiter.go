@@ -0,0 +1,122 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter counts attempts per key and locks a key out once it has too many of them.
+// The in-memory implementation is enough for a single instance; a shared store such as
+// Postgres can implement the same interface when the API runs on several instances.
+type RateLimiter interface {
+	// Allow reports whether key may make another attempt and, if not, how long it has to wait
+	Allow(key string) (bool, time.Duration, error)
+	// Hit records an attempt for key
+	Hit(key string) error
+	// Reset clears the attempts and the lockout of key
+	Reset(key string) error
+}
+
+type limiterEntry struct {
+	count       int
+	windowStart time.Time
+	lockedUntil time.Time
+}
+
+// memoryLimiter locks a key for lockout once it reaches maxAttempts within window
+type memoryLimiter struct {
+	mu          sync.Mutex
+	entries     map[string]*limiterEntry
+	maxAttempts int
+	window      time.Duration
+	lockout     time.Duration
+	// now is the clock of the limiter, replaced in tests
+	now func() time.Time
+}
+
+func newMemoryLimiter(maxAttempts int, window time.Duration, lockout time.Duration) *memoryLimiter {
+	l := &memoryLimiter{
+		entries:     make(map[string]*limiterEntry),
+		maxAttempts: maxAttempts,
+		window:      window,
+		lockout:     lockout,
+		now:         time.Now,
+	}
+
+	go l.cleanup()
+
+	return l
+}
+
+func (l *memoryLimiter) Allow(key string) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[key]
+	if !ok {
+		return true, 0, nil
+	}
+
+	now := l.now()
+	if now.Before(entry.lockedUntil) {
+		return false, entry.lockedUntil.Sub(now), nil
+	}
+
+	return true, 0, nil
+}
+
+func (l *memoryLimiter) Hit(key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	entry, ok := l.entries[key]
+	if !ok || now.Sub(entry.windowStart) > l.window {
+		entry = &limiterEntry{windowStart: now, lockedUntil: entry.lockedUntilOrZero()}
+		l.entries[key] = entry
+	}
+
+	entry.count++
+
+	if entry.count >= l.maxAttempts {
+		entry.lockedUntil = now.Add(l.lockout)
+		entry.count = 0
+		entry.windowStart = now
+	}
+
+	return nil
+}
+
+func (l *memoryLimiter) Reset(key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.entries, key)
+
+	return nil
+}
+
+// cleanup periodically drops entries that are neither counting nor locked anymore
+func (l *memoryLimiter) cleanup() {
+	for {
+		time.Sleep(time.Minute)
+
+		l.mu.Lock()
+		now := l.now()
+		for key, entry := range l.entries {
+			if now.Sub(entry.windowStart) > l.window && now.After(entry.lockedUntil) {
+				delete(l.entries, key)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+func (e *limiterEntry) lockedUntilOrZero() time.Time {
+	if e == nil {
+		return time.Time{}
+	}
+
+	return e.lockedUntil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// testClock is a clock that only moves when the test advances it
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// newTestLimiter returns a limiter allowing 3 attempts a minute with a 5 minute lockout
+func newTestLimiter() (*memoryLimiter, *testClock) {
+	clock := &testClock{now: time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)}
+
+	l := newMemoryLimiter(3, time.Minute, 5*time.Minute)
+	l.now = clock.Now
+
+	return l, clock
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	// step is one action on the limiter followed by the expected answer of Allow for the key
+	type step struct {
+		action  string
+		key     string
+		advance time.Duration
+		allowed bool
+		wait    time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"below the threshold", []step{
+			{action: "hit", key: "a", allowed: true},
+			{action: "hit", key: "a", allowed: true},
+		}},
+		{"threshold locks the key", []step{
+			{action: "hit", key: "a", allowed: true},
+			{action: "hit", key: "a", allowed: true},
+			{action: "hit", key: "a", allowed: false, wait: 5 * time.Minute},
+			{action: "wait", key: "a", advance: 2 * time.Minute, allowed: false, wait: 3 * time.Minute},
+		}},
+		{"lockout expires", []step{
+			{action: "hit", key: "a", allowed: true},
+			{action: "hit", key: "a", allowed: true},
+			{action: "hit", key: "a", allowed: false, wait: 5 * time.Minute},
+			{action: "wait", key: "a", advance: 5 * time.Minute, allowed: true},
+			{action: "hit", key: "a", allowed: true},
+		}},
+		{"attempts older than the window are forgotten", []step{
+			{action: "hit", key: "a", allowed: true},
+			{action: "hit", key: "a", allowed: true},
+			{action: "wait", key: "a", advance: time.Minute + time.Second, allowed: true},
+			{action: "hit", key: "a", allowed: true},
+			{action: "hit", key: "a", allowed: true},
+			{action: "hit", key: "a", allowed: false, wait: 5 * time.Minute},
+		}},
+		{"attempts within the window add up", []step{
+			{action: "hit", key: "a", allowed: true},
+			{action: "wait", key: "a", advance: 30 * time.Second, allowed: true},
+			{action: "hit", key: "a", allowed: true},
+			{action: "wait", key: "a", advance: 30 * time.Second, allowed: true},
+			{action: "hit", key: "a", allowed: false, wait: 5 * time.Minute},
+		}},
+		{"reset on success clears the attempts", []step{
+			{action: "hit", key: "a", allowed: true},
+			{action: "hit", key: "a", allowed: true},
+			{action: "reset", key: "a", allowed: true},
+			{action: "hit", key: "a", allowed: true},
+			{action: "hit", key: "a", allowed: true},
+		}},
+		{"reset clears the lockout", []step{
+			{action: "hit", key: "a", allowed: true},
+			{action: "hit", key: "a", allowed: true},
+			{action: "hit", key: "a", allowed: false, wait: 5 * time.Minute},
+			{action: "reset", key: "a", allowed: true},
+		}},
+		{"keys are counted separately", []step{
+			{action: "hit", key: "a", allowed: true},
+			{action: "hit", key: "a", allowed: true},
+			{action: "hit", key: "b", allowed: true},
+			{action: "hit", key: "a", allowed: false, wait: 5 * time.Minute},
+			{action: "wait", key: "b", allowed: true},
+			{action: "reset", key: "b", allowed: true},
+			{action: "wait", key: "a", allowed: false, wait: 5 * time.Minute},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, clock := newTestLimiter()
+
+			for i, s := range tt.steps {
+				var err error
+				switch s.action {
+				case "hit":
+					err = l.Hit(s.key)
+				case "reset":
+					err = l.Reset(s.key)
+				case "wait":
+					clock.Advance(s.advance)
+				}
+				if err != nil {
+					t.Fatalf("step %d: %s returned error: %v", i, s.action, err)
+				}
+
+				allowed, wait, err := l.Allow(s.key)
+				if err != nil {
+					t.Fatalf("step %d: Allow returned error: %v", i, err)
+				}
+
+				if allowed != s.allowed || wait != s.wait {
+					t.Errorf("step %d: Allow(%q) = %v, %v, want %v, %v", i, s.key, allowed, wait, s.allowed, s.wait)
+				}
+			}
+		})
+	}
+}
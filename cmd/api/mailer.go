@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Mailer sends plain text emails
+type Mailer interface {
+	Send(to string, subject string, body string) error
+}
+
+// SMTPMailer sends emails through an SMTP server
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Sender   string
+}
+
+func (m *SMTPMailer) Send(to string, subject string, body string) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	// The envelope sender must be a bare address, the header can keep the display name
+	from := m.Sender
+	if address, err := mail.ParseAddress(m.Sender); err == nil {
+		from = address.Address
+	}
+
+	addr := fmt.Sprintf("%s:%d", m.Host, m.Port)
+
+	return smtp.SendMail(addr, auth, from, []string{to}, buildMessage(m.Sender, to, subject, body))
+}
+
+// LogMailer writes emails to a directory, or to the log when Dir is empty, so the
+// flows that send email can be used locally without a mail server
+type LogMailer struct {
+	Dir    string
+	Sender string
+}
+
+func (m *LogMailer) Send(to string, subject string, body string) error {
+	msg := buildMessage(m.Sender, to, subject, body)
+
+	if m.Dir == "" {
+		log.Printf("Email to %s:\n%s", to, msg)
+		return nil
+	}
+
+	err := os.MkdirAll(m.Dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	fileName := fmt.Sprintf("%s_%s.eml", time.Now().Format("20060102_150405.000000"), sanitizeAddress(to))
+
+	return os.WriteFile(filepath.Join(m.Dir, fileName), msg, 0644)
+}
+
+// buildMessage formats a plain text email with its headers
+func buildMessage(from string, to string, subject string, body string) []byte {
+	var sb strings.Builder
+
+	// Header values must not be able to add headers of their own
+	header := strings.NewReplacer("\r", "", "\n", "")
+	from, to, subject = header.Replace(from), header.Replace(to), header.Replace(subject)
+
+	sb.WriteString("From: " + from + "\r\n")
+	sb.WriteString("To: " + to + "\r\n")
+	sb.WriteString("Subject: " + subject + "\r\n")
+	sb.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=\"UTF-8\"\r\n")
+	sb.WriteString("\r\n")
+	sb.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return []byte(sb.String())
+}
+
+// sanitizeAddress keeps an email address usable as part of a file name
+func sanitizeAddress(address string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, address)
+}
+
+// sendEmail delivers an email in the background so the request does not wait for the mail server
+func (app *application) sendEmail(to string, subject string, body string) {
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Println("error sending email:", err)
+			}
+		}()
+
+		err := app.Mailer.Send(to, subject, body)
+		if err != nil {
+			log.Printf("error sending email to %s: %v", to, err)
+		}
+	}()
+}
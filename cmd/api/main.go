@@ -1,6 +1,7 @@
 package main
 
 import (
+	"alumnihub/internal/models"
 	"alumnihub/internal/repository"
 	"alumnihub/internal/repository/dbrepo"
 	"flag"
@@ -13,14 +14,20 @@ import (
 const port = 8080
 
 type application struct {
-	DSN          string
-	Domain       string
-	DB           repository.DatabaseRepo
-	auth         Auth
-	JWTSecret    string
-	JWTIssuer    string
-	JWTAudience  string
-	CookieDomain string
+	DSN                  string
+	Domain               string
+	DB                   repository.DatabaseRepo
+	auth                 Auth
+	JWTSecret            string
+	JWTIssuer            string
+	JWTAudience          string
+	CookieDomain         string
+	FrontendURL          string
+	RequireVerifiedEmail bool
+	Mailer               Mailer
+	Notifiers            map[string]Notifier
+	ipLimiter            RateLimiter
+	loginLimiter         RateLimiter
 }
 
 func main() {
@@ -34,8 +41,38 @@ func main() {
 	flag.StringVar(&app.JWTAudience, "jwt-audience", "example.com", "signing audience")
 	flag.StringVar(&app.CookieDomain, "cookie-domain", "alumnihub.site", "cookie domain")
 	flag.StringVar(&app.Domain, "domain", "example.com", "Domain")
+	flag.StringVar(&app.FrontendURL, "frontend-url", "http://localhost:3000", "frontend URL used in email links")
+	flag.BoolVar(&app.RequireVerifiedEmail, "require-verified-email", false, "refuse to log in users with an unverified email")
+
+	var smtpMailer SMTPMailer
+	var mailDir string
+	flag.StringVar(&smtpMailer.Host, "smtp-host", "", "SMTP host, emails are written to mail-dir when empty")
+	flag.IntVar(&smtpMailer.Port, "smtp-port", 587, "SMTP port")
+	flag.StringVar(&smtpMailer.Username, "smtp-username", "", "SMTP username")
+	flag.StringVar(&smtpMailer.Password, "smtp-password", "", "SMTP password")
+	flag.StringVar(&smtpMailer.Sender, "smtp-sender", "Alumnihub <no-reply@alumnihub.site>", "email sender")
+	flag.StringVar(&mailDir, "mail-dir", "", "directory for emails when no SMTP host is set, logged when empty")
+
+	var whatsApp WebhookNotifier
+	flag.StringVar(&whatsApp.URL, "whatsapp-webhook-url", "", "webhook of the WhatsApp gateway, the whatsapp channel is disabled when empty")
+	flag.StringVar(&whatsApp.Token, "whatsapp-webhook-token", "", "bearer token sent to the WhatsApp gateway")
 	flag.Parse()
 
+	if smtpMailer.Host != "" {
+		app.Mailer = &smtpMailer
+	} else {
+		app.Mailer = &LogMailer{Dir: mailDir, Sender: smtpMailer.Sender}
+	}
+
+	// Channels survey campaigns can be sent through
+	app.Notifiers = map[string]Notifier{
+		models.ChannelEmail: &EmailNotifier{Mailer: app.Mailer},
+		models.ChannelLog:   &LogNotifier{},
+	}
+	if whatsApp.URL != "" {
+		app.Notifiers[models.ChannelWhatsApp] = &whatsApp
+	}
+
 	//
 	conn, err := app.connectToDB()
 	if err != nil {
@@ -56,6 +93,13 @@ func main() {
 		CookieDomain:  app.CookieDomain,
 	}
 
+	// Requests per IP on the public auth endpoints, and failed logins per account
+	app.ipLimiter = newMemoryLimiter(20, time.Minute, time.Minute)
+	app.loginLimiter = newMemoryLimiter(5, time.Minute*15, time.Minute*15)
+
+	go app.runCampaigns(time.Minute)
+	go app.runArticlePublisher(time.Minute)
+
 	log.Println("Starting application on", port)
 
 	http.Handle("/public/", http.StripPrefix("/public/", http.FileServer(http.Dir("/home/ikramzaidann/alumnihub/public"))))
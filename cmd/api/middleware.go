@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"log"
 	"net/http"
 )
 
@@ -20,6 +22,10 @@ func (app *application) enableCORS(h http.Handler) http.Handler {
 	})
 }
 
+// authRequired verifies the access token once and stores its principal in the request context.
+// Access tokens stay valid until they expire, so the session they were issued for is looked
+// up on every request: ending a session, logging out or resetting the password locks the
+// token out right away instead of after up to an hour.
 func (app *application) authRequired(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		_, claims, err := app.auth.GetTokenFromHeaderAndVerify(w, r)
@@ -28,21 +34,41 @@ func (app *application) authRequired(next http.Handler) http.Handler {
 			return
 		}
 
-		// Simpan klaim dalam konteks request
-		ctx := context.WithValue(r.Context(), userClaimsKey, claims)
+		principal, err := newPrincipal(claims)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		active, err := app.DB.SessionActive(principal.SessionID)
+		if err != nil {
+			log.Println("error checking session:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if !active {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		// Simpan principal dalam konteks request
+		ctx := context.WithValue(r.Context(), principalKey, principal)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// adminRequired only lets staff accounts through. It must run after authRequired.
 func (app *application) adminRequired(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, claims, err := app.auth.GetTokenFromHeaderAndVerify(w, r)
+		principal, err := currentUser(r)
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
 
-		if !claims.IsAdmin {
+		// Staff roles always grant some permission, alumni accounts get none
+		if !principal.IsAdmin && len(principal.Permissions) == 0 {
 			w.WriteHeader(http.StatusForbidden)
 			return
 		}
@@ -50,3 +76,46 @@ func (app *application) adminRequired(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// requirePermission only lets through users whose token grants the permission.
+// It must run after authRequired, which stores the principal in the context.
+func (app *application) requirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := currentUser(r)
+			if err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			if !principal.HasPermission(permission) {
+				app.errorJSON(w, fmt.Errorf("missing permission %s", permission), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimit throttles requests per client IP
+func (app *application) rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := "ip:" + clientIP(r)
+
+		allowed, wait, err := app.ipLimiter.Allow(key)
+		if err != nil {
+			log.Println("error checking rate limit:", err)
+		} else if !allowed {
+			app.tooManyRequests(w, wait)
+			return
+		}
+
+		err = app.ipLimiter.Hit(key)
+		if err != nil {
+			log.Println("error recording rate limit hit:", err)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
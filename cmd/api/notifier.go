@@ -0,0 +1,96 @@
+package main
+
+import (
+	"alumnihub/internal/models"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Notification is a message to one alumni
+type Notification struct {
+	Recipient models.Recipient
+	Subject   string
+	Body      string
+}
+
+// Notifier delivers notifications over one channel. A nil error means the channel
+// accepted the notification, which counts as delivered.
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// EmailNotifier sends notifications as emails through the mailer of the application
+type EmailNotifier struct {
+	Mailer Mailer
+}
+
+func (e *EmailNotifier) Notify(n Notification) error {
+	if n.Recipient.Email == "" {
+		return errors.New("recipient has no email address")
+	}
+
+	return e.Mailer.Send(n.Recipient.Email, n.Subject, n.Body)
+}
+
+// WebhookNotifier posts notifications as JSON to a messaging gateway, such as a
+// WhatsApp provider, which delivers them to the phone number of the recipient
+type WebhookNotifier struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+func (wh *WebhookNotifier) Notify(n Notification) error {
+	if n.Recipient.Phone == "" {
+		return errors.New("recipient has no phone number")
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"to":      n.Recipient.Phone,
+		"name":    n.Recipient.Name,
+		"message": n.Subject + "\n\n" + n.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, wh.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if wh.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+wh.Token)
+	}
+
+	client := wh.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// LogNotifier writes notifications to the log, so campaigns can be tried locally
+type LogNotifier struct{}
+
+func (l *LogNotifier) Notify(n Notification) error {
+	log.Printf("Notification to %s (user %d):\n%s\n\n%s", n.Recipient.Name, n.Recipient.UserID, n.Subject, n.Body)
+	return nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// runArticlePublisher publishes scheduled articles once their publish time has passed.
+// Readers already see them from that time on, this keeps their status in line.
+func (app *application) runArticlePublisher(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		published, err := app.DB.PublishScheduledArticles(time.Now().UTC())
+		if err != nil {
+			log.Println("error publishing scheduled articles:", err)
+			continue
+		}
+
+		if published > 0 {
+			log.Printf("published %d scheduled articles", published)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"alumnihub/internal/models"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -14,27 +15,39 @@ func (app *application) routes() http.Handler {
 	mux.Use(app.enableCORS)
 
 	mux.Get("/", app.Home)
-	mux.Post("/authenticate", app.authenticate)
-	mux.Post("/register_check", app.registerCheck)
-	mux.Post("/register", app.register)
+	mux.With(app.rateLimit).Post("/authenticate", app.authenticate)
+	mux.With(app.rateLimit).Post("/register_check", app.registerCheck)
+	mux.With(app.rateLimit).Post("/register", app.register)
+	mux.With(app.rateLimit).Post("/password/forgot", app.forgotPassword)
+	mux.With(app.rateLimit).Post("/password/reset", app.resetPassword)
+	mux.With(app.rateLimit).Post("/email/verify", app.verifyEmail)
+	mux.With(app.rateLimit).Post("/email/verify/resend", app.resendVerificationEmail)
+	mux.Post("/invitations/{token}/open", app.openInvitation)
 	mux.Get("/refresh", app.refreshToken)
 	mux.Get("/logout", app.logout)
 	mux.Get("/public/{image_path}", app.serveImage)
-	mux.Get("/forms/{id}/answers/export", app.exportAnswers)
 
 	mux.Route("/", func(mux chi.Router) {
 		mux.Use(app.authRequired)
 
+		mux.Get("/search", app.search)
+
 		mux.Get("/alumni", app.AllAlumni)
 		mux.Get("/alumni/{id}", app.Alumni)
 
 		mux.Get("/articles", app.allArticles)
 		mux.Get("/articles/{slug}", app.article)
-
-		mux.Get("/forms", app.allForms)                   // Get all forms data
-		mux.Get("/forms/{id}", app.form)                  // Get a form data without questions
-		mux.Get("/forms/{id}/show", app.showForm)         // Get a complete form data with questions and options within the form
-		mux.Post("/forms/{id}/submit", app.insertAnswers) // Submit form answers
+		mux.Get("/categories", app.allCategories)
+		mux.Get("/tags", app.allTags)
+
+		mux.Get("/forms", app.allForms)                              // Get all forms data
+		mux.Get("/forms/{id}", app.form)                             // Get a form data without questions
+		mux.Get("/forms/{id}/show", app.showForm)                    // Get a complete form data with questions and options within the form
+		mux.Post("/forms/{id}/submit", app.insertAnswers)            // Submit form answers
+		mux.Get("/forms/{id}/draft", app.draft)                      // Get the saved draft of the user
+		mux.Put("/forms/{id}/draft", app.saveDraft)                  // Save the unfinished answers of the user
+		mux.Put("/forms/{id}/submission", app.updateSubmission)      // Replace the submitted answers of the user
+		mux.Delete("/forms/{id}/submission", app.withdrawSubmission) // Withdraw the submitted answers of the user
 
 		mux.Get("/forums", app.allForums) // Get all forum data
 		mux.Get("/forums/{id}", app.forum)
@@ -44,6 +57,7 @@ func (app *application) routes() http.Handler {
 		mux.Post("/forums/{id}/like", app.insertLike)
 		mux.Post("/forums/{id}/unlike", app.deleteLike)
 		mux.Post("/forums/{id}/reply", app.insertComment)
+		mux.Delete("/forums/{id}/replies/{rid}", app.deleteComment)
 
 		mux.Get("/profile", app.myProfile)
 		mux.Get("/profile/{username}", app.profile)
@@ -59,6 +73,9 @@ func (app *application) routes() http.Handler {
 		mux.Patch("/jobs/{id}", app.updateJob)
 		mux.Delete("/jobs/{id}", app.deleteJob)
 
+		mux.Get("/sessions", app.sessions)
+		mux.Delete("/sessions/{id}", app.deleteSession)
+
 		mux.Get("/likes", app.userLikes)
 		mux.Get("/answers", app.userAnswers)
 
@@ -68,29 +85,82 @@ func (app *application) routes() http.Handler {
 		mux.Route("/", func(mux chi.Router) {
 			mux.Use(app.adminRequired)
 
-			mux.Get("/dashboard", app.Dashboard)
-
-			mux.Post("/alumni/create", app.insertAlumni)
-			mux.Post("/alumni/import", app.importAlumni)
-			mux.Post("/alumni/import/save", app.insertImportAlumni)
-			mux.Patch("/alumni/{id}", app.updateAlumni)
-			mux.Delete("/alumni/{id}", app.deleteAlumni)
-
-			mux.Get("/articles/{id}/show", app.showArticle)
-			mux.Post("/articles/create", app.insertArticle)
-			mux.Patch("/articles/{id}", app.updateArticle)
-			mux.Delete("/articles/{id}", app.deleteArticle)
-
-			mux.Post("/forms/create", app.insertForm)
-			mux.Patch("/forms/{id}", app.updateForm)
-			mux.Delete("/forms/{id}", app.deleteForm)
-			mux.Get("/forms/{id}/answers", app.showFormAnswers)
-			mux.Get("/forms/{fid}/questions/{qid}/answers", app.showQuestionAnswers)
-
-			mux.Get("/questions/{id}", app.question)
-			mux.Post("/questions/create", app.insertQuestion)
-			mux.Delete("/questions/{id}", app.deleteQuestion)
-			mux.Patch("/questions/{id}", app.updateQuestion)
+			mux.With(app.requirePermission(models.PermDashboardRead)).Get("/dashboard", app.Dashboard)
+
+			mux.Group(func(mux chi.Router) {
+				mux.Use(app.requirePermission(models.PermUsersManage))
+
+				mux.Post("/users/{id}/unlock", app.unlockUser)
+				mux.Get("/roles", app.allRoles)
+				mux.Get("/users/{id}/roles", app.userRoles)
+				mux.Put("/users/{id}/roles", app.updateUserRoles)
+			})
+
+			mux.With(app.requirePermission(models.PermAuditRead)).Get("/admin/audit", app.auditLog)
+
+			mux.Group(func(mux chi.Router) {
+				mux.Use(app.requirePermission(models.PermAlumniWrite))
+
+				mux.Post("/alumni/create", app.insertAlumni)
+				mux.Post("/alumni/import", app.importAlumni)
+				mux.Post("/alumni/import/save", app.insertImportAlumni)
+				mux.Patch("/alumni/{id}", app.updateAlumni)
+				mux.Delete("/alumni/{id}", app.deleteAlumni)
+			})
+
+			mux.Group(func(mux chi.Router) {
+				mux.Use(app.requirePermission(models.PermArticlesWrite))
+
+				mux.Get("/articles/{id}/show", app.showArticle)
+				mux.Post("/articles/create", app.insertArticle)
+				mux.Patch("/articles/{id}", app.updateArticle)
+				mux.Delete("/articles/{id}", app.deleteArticle)
+				mux.Patch("/articles/{id}/status", app.setArticleStatus)
+				mux.Post("/articles/{id}/unpublish", app.unpublishArticle)
+				mux.Get("/articles/{id}/revisions", app.articleRevisions)
+				mux.Get("/articles/{id}/revisions/{rev}/diff", app.articleRevisionDiff)
+				mux.Post("/articles/{id}/revisions/{rev}/restore", app.restoreArticleRevision)
+
+				mux.Post("/categories/create", app.insertCategory)
+				mux.Patch("/categories/{id}", app.updateCategory)
+				mux.Delete("/categories/{id}", app.deleteCategory)
+				mux.Post("/tags/create", app.insertTag)
+				mux.Patch("/tags/{id}", app.updateTag)
+				mux.Delete("/tags/{id}", app.deleteTag)
+			})
+
+			mux.Group(func(mux chi.Router) {
+				mux.Use(app.requirePermission(models.PermFormsWrite))
+
+				mux.Post("/forms/create", app.insertForm)
+				mux.Patch("/forms/{id}", app.updateForm)
+				mux.Delete("/forms/{id}", app.deleteForm)
+				mux.Post("/forms/{id}/duplicate", app.duplicateForm)
+				mux.Patch("/forms/{id}/questions/order", app.reorderQuestions)
+				mux.Post("/forms/{id}/sections", app.insertSection)
+				mux.Patch("/sections/{id}", app.updateSection)
+				mux.Delete("/sections/{id}", app.deleteSection)
+
+				mux.Post("/forms/{id}/campaigns/preview", app.previewCampaign)
+				mux.Post("/forms/{id}/campaigns", app.insertCampaign)
+				mux.Get("/forms/{id}/campaigns", app.formCampaigns)
+				mux.Get("/campaigns/{id}", app.campaign)
+
+				mux.Get("/questions/{id}", app.question)
+				mux.Post("/questions/create", app.insertQuestion)
+				mux.Delete("/questions/{id}", app.deleteQuestion)
+				mux.Patch("/questions/{id}", app.updateQuestion)
+			})
+
+			mux.Group(func(mux chi.Router) {
+				mux.Use(app.requirePermission(models.PermAnswersRead))
+
+				mux.Get("/forms/{id}/answers", app.showFormAnswers)
+				mux.Get("/forms/{fid}/questions/{qid}/answers", app.showQuestionAnswers)
+				mux.Get("/forms/{id}/analytics", app.formAnalytics)
+				mux.Get("/forms/{id}/answers/export", app.exportAnswers)
+				mux.Get("/forms/{id}/submissions/{uid}/versions", app.submissionVersions)
+			})
 		})
 	})
 
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlPolicy is an allow-list of the HTML that may be stored. Elements that are not
+// allowed are unwrapped, keeping their text, except the ones in dropContent which are
+// removed together with everything inside them.
+type htmlPolicy struct {
+	// elements maps the allowed tags to their allowed attributes
+	elements map[string][]string
+	// globalAttrs are allowed on every allowed element
+	globalAttrs []string
+	// linkRel is forced on every link
+	linkRel string
+	// dataImages allows inline base64 images in img src
+	dataImages bool
+}
+
+// dropContent are elements whose content is never shown as text
+var dropContent = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"iframe":   true,
+	"object":   true,
+	"embed":    true,
+	"noscript": true,
+	"template": true,
+	"svg":      true,
+	"math":     true,
+	"head":     true,
+	"title":    true,
+	"textarea": true,
+	"select":   true,
+}
+
+// urlAttrs are the attributes that hold URLs and are checked against safeURL
+var urlAttrs = map[string]bool{
+	"href": true,
+	"src":  true,
+}
+
+// articlePolicy allows the formatting, images and tables the article editor produces
+var articlePolicy = &htmlPolicy{
+	elements: map[string][]string{
+		"p": nil, "br": nil, "hr": nil, "div": nil, "span": nil,
+		"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+		"strong": nil, "b": nil, "em": nil, "i": nil, "u": nil, "s": nil, "strike": nil, "sub": nil, "sup": nil,
+		"blockquote": nil, "pre": nil, "code": nil,
+		"ul": nil, "ol": {"start"}, "li": nil,
+		"a":      {"href", "title", "target"},
+		"img":    {"src", "alt", "title", "width", "height"},
+		"figure": nil, "figcaption": nil,
+		"table": nil, "thead": nil, "tbody": nil, "tr": nil,
+		"th": {"colspan", "rowspan"}, "td": {"colspan", "rowspan"},
+	},
+	globalAttrs: []string{"class"},
+	linkRel:     "noopener",
+	dataImages:  true,
+}
+
+// postPolicy allows the light formatting of forum posts and replies written by alumni
+var postPolicy = &htmlPolicy{
+	elements: map[string][]string{
+		"p": nil, "br": nil,
+		"strong": nil, "b": nil, "em": nil, "i": nil, "u": nil, "s": nil,
+		"blockquote": nil, "pre": nil, "code": nil,
+		"ul": nil, "ol": nil, "li": nil,
+		"a": {"href"},
+	},
+	linkRel: "noopener nofollow ugc",
+}
+
+// Sanitize parses input as the content of a body element and renders what the policy allows
+func (p *htmlPolicy) Sanitize(input string) (string, error) {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+
+	nodes, err := html.ParseFragment(strings.NewReader(input), body)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, node := range nodes {
+		for _, clean := range p.clean(node) {
+			err = html.Render(&sb, clean)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// clean returns the nodes that replace n once the policy is applied to it and its children
+func (p *htmlPolicy) clean(n *html.Node) []*html.Node {
+	switch n.Type {
+	case html.TextNode:
+		return []*html.Node{n}
+	case html.ElementNode:
+	default:
+		// Comments, doctypes and the like are dropped
+		return nil
+	}
+
+	tag := strings.ToLower(n.Data)
+	if dropContent[tag] {
+		return nil
+	}
+
+	var children []*html.Node
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		n.RemoveChild(c)
+		children = append(children, p.clean(c)...)
+		c = next
+	}
+
+	allowed, ok := p.elements[tag]
+	if !ok {
+		return children
+	}
+
+	var attrs []html.Attribute
+	for _, attr := range n.Attr {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace != "" || !(containsString(allowed, key) || containsString(p.globalAttrs, key)) {
+			continue
+		}
+
+		if urlAttrs[key] && !p.safeURL(tag, attr.Val) {
+			continue
+		}
+
+		if key == "target" && attr.Val != "_blank" {
+			continue
+		}
+
+		attrs = append(attrs, html.Attribute{Key: key, Val: attr.Val})
+	}
+
+	if tag == "a" && p.linkRel != "" {
+		attrs = append(attrs, html.Attribute{Key: "rel", Val: p.linkRel})
+	}
+
+	n.Attr = attrs
+	for _, child := range children {
+		n.AppendChild(child)
+	}
+
+	return []*html.Node{n}
+}
+
+// safeURL reports whether a URL may be kept. Relative URLs and http, https and mailto are
+// allowed, anything else such as javascript: is not.
+func (p *htmlPolicy) safeURL(tag string, value string) bool {
+	// Browsers ignore whitespace and control characters inside the scheme
+	url := strings.ToLower(strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, value))
+
+	colon := strings.IndexByte(url, ':')
+	if colon < 0 || strings.ContainsAny(url[:colon], "/?#") {
+		return true
+	}
+
+	switch url[:colon] {
+	case "http", "https":
+		return true
+	case "mailto":
+		return tag == "a"
+	case "data":
+		if !p.dataImages || tag != "img" {
+			return false
+		}
+		for _, prefix := range []string{"data:image/png;", "data:image/jpeg;", "data:image/gif;", "data:image/webp;"} {
+			if strings.HasPrefix(url, prefix) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
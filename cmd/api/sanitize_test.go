@@ -0,0 +1,70 @@
+package main
+
+import "testing"
+
+func TestArticlePolicySanitize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"javascript link", `<a href="javascript:alert(1)">x</a>`, `<a rel="noopener">x</a>`},
+		{"javascript link with tab", "<a href=\"java\tscript:alert(1)\">x</a>", `<a rel="noopener">x</a>`},
+		{"javascript link with leading space", `<a href=" javascript:alert(1)">x</a>`, `<a rel="noopener">x</a>`},
+		{"javascript link in mixed case", `<a href="JaVaScRiPt:alert(1)">x</a>`, `<a rel="noopener">x</a>`},
+		{"javascript link with entity", `<a href="&#106;avascript:alert(1)">x</a>`, `<a rel="noopener">x</a>`},
+		{"noscript breakout", `<noscript><p title="</noscript><img src=x onerror=alert(1)>"></noscript>`, `<img src="x"/>&#34;&gt;`},
+		{"xmp breakout", `<xmp><img src=x onerror=alert(1)></xmp>`, `&lt;img src=x onerror=alert(1)&gt;`},
+		{"plaintext breakout", `<plaintext><img src=x onerror=alert(1)>`, `&lt;img src=x onerror=alert(1)&gt;`},
+		{"svg data image", `<img src="data:image/svg+xml;base64,PHN2Zz4=">`, `<img/>`},
+		{"png data image", `<img src="data:image/png;base64,iVBORw0KGgo=" alt="dot">`, `<img src="data:image/png;base64,iVBORw0KGgo=" alt="dot"/>`},
+		{"rel override", `<a href="https://example.com" rel="opener" target="_blank">x</a>`, `<a href="https://example.com" target="_blank" rel="noopener">x</a>`},
+		{"target other than blank", `<a href="https://example.com" target="_self">x</a>`, `<a href="https://example.com" rel="noopener">x</a>`},
+		{"svg", `<svg><script>alert(1)</script><a href="https://x">svg</a></svg>`, ``},
+		{"math", `<math><mi xlink:href="javascript:alert(1)">m</mi></math>`, ``},
+		{"event handlers and style", `<p onclick="alert(1)" class="lead" style="color:red">Hi</p>`, `<p class="lead">Hi</p>`},
+		{"script", `<script>alert(1)</script><p>ok</p>`, `<p>ok</p>`},
+		{"style and iframe", `<style>p{}</style><iframe src="https://x"></iframe>text`, `text`},
+		{"mailto only on links", `<a href="mailto:a@b.c">mail</a><img src="mailto:a@b.c">`, `<a href="mailto:a@b.c" rel="noopener">mail</a><img/>`},
+		{"relative link", `<a href="/articles/x">rel</a>`, `<a href="/articles/x" rel="noopener">rel</a>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := articlePolicy.Sanitize(tt.input)
+			if err != nil {
+				t.Fatalf("Sanitize(%q) returned error: %v", tt.input, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostPolicySanitize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"links are nofollow", `<a href="https://x">x</a>`, `<a href="https://x" rel="noopener nofollow ugc">x</a>`},
+		{"no images or classes", `<p class="x">hi <img src="https://x/y.png"></p>`, `<p>hi </p>`},
+		{"no headings", `<h1>t</h1>`, `t`},
+		{"javascript link", `<a href="javascript:alert(1)">x</a>`, `<a rel="noopener nofollow ugc">x</a>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := postPolicy.Sanitize(tt.input)
+			if err != nil {
+				t.Fatalf("Sanitize(%q) returned error: %v", tt.input, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
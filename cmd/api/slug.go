@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxSlugLength keeps slugs short enough for URLs and leaves room for a -N suffix
+const maxSlugLength = 200
+
+// slugReplacer spells out symbols and folds the accented letters found in Indonesian
+// titles, loanwords and names to plain ASCII
+var slugReplacer = strings.NewReplacer(
+	"&", " dan ",
+	"%", " persen ",
+	"@", " at ",
+	"'", "", "’", "", "‘", "", "`", "",
+	"à", "a", "á", "a", "â", "a", "ä", "a", "ã", "a", "å", "a",
+	"è", "e", "é", "e", "ê", "e", "ë", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i",
+	"ò", "o", "ó", "o", "ô", "o", "ö", "o", "õ", "o",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n", "ý", "y", "ÿ", "y",
+	"æ", "ae", "œ", "oe", "ß", "ss",
+)
+
+// slugify turns a title into a lowercase slug of ASCII letters, digits and single dashes
+func slugify(title string) string {
+	s := slugReplacer.Replace(strings.ToLower(title))
+
+	var sb strings.Builder
+	dash := false
+
+	for _, r := range s {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if dash && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			sb.WriteRune(r)
+			dash = false
+		default:
+			dash = true
+		}
+
+		if sb.Len() >= maxSlugLength {
+			break
+		}
+	}
+
+	// A dash and a letter can be written past the limit, the slug is plain ASCII so it can be cut
+	slug := sb.String()
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+	}
+
+	return strings.Trim(slug, "-")
+}
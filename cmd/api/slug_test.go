@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"words and digits", "Reuni Akbar 2024", "reuni-akbar-2024"},
+		{"accents and ampersand", "Café & Résumé", "cafe-dan-resume"},
+		{"percent", "Diskon 50% Alumni", "diskon-50-persen-alumni"},
+		{"at sign", "Email @ Sekolah", "email-at-sekolah"},
+		{"apostrophe", "Jum'at Berkah", "jumat-berkah"},
+		{"punctuation and spaces", "  --Hello,   World!--  ", "hello-world"},
+		{"no ascii letters", "日本語", ""},
+		{"empty", "", ""},
+		{"cut at a dash", strings.Repeat("a", maxSlugLength-1) + " b", strings.Repeat("a", maxSlugLength-1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slugify(tt.input); got != tt.want {
+				t.Errorf("slugify(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugifyMaxLength(t *testing.T) {
+	for _, word := range []string{"a", "ab", "abc", "abcdefg"} {
+		got := slugify(strings.Repeat(word+" ", maxSlugLength))
+
+		if len(got) > maxSlugLength {
+			t.Errorf("slugify of repeated %q is %d characters long, want at most %d", word, len(got), maxSlugLength)
+		}
+
+		if strings.HasSuffix(got, "-") {
+			t.Errorf("slugify of repeated %q ends with a dash", word)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"alumnihub/internal/models"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	passwordResetExpiry     = time.Hour
+	emailVerificationExpiry = time.Hour * 48
+)
+
+// generateUserToken creates a random single-use token. The plain text is sent to the user,
+// only its hash is stored.
+func generateUserToken(userID int, ttl time.Duration, scope string) (string, models.UserToken, error) {
+	randomBytes := make([]byte, 20)
+
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", models.UserToken{}, err
+	}
+
+	plain := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
+
+	token := models.UserToken{
+		UserID:    userID,
+		Hash:      hashToken(plain),
+		Scope:     scope,
+		ExpiresAt: time.Now().Add(ttl),
+		CreatedAt: time.Now(),
+	}
+
+	return plain, token, nil
+}
+
+// hashToken returns the hex encoded SHA-256 hash of a plain text token
+func hashToken(plain string) string {
+	hash := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(hash[:])
+}
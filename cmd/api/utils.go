@@ -1,11 +1,17 @@
 package main
 
 import (
+	"alumnihub/internal/models"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/net/html"
 )
@@ -14,6 +20,7 @@ type JSONResponse struct {
 	Error   bool        `json:"error"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
+	Meta    interface{} `json:"meta,omitempty"`
 }
 
 func (app *application) writeJSON(w http.ResponseWriter, status int, data interface{}, headers ...http.Header) error {
@@ -59,6 +66,50 @@ func (app *application) readJSON(w http.ResponseWriter, r *http.Request, data in
 	return nil
 }
 
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+// readQueryOptions reads page, per_page and sort from the query string, plus any of the allowed filters
+func (app *application) readQueryOptions(r *http.Request, filters ...string) (models.QueryOptions, error) {
+	qs := r.URL.Query()
+
+	opts := models.QueryOptions{
+		Page:    1,
+		PerPage: defaultPerPage,
+		Sort:    strings.TrimSpace(qs.Get("sort")),
+		Filters: make(map[string]string),
+	}
+
+	if page := qs.Get("page"); page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			return opts, errors.New("page must be a positive number")
+		}
+		opts.Page = n
+	}
+
+	if perPage := qs.Get("per_page"); perPage != "" {
+		n, err := strconv.Atoi(perPage)
+		if err != nil || n < 1 {
+			return opts, errors.New("per_page must be a positive number")
+		}
+		if n > maxPerPage {
+			return opts, fmt.Errorf("per_page cannot be more than %d", maxPerPage)
+		}
+		opts.PerPage = n
+	}
+
+	for _, key := range filters {
+		if value := strings.TrimSpace(qs.Get(key)); value != "" {
+			opts.Filters[key] = value
+		}
+	}
+
+	return opts, nil
+}
+
 func (app *application) errorJSON(w http.ResponseWriter, err error, status ...int) error {
 	statusCode := http.StatusBadRequest
 
@@ -127,3 +178,19 @@ func (app *application) sanitizeFileName(title string) string {
 		return -1
 	}, title), "__", "_")
 }
+
+// tooManyRequests tells the client to slow down and when it may try again
+func (app *application) tooManyRequests(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	app.errorJSON(w, errors.New("too many attempts, please try again later"), http.StatusTooManyRequests)
+}
+
+// clientIP returns the address of the client that made the request, without the port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
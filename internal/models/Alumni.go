@@ -40,3 +40,18 @@ type AlumniJob struct {
 	CreatedAt        time.Time `json:"created_at,omitempty"`
 	UpdatedAt        time.Time `json:"updated_at,omitempty"`
 }
+
+type AlumniImportRow struct {
+	Row       int      `json:"row"`
+	Alumni    Alumni   `json:"alumni"`
+	Errors    []string `json:"errors,omitempty"`
+	Duplicate bool     `json:"duplicate"`
+}
+
+type AlumniImportPreview struct {
+	Rows       []*AlumniImportRow `json:"rows"`
+	Total      int                `json:"total"`
+	Valid      int                `json:"valid"`
+	Invalid    int                `json:"invalid"`
+	Duplicates int                `json:"duplicates"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import "math"
+
+// FormAnalytics summarizes the responses to a form
+type FormAnalytics struct {
+	FormID           int                  `json:"form_id"`
+	TotalAlumni      int                  `json:"total_alumni"`
+	Respondents      int                  `json:"respondents"`
+	ResponseRate     float64              `json:"response_rate"`
+	ByGraduationYear []*ResponseBreakdown `json:"by_graduation_year"`
+	ByClass          []*ResponseBreakdown `json:"by_class"`
+	ByGender         []*ResponseBreakdown `json:"by_gender"`
+	Questions        []*QuestionStats     `json:"questions"`
+	CrossTab         *CrossTab            `json:"cross_tab,omitempty"`
+}
+
+// ResponseBreakdown is the response rate of one group of alumni
+type ResponseBreakdown struct {
+	Group        string  `json:"group"`
+	Alumni       int     `json:"alumni"`
+	Respondents  int     `json:"respondents"`
+	ResponseRate float64 `json:"response_rate"`
+}
+
+type QuestionStats struct {
+	QuestionID int             `json:"question_id"`
+	Question   string          `json:"question_text"`
+	Type       string          `json:"type"`
+	Responses  int             `json:"responses"`
+	Answers    []*GroupAnswer  `json:"answers,omitempty"`
+	Summary    *NumericSummary `json:"summary,omitempty"`
+}
+
+// NumericSummary describes the answers to a linear scale or number question
+type NumericSummary struct {
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+}
+
+// CrossTab counts the respondents for every pair of answers to two questions
+type CrossTab struct {
+	RowQuestionID    int             `json:"row_question_id"`
+	ColumnQuestionID int             `json:"column_question_id"`
+	Cells            []*CrossTabCell `json:"cells"`
+}
+
+type CrossTabCell struct {
+	Row    string `json:"row"`
+	Column string `json:"column"`
+	Count  int    `json:"count"`
+}
+
+// Rate returns part as a percentage of total, rounded to two decimals
+func Rate(part int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return math.Round(float64(part)/float64(total)*10000) / 100
+}
@@ -1,16 +1,77 @@
 package models
 
+import "time"
+
+// Answer is a single stored value. Checkboxes store one answer per picked option and
+// grids one answer per row, with Row naming the row.
 type Answer struct {
-	ID         int    `json:"id"`
-	UserID     int    `json:"user_id"`
-	FormID     int    `json:"form_id"`
-	QuestionID int    `json:"question_id"`
-	Answer     string `json:"answer_text"`
+	ID           int    `json:"id"`
+	UserID       int    `json:"user_id"`
+	FormID       int    `json:"form_id"`
+	QuestionID   int    `json:"question_id"`
+	Answer       string `json:"answer_text"`
+	SubmissionID int    `json:"submission_id,omitempty"`
+	Row          string `json:"answer_row,omitempty"`
+	// Values and Grid are only read from submissions of checkboxes and grid questions
+	Values []string          `json:"answer_values,omitempty"`
+	Grid   map[string]string `json:"answer_grid,omitempty"`
+}
+
+// FormSubmission groups the answers a user gave to a form
+type FormSubmission struct {
+	ID          int       `json:"id"`
+	FormID      int       `json:"form_id"`
+	UserID      int       `json:"user_id"`
+	SubmittedAt time.Time `json:"submitted_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Answers     []*Answer `json:"answers,omitempty"`
+}
+
+const (
+	SubmissionSubmitted = "submit"
+	SubmissionEdited    = "edit"
+	SubmissionWithdrawn = "withdraw"
+)
+
+// SubmissionVersion is a snapshot of the answers of a user to a form, taken every time
+// the submission is made, edited or withdrawn. Withdrawals keep the withdrawn answers.
+type SubmissionVersion struct {
+	ID           int       `json:"id"`
+	FormID       int       `json:"form_id"`
+	UserID       int       `json:"user_id"`
+	SubmissionID int       `json:"submission_id,omitempty"`
+	Version      int       `json:"version"`
+	Action       string    `json:"action"`
+	Answers      []*Answer `json:"answers"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// FormDraft holds the answers a user saved before submitting a form. The answers are kept
+// as they were sent and only validated on submit.
+type FormDraft struct {
+	ID        int       `json:"id"`
+	FormID    int       `json:"form_id"`
+	UserID    int       `json:"user_id"`
+	Answers   []*Answer `json:"answers"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type GroupAnswer struct {
 	FormID     int    `json:"form_id"`
 	QuestionID int    `json:"question_id"`
 	Answer     string `json:"answer_text"`
+	Row        string `json:"answer_row,omitempty"`
 	Count      int    `json:"answer_count"`
 }
+
+// Respondent is a user who answered a form, with the alumni data used in exports
+type Respondent struct {
+	UserID   int       `json:"user_id"`
+	Username string    `json:"username"`
+	Name     string    `json:"name"`
+	NISN     string    `json:"nisn"`
+	Year     int       `json:"graduation_year"`
+	Class    string    `json:"class"`
+	Answers  []*Answer `json:"answers"`
+}
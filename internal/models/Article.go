@@ -1,15 +1,161 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	ArticleDraft     = "draft"
+	ArticleReview    = "review"
+	ArticleScheduled = "scheduled"
+	ArticlePublished = "published"
+)
+
+// articleTransitions lists the statuses an article may move to from each status.
+// Scheduled articles are published by the publisher once their time has come.
+var articleTransitions = map[string][]string{
+	ArticleDraft:     {ArticleDraft, ArticleReview, ArticleScheduled, ArticlePublished},
+	ArticleReview:    {ArticleDraft, ArticleReview, ArticleScheduled, ArticlePublished},
+	ArticleScheduled: {ArticleDraft, ArticleScheduled, ArticlePublished},
+	ArticlePublished: {ArticleDraft, ArticlePublished},
+}
 
 type Article struct {
+	ID          int         `json:"id"`
+	Title       string      `json:"title"`
+	Slug        string      `json:"slug"`
+	Body        string      `json:"body"`
+	Image       string      `json:"image,omitempty"`
+	Status      string      `json:"status"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	PublishedAt *time.Time  `json:"published_at"`
+	Categories  []*Category `json:"categories,omitempty"`
+	Tags        []*Tag      `json:"tags,omitempty"`
+	Related     []*Article  `json:"related,omitempty"`
+	// CategoryIDs and TagNames set the taxonomy of an article when it is saved
+	CategoryIDs []int    `json:"category_ids,omitempty"`
+	TagNames    []string `json:"tag_names,omitempty"`
+}
+
+// Category groups articles by subject, an article can be in several categories
+type Category struct {
 	ID          int       `json:"id"`
-	Title       string    `json:"title"`
+	Name        string    `json:"name"`
 	Slug        string    `json:"slug"`
-	Body        string    `json:"body"`
-	Image       string    `json:"image,omitempty"`
-	Status      string    `json:"status"`
+	Description string    `json:"description,omitempty"`
+	Articles    int       `json:"articles_count,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
-	PublishedAt time.Time `json:"published_at"`
+}
+
+// Tag is a free-form label of articles, created the first time an article uses it
+type Tag struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	Articles  int       `json:"articles_count,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Transition moves the article to status. Publishing with publishAt in the future
+// schedules the article instead, and publishing without it publishes it now.
+// Drafts and articles in review have no publish time.
+func (a *Article) Transition(status string, publishAt *time.Time, now time.Time) error {
+	if publishAt != nil && publishAt.IsZero() {
+		publishAt = nil
+	}
+
+	// published_at is stored without a time zone and read back as UTC, so publish times
+	// are kept in UTC and now, like every visibility check, has to be in UTC as well
+	if publishAt != nil {
+		local := publishAt.In(now.Location())
+		publishAt = &local
+	}
+
+	from := a.Status
+	if from == "" {
+		from = ArticleDraft
+	}
+
+	allowed, ok := articleTransitions[from]
+	if !ok {
+		return fmt.Errorf("unknown article status %s", from)
+	}
+
+	found := false
+	for _, s := range allowed {
+		if s == status {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("an article cannot go from %s to %s", from, status)
+	}
+
+	switch status {
+	case ArticleDraft, ArticleReview:
+		a.Status = status
+		a.PublishedAt = nil
+	case ArticleScheduled, ArticlePublished:
+		switch {
+		case publishAt != nil && publishAt.After(now):
+			a.Status = ArticleScheduled
+			a.PublishedAt = publishAt
+		case status == ArticleScheduled:
+			return errors.New("scheduled articles need a published_at in the future")
+		case a.Status == ArticlePublished && a.PublishedAt != nil && publishAt == nil:
+			// Already published articles keep their publish time
+		default:
+			a.Status = ArticlePublished
+			a.PublishedAt = &now
+			if publishAt != nil {
+				a.PublishedAt = publishAt
+			}
+		}
+	}
+
+	return nil
+}
+
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// ArticleRevision is a snapshot of an article taken every time it is saved
+type ArticleRevision struct {
+	ID             int       `json:"id"`
+	ArticleID      int       `json:"article_id"`
+	Revision       int       `json:"revision"`
+	Title          string    `json:"title"`
+	Slug           string    `json:"slug"`
+	Body           string    `json:"body,omitempty"`
+	Status         string    `json:"status"`
+	AuthorID       int       `json:"author_id,omitempty"`
+	AuthorUsername string    `json:"author_username,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// DiffLine is one line of the body in a diff between two revisions
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// ArticleDiff shows what changed from one revision of an article to another. Revision 0
+// stands for an empty article, so the first revision shows everything as inserted.
+type ArticleDiff struct {
+	ArticleID int         `json:"article_id"`
+	From      int         `json:"from"`
+	To        int         `json:"to"`
+	TitleFrom string      `json:"title_from"`
+	TitleTo   string      `json:"title_to"`
+	Added     int         `json:"added"`
+	Removed   int         `json:"removed"`
+	Lines     []*DiffLine `json:"lines"`
 }
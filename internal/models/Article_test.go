@@ -0,0 +1,108 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestArticleTransition(t *testing.T) {
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	past := now.Add(-24 * time.Hour)
+	future := now.Add(24 * time.Hour)
+	earlier := now.Add(-72 * time.Hour)
+
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	// 15:00 in Jakarta is 08:00 UTC, before now, and 18:00 is 11:00 UTC, after now
+	pastJakarta := time.Date(2024, 6, 1, 15, 0, 0, 0, jakarta)
+	futureJakarta := time.Date(2024, 6, 1, 18, 0, 0, 0, jakarta)
+
+	tests := []struct {
+		name          string
+		from          string
+		publishedAt   *time.Time
+		to            string
+		publishAt     *time.Time
+		wantStatus    string
+		wantPublished *time.Time
+		wantErr       string
+	}{
+		{"new article to draft", "", nil, ArticleDraft, nil, ArticleDraft, nil, ""},
+		{"new article published", "", nil, ArticlePublished, nil, ArticlePublished, &now, ""},
+		{"draft to review", ArticleDraft, nil, ArticleReview, nil, ArticleReview, nil, ""},
+		{"draft published now", ArticleDraft, nil, ArticlePublished, nil, ArticlePublished, &now, ""},
+		{"draft published with a past time", ArticleDraft, nil, ArticlePublished, &past, ArticlePublished, &past, ""},
+		{"draft published with a future time is scheduled", ArticleDraft, nil, ArticlePublished, &future, ArticleScheduled, &future, ""},
+		{"draft scheduled", ArticleDraft, nil, ArticleScheduled, &future, ArticleScheduled, &future, ""},
+		{"draft scheduled without a time", ArticleDraft, nil, ArticleScheduled, nil, ArticleDraft, nil, "scheduled articles need a published_at in the future"},
+		{"draft scheduled with a past time", ArticleDraft, nil, ArticleScheduled, &past, ArticleDraft, nil, "scheduled articles need a published_at in the future"},
+		{"draft scheduled with a zero time", ArticleDraft, nil, ArticleScheduled, &time.Time{}, ArticleDraft, nil, "scheduled articles need a published_at in the future"},
+		{"review back to draft", ArticleReview, nil, ArticleDraft, nil, ArticleDraft, nil, ""},
+		{"review published", ArticleReview, nil, ArticlePublished, nil, ArticlePublished, &now, ""},
+		{"scheduled back to draft drops the time", ArticleScheduled, &future, ArticleDraft, nil, ArticleDraft, nil, ""},
+		{"scheduled to review", ArticleScheduled, &future, ArticleReview, nil, ArticleScheduled, &future, "an article cannot go from scheduled to review"},
+		{"scheduled published now", ArticleScheduled, &future, ArticlePublished, nil, ArticlePublished, &now, ""},
+		{"scheduled again with a past time in another zone", ArticleScheduled, &future, ArticleScheduled, &pastJakarta, ArticleScheduled, &future, "scheduled articles need a published_at in the future"},
+		{"published keeps its time", ArticlePublished, &earlier, ArticlePublished, nil, ArticlePublished, &earlier, ""},
+		{"published keeps its time with a zero time", ArticlePublished, &earlier, ArticlePublished, &time.Time{}, ArticlePublished, &earlier, ""},
+		{"published with a new past time", ArticlePublished, &earlier, ArticlePublished, &past, ArticlePublished, &past, ""},
+		{"published with a future time is scheduled again", ArticlePublished, &earlier, ArticlePublished, &future, ArticleScheduled, &future, ""},
+		{"published back to draft", ArticlePublished, &earlier, ArticleDraft, nil, ArticleDraft, nil, ""},
+		{"published to review", ArticlePublished, &earlier, ArticleReview, nil, ArticlePublished, &earlier, "an article cannot go from published to review"},
+		{"published to scheduled", ArticlePublished, &earlier, ArticleScheduled, &future, ArticlePublished, &earlier, "an article cannot go from published to scheduled"},
+		{"unknown status", "archived", nil, ArticleDraft, nil, "archived", nil, "unknown article status archived"},
+		{"past time in another zone", ArticleDraft, nil, ArticlePublished, &pastJakarta, ArticlePublished, &pastJakarta, ""},
+		{"future time in another zone", ArticleDraft, nil, ArticlePublished, &futureJakarta, ArticleScheduled, &futureJakarta, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article := Article{Status: tt.from, PublishedAt: tt.publishedAt}
+
+			err := article.Transition(tt.to, tt.publishAt, now)
+
+			gotErr := ""
+			if err != nil {
+				gotErr = err.Error()
+			}
+
+			if gotErr != tt.wantErr {
+				t.Fatalf("Transition error = %q, want %q", gotErr, tt.wantErr)
+			}
+
+			if article.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", article.Status, tt.wantStatus)
+			}
+
+			switch {
+			case tt.wantPublished == nil && article.PublishedAt != nil:
+				t.Errorf("published_at = %v, want none", *article.PublishedAt)
+			case tt.wantPublished != nil && article.PublishedAt == nil:
+				t.Errorf("published_at is missing, want %v", *tt.wantPublished)
+			case tt.wantPublished != nil && !article.PublishedAt.Equal(*tt.wantPublished):
+				t.Errorf("published_at = %v, want %v", *article.PublishedAt, *tt.wantPublished)
+			}
+		})
+	}
+}
+
+func TestArticleTransitionStoresUTC(t *testing.T) {
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	jakarta := time.FixedZone("WIB", 7*60*60)
+
+	// published_at is stored without a time zone, so its wall clock has to be the UTC one
+	for _, publishAt := range []time.Time{
+		time.Date(2024, 6, 1, 15, 0, 0, 0, jakarta),
+		time.Date(2024, 6, 1, 18, 0, 0, 0, jakarta),
+	} {
+		article := Article{Status: ArticleDraft}
+
+		err := article.Transition(ArticlePublished, &publishAt, now)
+		if err != nil {
+			t.Fatalf("Transition returned error: %v", err)
+		}
+
+		if article.PublishedAt.Location() != time.UTC || article.PublishedAt.Hour() != publishAt.UTC().Hour() {
+			t.Errorf("published_at = %v, want %v", *article.PublishedAt, publishAt.UTC())
+		}
+	}
+}
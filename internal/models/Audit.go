@@ -0,0 +1,29 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+	AuditImport = "import"
+	AuditUnlock = "unlock"
+	AuditRoles  = "roles.update"
+)
+
+type AuditEntry struct {
+	ID            int             `json:"id"`
+	ActorID       int             `json:"actor_id,omitempty"`
+	ActorUsername string          `json:"actor_username,omitempty"`
+	Action        string          `json:"action"`
+	EntityType    string          `json:"entity_type"`
+	EntityID      int             `json:"entity_id,omitempty"`
+	Before        json.RawMessage `json:"before,omitempty"`
+	After         json.RawMessage `json:"after,omitempty"`
+	IP            string          `json:"ip"`
+	UserAgent     string          `json:"user_agent"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
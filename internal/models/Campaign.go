@@ -0,0 +1,118 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	ChannelEmail    = "email"
+	ChannelWhatsApp = "whatsapp"
+	ChannelLog      = "log"
+
+	MessageInvitation = "invitation"
+	MessageReminder   = "reminder"
+
+	MessageQueued  = "queued"
+	MessageSending = "sending"
+	MessageSent    = "sent"
+	MessageFailed  = "failed"
+	MessageSkipped = "skipped"
+)
+
+// RecipientFilter selects the alumni a campaign is sent to. Empty fields do not filter.
+type RecipientFilter struct {
+	GraduationYearFrom int      `json:"graduation_year_from,omitempty"`
+	GraduationYearTo   int      `json:"graduation_year_to,omitempty"`
+	Classes            []string `json:"classes,omitempty"`
+	Gender             string   `json:"gender,omitempty"`
+	Location           string   `json:"location,omitempty"`
+}
+
+// Value stores the filter as a JSON document
+func (f RecipientFilter) Value() (driver.Value, error) {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+// Scan reads the filter from a JSON document, NULL leaves it empty
+func (f *RecipientFilter) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*f = RecipientFilter{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	default:
+		return errors.New("unsupported type for recipient filter")
+	}
+}
+
+// Recipient is an alumni with an account who can be invited to answer a form
+type Recipient struct {
+	UserID int    `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Phone  string `json:"phone"`
+	Year   int    `json:"graduation_year"`
+	Class  string `json:"class"`
+}
+
+// Campaign invites a segment of alumni to a form and reminds the ones who have not answered
+type Campaign struct {
+	ID           int             `json:"id"`
+	FormID       int             `json:"form_id"`
+	Name         string          `json:"name"`
+	Channel      string          `json:"channel"`
+	Message      string          `json:"message"`
+	Filter       RecipientFilter `json:"filter"`
+	StartAt      time.Time       `json:"start_at"`
+	ReminderDays []int           `json:"reminder_days,omitempty"`
+	CreatedBy    int             `json:"created_by"`
+	CreatedAt    time.Time       `json:"created_at"`
+	Stats        *CampaignStats  `json:"stats,omitempty"`
+}
+
+// CampaignStats counts the recipients of a campaign by how far they got
+type CampaignStats struct {
+	Recipients int `json:"recipients"`
+	Delivered  int `json:"delivered"`
+	Opened     int `json:"opened"`
+	Submitted  int `json:"submitted"`
+	Failed     int `json:"failed"`
+}
+
+// CampaignRecipient is the delivery status of a campaign for one alumni
+type CampaignRecipient struct {
+	Recipient
+	Token       string     `json:"-"`
+	DeliveredAt *time.Time `json:"delivered_at"`
+	OpenedAt    *time.Time `json:"opened_at"`
+	SubmittedAt *time.Time `json:"submitted_at"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+// CampaignMessage is a queued invitation or reminder, with what is needed to send it
+type CampaignMessage struct {
+	ID          int       `json:"id"`
+	CampaignID  int       `json:"campaign_id"`
+	RecipientID int       `json:"recipient_id"`
+	Kind        string    `json:"kind"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+	Channel     string    `json:"channel"`
+	Message     string    `json:"message"`
+	FormID      int       `json:"form_id"`
+	FormTitle   string    `json:"form_title"`
+	Token       string    `json:"-"`
+	Recipient   Recipient `json:"recipient"`
+	// Submitted reports whether the recipient already answered the form
+	Submitted bool `json:"submitted"`
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
 type Form struct {
 	ID             int         `json:"id"`
@@ -8,10 +11,63 @@ type Form struct {
 	Description    string      `json:"description"`
 	Hidden         string      `json:"hidden"`
 	HasTimeLimit   string      `json:"has_time_limit"`
+	IsTemplate     bool        `json:"is_template"`
+	AllowEdits     bool        `json:"allow_edits"`
 	StartDate      time.Time   `json:"start_date"`
 	EndDate        time.Time   `json:"end_date"`
 	CreatedAt      time.Time   `json:"created_at"`
 	UpdatedAt      time.Time   `json:"updated_at"`
 	Questions      []*Question `json:"questions,omitempty"`
 	QuestionsArray []int       `json:"questions_array,omitempty"`
+	Sections       []*Section  `json:"sections,omitempty"`
+}
+
+// Section is a named page of a form. Questions without a section are shown before
+// the first section.
+type Section struct {
+	ID          int         `json:"id"`
+	FormID      int         `json:"form_id"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Position    int         `json:"position"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	Questions   []*Question `json:"questions"`
+}
+
+// QuestionOrder lists the questions of one section in the order they are shown.
+// SectionID 0 holds the questions without a section.
+type QuestionOrder struct {
+	SectionID   int   `json:"section_id"`
+	QuestionIDs []int `json:"question_ids"`
+}
+
+// IsHidden reports whether the form is hidden from alumni
+func (f *Form) IsHidden() bool {
+	hidden, _ := strconv.ParseBool(f.Hidden)
+	return hidden
+}
+
+// CanEditSubmissions reports whether respondents may still change or withdraw their
+// submission at the given time. Edits are allowed until the form closes.
+func (f *Form) CanEditSubmissions(now time.Time) bool {
+	return f.AllowEdits && f.IsOpen(now)
+}
+
+// IsOpen reports whether the form accepts submissions at the given time. The start and end
+// dates are stored without a time zone and read back as UTC, so now has to be in UTC too.
+func (f *Form) IsOpen(now time.Time) bool {
+	if limited, _ := strconv.ParseBool(f.HasTimeLimit); !limited {
+		return true
+	}
+
+	if !f.StartDate.IsZero() && now.Before(f.StartDate) {
+		return false
+	}
+
+	if !f.EndDate.IsZero() && now.After(f.EndDate) {
+		return false
+	}
+
+	return true
 }
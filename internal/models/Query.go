@@ -0,0 +1,51 @@
+package models
+
+// QueryOptions carries pagination, sorting and filter parameters for list queries
+type QueryOptions struct {
+	Page    int
+	PerPage int
+	Sort    string
+	Filters map[string]string
+}
+
+// Offset returns the number of rows to skip for the requested page
+func (q QueryOptions) Offset() int {
+	if q.Page < 1 {
+		return 0
+	}
+
+	return (q.Page - 1) * q.PerPage
+}
+
+// Filter returns the value of a filter, or an empty string when it is not set
+func (q QueryOptions) Filter(key string) string {
+	if q.Filters == nil {
+		return ""
+	}
+
+	return q.Filters[key]
+}
+
+type Pagination struct {
+	Page       int    `json:"page"`
+	PerPage    int    `json:"per_page"`
+	Total      int    `json:"total"`
+	TotalPages int    `json:"total_pages"`
+	Sort       string `json:"sort,omitempty"`
+}
+
+// NewPagination builds the metadata returned alongside a paginated list
+func NewPagination(opts QueryOptions, total int) Pagination {
+	totalPages := 0
+	if opts.PerPage > 0 {
+		totalPages = (total + opts.PerPage - 1) / opts.PerPage
+	}
+
+	return Pagination{
+		Page:       opts.Page,
+		PerPage:    opts.PerPage,
+		Total:      total,
+		TotalPages: totalPages,
+		Sort:       opts.Sort,
+	}
+}
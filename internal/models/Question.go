@@ -1,20 +1,90 @@
 package models
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	QuestionMultipleChoice = "multiple_choice"
+	QuestionShortAnswer    = "short_answer"
+	QuestionLongAnswer     = "long_answer"
+	QuestionCheckboxes     = "checkboxes"
+	QuestionDropdown       = "dropdown"
+	QuestionLinearScale    = "linear_scale"
+	QuestionDate           = "date"
+	QuestionNumber         = "number"
+	QuestionGrid           = "grid"
+)
 
 type Question struct {
-	ID                int            `json:"id"`
-	FormID            int            `json:"form_id"`
-	Question          string         `json:"question_text"`
-	Type              string         `json:"type"`
-	Extension         bool           `json:"extension"`
-	CreatedAt         time.Time      `json:"created_at"`
-	UpdatedAt         time.Time      `json:"updated_at"`
-	Options           []*Option      `json:"options,omitempty"`
-	OptionsArray      []string       `json:"options_array,omitempty"`
-	Answers           []*Answer      `json:"answers,omitempty"`
-	GroupAnswer       []*GroupAnswer `json:"answers_group,omitempty"`
-	QuestionExtension *Extension     `json:"question_extension,omitempty"`
+	ID                int              `json:"id"`
+	FormID            int              `json:"form_id"`
+	Question          string           `json:"question_text"`
+	Type              string           `json:"type"`
+	Extension         bool             `json:"extension"`
+	Required          bool             `json:"required"`
+	Settings          QuestionSettings `json:"settings"`
+	SectionID         int              `json:"section_id"`
+	Position          int              `json:"position"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+	Options           []*Option        `json:"options,omitempty"`
+	OptionsArray      []string         `json:"options_array,omitempty"`
+	Answers           []*Answer        `json:"answers,omitempty"`
+	GroupAnswer       []*GroupAnswer   `json:"answers_group,omitempty"`
+	QuestionExtension *Extension       `json:"question_extension,omitempty"`
+	Rules             []*Extension     `json:"rules,omitempty"`
+}
+
+// HasOptions reports whether the answers to the question are picked from its options.
+// The options of a grid question are its columns.
+func (q *Question) HasOptions() bool {
+	switch q.Type {
+	case QuestionMultipleChoice, QuestionCheckboxes, QuestionDropdown, QuestionGrid:
+		return true
+	default:
+		return false
+	}
+}
+
+// QuestionSettings holds the type specific configuration of a question
+type QuestionSettings struct {
+	// Min and Max bound linear scales and numbers
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+	// MinLabel and MaxLabel describe both ends of a linear scale
+	MinLabel string `json:"min_label,omitempty"`
+	MaxLabel string `json:"max_label,omitempty"`
+	// Rows are the rows of a grid question
+	Rows []string `json:"rows,omitempty"`
+}
+
+// Value stores the settings as a JSON document
+func (s QuestionSettings) Value() (driver.Value, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+// Scan reads the settings from a JSON document, NULL leaves them empty
+func (s *QuestionSettings) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*s = QuestionSettings{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return errors.New("unsupported type for question settings")
+	}
 }
 
 type Option struct {
@@ -23,9 +93,45 @@ type Option struct {
 	Option     string `json:"option_text"`
 }
 
+const (
+	RuleEquals    = "equals"
+	RuleNotEquals = "not_equals"
+	RuleAnswered  = "answered"
+
+	RuleShow   = "show"
+	RuleSkipTo = "skip_to"
+)
+
+// Extension is a branching rule of a question. When the answer to QuestionID matches the
+// rule, the "show" action reveals FollowUpQuestion and "skip_to" jumps ahead to it. A
+// "skip_to" rule can target FollowUpSection instead, jumping to the start of that section.
 type Extension struct {
 	ID               int    `json:"id"`
 	QuestionID       int    `json:"question_id"`
 	FollowUpQuestion int    `json:"followup_question_id"`
+	FollowUpSection  int    `json:"followup_section_id,omitempty"`
 	FollowUpOption   string `json:"followup_option_value"`
+	Operator         string `json:"operator"`
+	Action           string `json:"action"`
+}
+
+// Matches reports whether the values answered to the question of the rule fire the rule.
+// Questions with several values, such as checkboxes, match when any value does.
+func (e *Extension) Matches(values []string) bool {
+	found := false
+	for _, value := range values {
+		if value == e.FollowUpOption {
+			found = true
+			break
+		}
+	}
+
+	switch e.Operator {
+	case RuleNotEquals:
+		return len(values) > 0 && !found
+	case RuleAnswered:
+		return len(values) > 0
+	default:
+		return found
+	}
 }
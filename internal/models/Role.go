@@ -0,0 +1,28 @@
+package models
+
+const (
+	RoleSuperAdmin    = "super_admin"
+	RoleContentEditor = "content_editor"
+	RoleSurveyManager = "survey_manager"
+	RoleModerator     = "moderator"
+	RoleAlumni        = "alumni"
+)
+
+const (
+	PermDashboardRead  = "dashboard:read"
+	PermAlumniWrite    = "alumni:write"
+	PermArticlesWrite  = "articles:write"
+	PermFormsWrite     = "forms:write"
+	PermAnswersRead    = "answers:read"
+	PermForumsModerate = "forums:moderate"
+	PermJobsModerate   = "jobs:moderate"
+	PermUsersManage    = "users:manage"
+	PermAuditRead      = "audit:read"
+)
+
+type Role struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
@@ -0,0 +1,19 @@
+package models
+
+type SearchHit struct {
+	ID           int     `json:"id"`
+	Title        string  `json:"title"`
+	Snippet      string  `json:"snippet"`
+	Rank         float64 `json:"rank"`
+	Slug         string  `json:"slug,omitempty"`
+	UserUsername string  `json:"user_username,omitempty"`
+}
+
+type SearchResult struct {
+	Query    string       `json:"query"`
+	Alumni   []*SearchHit `json:"alumni"`
+	Profiles []*SearchHit `json:"profiles"`
+	Articles []*SearchHit `json:"articles"`
+	Forums   []*SearchHit `json:"forums"`
+	Jobs     []*SearchHit `json:"jobs"`
+}
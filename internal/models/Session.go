@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type Session struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+type RefreshToken struct {
+	ID        int       `json:"id"`
+	JTI       string    `json:"jti"`
+	SessionID int       `json:"session_id"`
+	UserID    int       `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+const (
+	ScopePasswordReset     = "password_reset"
+	ScopeEmailVerification = "email_verification"
+)
+
+type UserToken struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Hash      string    `json:"-"`
+	Scope     string    `json:"scope"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -13,6 +13,7 @@ type User struct {
 	Email     string    `json:"email"`
 	Password  string    `json:"password"`
 	IsAdmin   bool      `json:"is_admin"`
+	Verified  bool      `json:"email_verified"`
 	CreatedAt time.Time `json:"-"`
 	UpdatedAt time.Time `json:"-"`
 }
@@ -2,9 +2,17 @@ package dbrepo
 
 import (
 	"alumnihub/internal/models"
+	"alumnihub/internal/repository"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/jackc/pgconn"
 )
 
 type PostgresDBRepo struct {
@@ -13,10 +21,34 @@ type PostgresDBRepo struct {
 
 const dbTimeOut = time.Second * 3
 
+// dbLongTimeOut is used by bulk operations such as imports
+const dbLongTimeOut = time.Second * 30
+
 func (m *PostgresDBRepo) Connection() *sql.DB {
 	return m.DB
 }
 
+// sortClause turns a sort key such as "name" or "-graduation_year" into an ORDER BY clause.
+// Only keys listed in columns are accepted, so the result is safe to put into the query.
+func sortClause(sort string, columns map[string]string, fallback string) (string, error) {
+	if sort == "" {
+		return fallback, nil
+	}
+
+	direction := "ASC"
+	if strings.HasPrefix(sort, "-") {
+		direction = "DESC"
+		sort = strings.TrimPrefix(sort, "-")
+	}
+
+	column, ok := columns[sort]
+	if !ok {
+		return "", fmt.Errorf("cannot sort by %s", sort)
+	}
+
+	return fmt.Sprintf("%s %s, %s", column, direction, fallback), nil
+}
+
 func (m *PostgresDBRepo) InsertUser(user models.User) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
@@ -60,7 +92,7 @@ func (m *PostgresDBRepo) GetUserByEmail(email string) (*models.User, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	query := `select id, username, email, password, is_admin, created_at, updated_at 
+	query := `select id, username, email, password, is_admin, email_verified_at is not null, created_at, updated_at 
 			from users where email = $1`
 
 	var user models.User
@@ -72,6 +104,7 @@ func (m *PostgresDBRepo) GetUserByEmail(email string) (*models.User, error) {
 		&user.Email,
 		&user.Password,
 		&user.IsAdmin,
+		&user.Verified,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -88,7 +121,7 @@ func (m *PostgresDBRepo) GetUserByID(id int) (*models.User, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	query := `select id, username, email, password, is_admin, created_at, updated_at 
+	query := `select id, username, email, password, is_admin, email_verified_at is not null, created_at, updated_at 
 			from users where id = $1`
 
 	var user models.User
@@ -100,6 +133,7 @@ func (m *PostgresDBRepo) GetUserByID(id int) (*models.User, error) {
 		&user.Email,
 		&user.Password,
 		&user.IsAdmin,
+		&user.Verified,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -112,6 +146,111 @@ func (m *PostgresDBRepo) GetUserByID(id int) (*models.User, error) {
 
 }
 
+func (m *PostgresDBRepo) InsertUserToken(token models.UserToken) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	// Only the newest token of a scope stays usable
+	stmt := `delete from user_tokens where user_id = $1 and scope = $2 and used_at is null`
+
+	_, err := m.DB.ExecContext(ctx, stmt, token.UserID, token.Scope)
+	if err != nil {
+		return err
+	}
+
+	stmt = `insert into user_tokens (user_id, token_hash, scope, expires_at, created_at)
+			values ($1, $2, $3, $4, $5)`
+
+	_, err = m.DB.ExecContext(ctx, stmt,
+		token.UserID,
+		token.Hash,
+		token.Scope,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// consumeUserToken marks an unused, unexpired token as used and returns its user.
+// It returns sql.ErrNoRows when the token is unknown, expired or already used.
+func consumeUserToken(ctx context.Context, tx *sql.Tx, hash string, scope string) (int, error) {
+	stmt := `update user_tokens set used_at = $1
+			where token_hash = $2 and scope = $3 and used_at is null and expires_at > $1
+			returning user_id`
+
+	var userID int
+	err := tx.QueryRowContext(ctx, stmt, time.Now(), hash, scope).Scan(&userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+func (m *PostgresDBRepo) ResetUserPassword(hash string, password string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	userID, err := consumeUserToken(ctx, tx, hash, models.ScopePasswordReset)
+	if err != nil {
+		return 0, err
+	}
+
+	// The link was delivered to the inbox, so it also proves the email address
+	stmt := `update users set password = $1, email_verified_at = COALESCE(email_verified_at, $2), updated_at = $2 where id = $3`
+
+	_, err = tx.ExecContext(ctx, stmt, password, time.Now(), userID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Whoever held the old password or a stolen refresh token is logged out everywhere
+	stmt = `update sessions set revoked_at = $1 where user_id = $2 and revoked_at is null`
+
+	_, err = tx.ExecContext(ctx, stmt, time.Now(), userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
+
+func (m *PostgresDBRepo) VerifyUserEmail(hash string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	userID, err := consumeUserToken(ctx, tx, hash, models.ScopeEmailVerification)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt := `update users set email_verified_at = COALESCE(email_verified_at, $1), updated_at = $1 where id = $2`
+
+	_, err = tx.ExecContext(ctx, stmt, time.Now(), userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
+
 func (m *PostgresDBRepo) GetUserUsernameByID(id int) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
@@ -177,15 +316,87 @@ func (m *PostgresDBRepo) GetUserPhotoByID(id int) (string, error) {
 	return "", nil
 }
 
-func (m *PostgresDBRepo) AllAlumni() ([]*models.Alumni, error) {
+// alumniSortColumns maps the sort keys accepted by AllAlumni to their columns
+var alumniSortColumns = map[string]string{
+	"id":              "a.id",
+	"nisn":            "a.nisn",
+	"nis":             "a.nis",
+	"name":            "a.name",
+	"gender":          "a.gender",
+	"graduation_year": "a.graduation_year",
+	"class":           "a.class",
+}
+
+func (m *PostgresDBRepo) AllAlumni(opts models.QueryOptions) ([]*models.Alumni, int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	query := `select id, nisn, nis, name, gender, phone, graduation_year, class from alumni order by id`
+	var conditions []string
+	var args []interface{}
 
-	rows, err := m.DB.QueryContext(ctx, query)
+	if year := opts.Filter("graduation_year"); year != "" {
+		n, err := strconv.Atoi(year)
+		if err != nil {
+			return nil, 0, errors.New("graduation_year must be a number")
+		}
+		args = append(args, n)
+		conditions = append(conditions, fmt.Sprintf("a.graduation_year = $%d", len(args)))
+	}
+
+	if class := opts.Filter("class"); class != "" {
+		args = append(args, class)
+		conditions = append(conditions, fmt.Sprintf("a.class = $%d", len(args)))
+	}
+
+	if gender := opts.Filter("gender"); gender != "" {
+		args = append(args, strings.ToUpper(gender))
+		conditions = append(conditions, fmt.Sprintf("a.gender = $%d", len(args)))
+	}
+
+	if hasAccount := opts.Filter("has_account"); hasAccount != "" {
+		b, err := strconv.ParseBool(hasAccount)
+		if err != nil {
+			return nil, 0, errors.New("has_account must be true or false")
+		}
+		if b {
+			conditions = append(conditions, "ap.id IS NOT NULL")
+		} else {
+			conditions = append(conditions, "ap.id IS NULL")
+		}
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	orderBy, err := sortClause(opts.Sort, alumniSortColumns, "a.id")
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	countQuery := `SELECT COUNT(a.id) FROM alumni a
+				LEFT JOIN alumni_profile ap ON ap.alumni_id = a.id ` + where
+
+	var total int
+	err = m.DB.QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`SELECT a.id, a.nisn, a.nis, a.name, a.gender, a.phone, a.graduation_year, a.class, COALESCE(u.username, '')
+				FROM alumni a
+				LEFT JOIN alumni_profile ap ON ap.alumni_id = a.id
+				LEFT JOIN users u ON u.id = ap.user_id
+				%s
+				ORDER BY %s
+				LIMIT $%d OFFSET $%d`, where, orderBy, len(args)+1, len(args)+2)
+
+	args = append(args, opts.PerPage, opts.Offset())
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
 	}
 	defer rows.Close()
 
@@ -202,15 +413,16 @@ func (m *PostgresDBRepo) AllAlumni() ([]*models.Alumni, error) {
 			&alumni.Phone,
 			&alumni.Year,
 			&alumni.Class,
+			&alumni.UserUsername,
 		)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 
 		alumnis = append(alumnis, &alumni)
 	}
 
-	return alumnis, nil
+	return alumnis, total, nil
 }
 
 func (m *PostgresDBRepo) Alumni(id int) (*models.Alumni, error) {
@@ -245,14 +457,16 @@ func (m *PostgresDBRepo) Alumni(id int) (*models.Alumni, error) {
 	return &alumni, nil
 }
 
-func (m *PostgresDBRepo) InsertAlumni(alumni models.Alumni) error {
+func (m *PostgresDBRepo) InsertAlumni(alumni models.Alumni) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
 	stmt := `insert into alumni (nisn, nis, name, gender, phone, graduation_year, class)
-			values ($1, $2, $3, $4, $5, $6, $7)`
+			values ($1, $2, $3, $4, $5, $6, $7) returning id`
 
-	_, err := m.DB.ExecContext(ctx, stmt,
+	var newID int
+
+	err := m.DB.QueryRowContext(ctx, stmt,
 		alumni.NISN,
 		alumni.NIS,
 		alumni.Name,
@@ -260,13 +474,13 @@ func (m *PostgresDBRepo) InsertAlumni(alumni models.Alumni) error {
 		alumni.Phone,
 		alumni.Year,
 		alumni.Class,
-	)
+	).Scan(&newID)
 
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return newID, nil
 }
 
 func (m *PostgresDBRepo) UpdateAlumni(alumni models.Alumni) error {
@@ -337,6 +551,126 @@ func (m *PostgresDBRepo) GetAlumniByNISN(nisn string) (*models.Alumni, error) {
 
 }
 
+func (m *PostgresDBRepo) GetExistingNISNs(nisns []string) (map[string]bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	existing := make(map[string]bool)
+
+	if len(nisns) == 0 {
+		return existing, nil
+	}
+
+	query := `select nisn from alumni where nisn = ANY($1)`
+
+	rows, err := m.DB.QueryContext(ctx, query, nisns)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var nisn string
+		err := rows.Scan(&nisn)
+		if err != nil {
+			return nil, err
+		}
+
+		existing[nisn] = true
+	}
+
+	return existing, rows.Err()
+}
+
+// GetExistingNIS returns the NISN of the stored alumni using each of the given NIS
+func (m *PostgresDBRepo) GetExistingNIS(nis []string) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	existing := make(map[string]string)
+
+	if len(nis) == 0 {
+		return existing, nil
+	}
+
+	query := `select nis, nisn from alumni where nis = ANY($1)`
+
+	rows, err := m.DB.QueryContext(ctx, query, nis)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var n, nisn string
+		err := rows.Scan(&n, &nisn)
+		if err != nil {
+			return nil, err
+		}
+
+		existing[n] = nisn
+	}
+
+	return existing, rows.Err()
+}
+
+// ImportAlumni stores every alumni inside one transaction. When upsert is true, rows with a NISN
+// that already exists are updated instead of failing the whole import.
+func (m *PostgresDBRepo) ImportAlumni(alumniList []models.Alumni, upsert bool) (int, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbLongTimeOut)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	stmt := `insert into alumni (nisn, nis, name, gender, phone, graduation_year, class)
+			values ($1, $2, $3, $4, $5, $6, $7)
+			returning true`
+
+	if upsert {
+		stmt = `insert into alumni (nisn, nis, name, gender, phone, graduation_year, class)
+			values ($1, $2, $3, $4, $5, $6, $7)
+			on conflict (nisn) do update set nis = excluded.nis, name = excluded.name, gender = excluded.gender,
+				phone = excluded.phone, graduation_year = excluded.graduation_year, class = excluded.class
+			returning (xmax = 0)`
+	}
+
+	inserted, updated := 0, 0
+
+	for i, alumni := range alumniList {
+		var isInsert bool
+		err := tx.QueryRowContext(ctx, stmt,
+			alumni.NISN,
+			alumni.NIS,
+			alumni.Name,
+			alumni.Gender,
+			alumni.Phone,
+			alumni.Year,
+			alumni.Class,
+		).Scan(&isInsert)
+
+		if err != nil {
+			return 0, 0, fmt.Errorf("alumni %d (nisn %s): %w", i+1, alumni.NISN, err)
+		}
+
+		if isInsert {
+			inserted++
+		} else {
+			updated++
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return inserted, updated, nil
+}
+
 func (m *PostgresDBRepo) GetAlumniNameByID(id int) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
@@ -648,13 +982,23 @@ func (m *PostgresDBRepo) GetAdminProfileByUserID(id int) (*models.Profile, error
 	return &profile, nil
 }
 
-func (m *PostgresDBRepo) AllArticles() ([]*models.Article, error) {
+// AllArticles returns every article, or only the ones alumni can read when publishedOnly is set,
+// optionally narrowed down to the slug of a category or a tag
+func (m *PostgresDBRepo) AllArticles(publishedOnly bool, category, tag string) ([]*models.Article, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	query := `select id, title, slug, body, image, status, created_at, updated_at, published_at from articles order by id`
+	where, args := articleFilters(publishedOnly, category, tag)
 
-	rows, err := m.DB.QueryContext(ctx, query)
+	order := "a.id"
+	if publishedOnly {
+		order = "a.published_at desc, a.id desc"
+	}
+
+	query := fmt.Sprintf(`select a.id, a.title, a.slug, a.body, a.image, a.status, a.created_at, a.updated_at, a.published_at
+				from articles a %s order by %s`, where, order)
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -682,6 +1026,15 @@ func (m *PostgresDBRepo) AllArticles() ([]*models.Article, error) {
 		articles = append(articles, &article)
 	}
 
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = m.attachArticleTaxonomy(ctx, articles)
+	if err != nil {
+		return nil, err
+	}
+
 	return articles, nil
 }
 
@@ -715,10 +1068,16 @@ func (m *PostgresDBRepo) Article(id int) (*models.Article, error) {
 		return nil, err
 	}
 
+	err = m.attachArticleTaxonomy(ctx, []*models.Article{&article})
+	if err != nil {
+		return nil, err
+	}
+
 	return &article, nil
 }
 
-func (m *PostgresDBRepo) ArticleBySlug(slug string) (*models.Article, error) {
+// ArticleBySlug returns an article, drafts and future articles are not found when publishedOnly is set
+func (m *PostgresDBRepo) ArticleBySlug(slug string, publishedOnly bool) (*models.Article, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
@@ -726,9 +1085,10 @@ func (m *PostgresDBRepo) ArticleBySlug(slug string) (*models.Article, error) {
 				SELECT id, title, slug, body, image, status, created_at, updated_at, published_at
 				FROM articles
 				WHERE slug = $1
+					AND (NOT $2 OR (status IN ('published', 'scheduled') AND published_at <= $3))
 			`
 
-	row := m.DB.QueryRowContext(ctx, query, slug)
+	row := m.DB.QueryRowContext(ctx, query, slug, publishedOnly, time.Now().UTC())
 
 	var article models.Article
 
@@ -748,19 +1108,30 @@ func (m *PostgresDBRepo) ArticleBySlug(slug string) (*models.Article, error) {
 		return nil, err
 	}
 
+	err = m.attachArticleTaxonomy(ctx, []*models.Article{&article})
+	if err != nil {
+		return nil, err
+	}
+
 	return &article, nil
 }
 
-func (m *PostgresDBRepo) InsertArticle(article models.Article) (int, error) {
+// InsertArticle saves a new article together with its categories, tags and first revision.
+// It returns repository.ErrSlugTaken when another article took its slug in the meantime.
+func (m *PostgresDBRepo) InsertArticle(article models.Article, authorID int) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
 	stmt := `insert into articles (title, slug, body, image, status, created_at, updated_at, published_at)
 			values ($1, $2, $3, $4, $5, $6, $7, $8) returning id`
 
-	var newID int
-
-	err := m.DB.QueryRowContext(ctx, stmt,
+	err = tx.QueryRowContext(ctx, stmt,
 		article.Title,
 		article.Slug,
 		article.Body,
@@ -769,256 +1140,305 @@ func (m *PostgresDBRepo) InsertArticle(article models.Article) (int, error) {
 		article.CreatedAt,
 		article.UpdatedAt,
 		article.PublishedAt,
-	).Scan(&newID)
+	).Scan(&article.ID)
 
 	if err != nil {
+		if isUniqueViolation(err, "articles_slug_key") {
+			return 0, repository.ErrSlugTaken
+		}
 		return 0, err
 	}
 
-	return newID, nil
-}
-
-func (m *PostgresDBRepo) UpdateArticle(article models.Article) error {
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
-	defer cancel()
-
-	stmt := `update articles set title = $1, slug = $2, body = $3,
-				status = $4, updated_at = $5, published_at = $6, image = $7 
-				where id = $8`
+	err = setArticleTaxonomy(ctx, tx, article)
+	if err != nil {
+		return 0, err
+	}
 
-	_, err := m.DB.ExecContext(ctx, stmt,
-		article.Title,
-		article.Slug,
-		article.Body,
-		article.Status,
-		article.UpdatedAt,
-		article.PublishedAt,
-		article.Image,
-		article.ID,
-	)
+	err = insertArticleRevision(ctx, tx, article, authorID)
+	if err != nil {
+		return 0, err
+	}
 
+	err = tx.Commit()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return article.ID, nil
 }
 
-func (m *PostgresDBRepo) DeleteArticle(id int) error {
+// UpdateArticle saves an article with its categories and tags and snapshots it as a new
+// revision by authorID. When its slug changes the old one is kept so links to it can be
+// redirected. It returns repository.ErrSlugTaken when another article took the new slug.
+func (m *PostgresDBRepo) UpdateArticle(article models.Article, authorID int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `delete from articles where id = $1`
-
-	_, err := m.DB.ExecContext(ctx, stmt, id)
+	tx, err := m.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	return nil
-}
+	var oldSlug string
 
-func (m *PostgresDBRepo) AllForms() ([]*models.Form, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
-	defer cancel()
+	query := `select COALESCE(slug, '') from articles where id = $1 for update`
+
+	err = tx.QueryRowContext(ctx, query, article.ID).Scan(&oldSlug)
+	if err != nil {
+		return err
+	}
 
-	query := `select id, title, description, hidden, has_time_limit, start_date, end_date, created_at, updated_at 
-				from forms order by id`
+	// Articles written before revisions were kept get their current state as revision 1,
+	// so the edit can still be undone
+	stmt := `insert into article_revisions (article_id, revision, title, slug, body, status, created_at)
+			select id, 1, title, slug, body, status, COALESCE(updated_at, created_at) from articles
+			where id = $1 and not exists (select 1 from article_revisions where article_id = $1)`
 
-	rows, err := m.DB.QueryContext(ctx, query)
+	_, err = tx.ExecContext(ctx, stmt, article.ID)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
 
-	var forms []*models.Form
+	if oldSlug != "" && oldSlug != article.Slug {
+		stmt = `insert into article_slugs (article_id, slug, created_at) values ($1, $2, $3)
+				on conflict (slug) do update set article_id = excluded.article_id, created_at = excluded.created_at`
 
-	for rows.Next() {
-		var form models.Form
-		err := rows.Scan(
-			&form.ID,
-			&form.Title,
-			&form.Description,
-			&form.Hidden,
-			&form.HasTimeLimit,
-			&form.StartDate,
-			&form.EndDate,
-			&form.CreatedAt,
-			&form.UpdatedAt,
-		)
+		_, err = tx.ExecContext(ctx, stmt, article.ID, oldSlug, time.Now())
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		forms = append(forms, &form)
+		// Going back to an earlier slug makes it current again
+		stmt = `delete from article_slugs where slug = $1`
+
+		_, err = tx.ExecContext(ctx, stmt, article.Slug)
+		if err != nil {
+			return err
+		}
 	}
 
-	return forms, nil
-}
+	stmt = `update articles set title = $1, slug = $2, body = $3,
+				status = $4, updated_at = $5, published_at = $6, image = $7
+				where id = $8`
 
-func (m *PostgresDBRepo) Form(id int) (*models.Form, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
-	defer cancel()
+	_, err = tx.ExecContext(ctx, stmt,
+		article.Title,
+		article.Slug,
+		article.Body,
+		article.Status,
+		article.UpdatedAt,
+		article.PublishedAt,
+		article.Image,
+		article.ID,
+	)
 
-	query := `
-				SELECT id, title, description, hidden, has_time_limit, start_date, end_date, created_at, updated_at
-				FROM forms
-				WHERE id = $1
-			`
+	if err != nil {
+		if isUniqueViolation(err, "articles_slug_key") {
+			return repository.ErrSlugTaken
+		}
+		return err
+	}
 
-	row := m.DB.QueryRowContext(ctx, query, id)
+	err = setArticleTaxonomy(ctx, tx, article)
+	if err != nil {
+		return err
+	}
 
-	var form models.Form
+	err = insertArticleRevision(ctx, tx, article, authorID)
+	if err != nil {
+		return err
+	}
 
-	err := row.Scan(
-		&form.ID,
-		&form.Title,
-		&form.Description,
-		&form.Hidden,
-		&form.HasTimeLimit,
-		&form.StartDate,
-		&form.EndDate,
-		&form.CreatedAt,
-		&form.UpdatedAt,
+	return tx.Commit()
+}
+
+// insertArticleRevision snapshots an article as its next revision
+func insertArticleRevision(ctx context.Context, tx *sql.Tx, article models.Article, authorID int) error {
+	stmt := `insert into article_revisions (article_id, revision, title, slug, body, status, author_id, created_at)
+			values ($1,
+				(select COALESCE(max(revision), 0) + 1 from article_revisions where article_id = $1),
+				$2, $3, $4, $5, $6, $7)`
+
+	_, err := tx.ExecContext(ctx, stmt,
+		article.ID,
+		article.Title,
+		article.Slug,
+		article.Body,
+		article.Status,
+		nullInt(authorID),
+		time.Now(),
 	)
 
-	if err != nil {
-		return nil, err
-	}
+	return err
+}
 
-	query = `
-				SELECT id, question_text, type, extension, created_at, updated_at
-				FROM questions
-				WHERE form_id = $1
-				ORDER BY id
+// ArticleRevisions returns the revisions of an article without their bodies, newest first
+func (m *PostgresDBRepo) ArticleRevisions(articleID int) ([]*models.ArticleRevision, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `
+				SELECT r.id, r.article_id, r.revision, COALESCE(r.title, ''), COALESCE(r.slug, ''), r.status,
+					COALESCE(r.author_id, 0), COALESCE(u.username, ''), r.created_at
+				FROM article_revisions r
+				LEFT JOIN users u ON u.id = r.author_id
+				WHERE r.article_id = $1
+				ORDER BY r.revision DESC
 			`
 
-	rows, err := m.DB.QueryContext(ctx, query, id)
-	if err != nil && err != sql.ErrNoRows {
+	rows, err := m.DB.QueryContext(ctx, query, articleID)
+	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var questions []*models.Question
+	var revisions []*models.ArticleRevision
+
 	for rows.Next() {
-		var question models.Question
+		var revision models.ArticleRevision
 		err := rows.Scan(
-			&question.ID,
-			&question.Question,
-			&question.Type,
-			&question.Extension,
-			&question.CreatedAt,
-			&question.UpdatedAt,
+			&revision.ID,
+			&revision.ArticleID,
+			&revision.Revision,
+			&revision.Title,
+			&revision.Slug,
+			&revision.Status,
+			&revision.AuthorID,
+			&revision.AuthorUsername,
+			&revision.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
 
-		questions = append(questions, &question)
+		revisions = append(revisions, &revision)
 	}
 
-	form.Questions = questions
-
-	return &form, nil
+	return revisions, rows.Err()
 }
 
-func (m *PostgresDBRepo) ShowForm(id int) (*models.Form, error) {
+// ArticleRevision returns one revision of an article with its body
+func (m *PostgresDBRepo) ArticleRevision(articleID int, revision int) (*models.ArticleRevision, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
 	query := `
-				SELECT id, title, description, hidden, has_time_limit, start_date, end_date, created_at, updated_at
-				FROM forms
-				WHERE id = $1
+				SELECT r.id, r.article_id, r.revision, COALESCE(r.title, ''), COALESCE(r.slug, ''), COALESCE(r.body, ''),
+					r.status, COALESCE(r.author_id, 0), COALESCE(u.username, ''), r.created_at
+				FROM article_revisions r
+				LEFT JOIN users u ON u.id = r.author_id
+				WHERE r.article_id = $1 AND r.revision = $2
 			`
 
-	row := m.DB.QueryRowContext(ctx, query, id)
-
-	var form models.Form
-
-	err := row.Scan(
-		&form.ID,
-		&form.Title,
-		&form.Description,
-		&form.Hidden,
-		&form.HasTimeLimit,
-		&form.StartDate,
-		&form.EndDate,
-		&form.CreatedAt,
-		&form.UpdatedAt,
+	var rev models.ArticleRevision
+
+	err := m.DB.QueryRowContext(ctx, query, articleID, revision).Scan(
+		&rev.ID,
+		&rev.ArticleID,
+		&rev.Revision,
+		&rev.Title,
+		&rev.Slug,
+		&rev.Body,
+		&rev.Status,
+		&rev.AuthorID,
+		&rev.AuthorUsername,
+		&rev.CreatedAt,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
-	fullQuestion, err := m.QuestionsByForm(form.ID)
+	return &rev, nil
+}
+
+// UniqueArticleSlug returns base, or base with the first free -2, -3... suffix, so that no
+// other article uses it now or used it before
+func (m *PostgresDBRepo) UniqueArticleSlug(base string, excludeID int) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `select slug from articles where (slug = $1 or slug like $1 || '-%') and id <> $2
+			union
+			select slug from article_slugs where (slug = $1 or slug like $1 || '-%') and article_id <> $2`
+
+	rows, err := m.DB.QueryContext(ctx, query, base, excludeID)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
+	defer rows.Close()
 
-	form.Questions = fullQuestion
+	taken := make(map[string]bool)
+	for rows.Next() {
+		var slug string
+		err := rows.Scan(&slug)
+		if err != nil {
+			return "", err
+		}
+		taken[slug] = true
+	}
 
-	return &form, nil
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+
+	slug := base
+	for n := 2; taken[slug]; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	return slug, nil
 }
 
-func (m *PostgresDBRepo) InsertForm(form models.Form) (int, error) {
+// ArticleSlugRedirect returns the current slug of the article that used to have slug. Like
+// ArticleBySlug, drafts and future articles are not found when publishedOnly is set.
+func (m *PostgresDBRepo) ArticleSlugRedirect(slug string, publishedOnly bool) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `insert into forms (title, description, has_time_limit, start_date,
-			end_date, created_at, updated_at)
-			values ($1, $2, $3, $4, $5, $6, $7) returning id`
-
-	var newID int
+	query := `select a.slug from article_slugs s
+			join articles a on a.id = s.article_id
+			where s.slug = $1
+				and (not $2 or (a.status in ('published', 'scheduled') and a.published_at <= $3))`
 
-	err := m.DB.QueryRowContext(ctx, stmt,
-		form.Title,
-		form.Description,
-		form.HasTimeLimit,
-		form.StartDate,
-		form.EndDate,
-		form.CreatedAt,
-		form.UpdatedAt,
-	).Scan(&newID)
+	var current string
 
+	err := m.DB.QueryRowContext(ctx, query, slug, publishedOnly, time.Now().UTC()).Scan(&current)
 	if err != nil {
-		return 0, err
+		return "", err
 	}
 
-	return newID, nil
+	return current, nil
 }
 
-func (m *PostgresDBRepo) UpdateForm(form models.Form) error {
+// PublishScheduledArticles publishes the scheduled articles whose time has come, now is in
+// UTC like the publish times
+func (m *PostgresDBRepo) PublishScheduledArticles(now time.Time) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `update forms set title = $1, description = $2, has_time_limit = $3, start_date = $4, end_date = $5, hidden = $6, updated_at = $7
-				where id = $8`
+	stmt := `update articles set status = 'published', updated_at = $2
+				where status = 'scheduled' and published_at <= $1`
 
-	_, err := m.DB.ExecContext(ctx, stmt,
-		form.Title,
-		form.Description,
-		form.HasTimeLimit,
-		form.StartDate,
-		form.EndDate,
-		form.Hidden,
-		form.UpdatedAt,
-		form.ID,
-	)
+	result, err := m.DB.ExecContext(ctx, stmt, now, time.Now())
+	if err != nil {
+		return 0, err
+	}
 
+	published, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return int(published), nil
 }
 
-func (m *PostgresDBRepo) DeleteForm(id int) error {
+func (m *PostgresDBRepo) DeleteArticle(id int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `delete from forms where id = $1`
+	stmt := `delete from articles where id = $1`
 
 	_, err := m.DB.ExecContext(ctx, stmt, id)
 	if err != nil {
@@ -1028,265 +1448,317 @@ func (m *PostgresDBRepo) DeleteForm(id int) error {
 	return nil
 }
 
-func (m *PostgresDBRepo) ShowFormAnswers(id int) (*models.Form, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
-	defer cancel()
+// articleFilters builds the where clause shared by the article lists. Alumni only see
+// published articles, and category and tag narrow the list down to a category or tag slug.
+func articleFilters(publishedOnly bool, category, tag string) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
 
-	query := `
-				SELECT id, title, description, hidden, has_time_limit, start_date, end_date, created_at, updated_at
-				FROM forms
-				WHERE id = $1
-			`
+	if publishedOnly {
+		args = append(args, time.Now().UTC())
+		conditions = append(conditions, fmt.Sprintf("a.status in ('published', 'scheduled') and a.published_at <= $%d", len(args)))
+	}
 
-	row := m.DB.QueryRowContext(ctx, query, id)
+	if category != "" {
+		args = append(args, category)
+		conditions = append(conditions, fmt.Sprintf(`exists (select 1 from article_categories ac
+					join categories c on c.id = ac.category_id
+					where ac.article_id = a.id and c.slug = $%d)`, len(args)))
+	}
 
-	var form models.Form
+	if tag != "" {
+		args = append(args, tag)
+		conditions = append(conditions, fmt.Sprintf(`exists (select 1 from article_tags atg
+					join tags t on t.id = atg.tag_id
+					where atg.article_id = a.id and t.slug = $%d)`, len(args)))
+	}
 
-	err := row.Scan(
-		&form.ID,
-		&form.Title,
-		&form.Description,
-		&form.Hidden,
-		&form.HasTimeLimit,
-		&form.StartDate,
-		&form.EndDate,
-		&form.CreatedAt,
-		&form.UpdatedAt,
-	)
+	if len(conditions) == 0 {
+		return "", nil
+	}
 
-	if err != nil {
-		return nil, err
+	return "where " + strings.Join(conditions, " and "), args
+}
+
+// attachArticleTaxonomy loads the categories and tags of the articles
+func (m *PostgresDBRepo) attachArticleTaxonomy(ctx context.Context, articles []*models.Article) error {
+	if len(articles) == 0 {
+		return nil
 	}
 
-	query = `
-				SELECT id, question_text, type, extension, created_at, updated_at
-				FROM questions
-				WHERE form_id = $1
-			`
+	byID := make(map[int]*models.Article, len(articles))
+	ids := make([]int, 0, len(articles))
+	for _, article := range articles {
+		byID[article.ID] = article
+		ids = append(ids, article.ID)
+	}
 
-	rows, err := m.DB.QueryContext(ctx, query, id)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, err
+	query := `select ac.article_id, c.id, c.name, c.slug, COALESCE(c.description, ''), c.created_at, c.updated_at from article_categories ac
+			join categories c on c.id = ac.category_id
+			where ac.article_id = ANY($1)
+			order by c.name, c.id`
+
+	rows, err := m.DB.QueryContext(ctx, query, ids)
+	if err != nil {
+		return err
 	}
 	defer rows.Close()
 
-	var questions []*models.Question
 	for rows.Next() {
-		var question models.Question
-		err := rows.Scan(
-			&question.ID,
-			&question.Question,
-			&question.Type,
-			&question.Extension,
-			&question.CreatedAt,
-			&question.UpdatedAt,
-		)
+		var articleID int
+		var category models.Category
+		err := rows.Scan(&articleID, &category.ID, &category.Name, &category.Slug, &category.Description, &category.CreatedAt, &category.UpdatedAt)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		// Get answers for each question
-		answerGroups, err := m.GroupAnswersByQuestion(form.ID, question.ID)
-		if err != nil && err != sql.ErrNoRows {
-			return nil, err
-		}
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
+		byID[articleID].Categories = append(byID[articleID].Categories, &category)
+	}
 
-		question.GroupAnswer = answerGroups
+	if err = rows.Err(); err != nil {
+		return err
+	}
 
-		questions = append(questions, &question)
+	query = `select atg.article_id, t.id, t.name, t.slug, t.created_at from article_tags atg
+			join tags t on t.id = atg.tag_id
+			where atg.article_id = ANY($1)
+			order by t.name, t.id`
+
+	tagRows, err := m.DB.QueryContext(ctx, query, ids)
+	if err != nil {
+		return err
 	}
+	defer tagRows.Close()
 
-	form.Questions = questions
+	for tagRows.Next() {
+		var articleID int
+		var tag models.Tag
+		err := tagRows.Scan(&articleID, &tag.ID, &tag.Name, &tag.Slug, &tag.CreatedAt)
+		if err != nil {
+			return err
+		}
 
-	return &form, nil
+		byID[articleID].Tags = append(byID[articleID].Tags, &tag)
+	}
+
+	return tagRows.Err()
 }
 
-func (m *PostgresDBRepo) Question(id int) (*models.Question, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
-	defer cancel()
+// setArticleTaxonomy replaces the categories of an article when its CategoryIDs are set and
+// its tags with Tags when its TagNames are set. Leaving either out keeps the current ones.
+func setArticleTaxonomy(ctx context.Context, tx *sql.Tx, article models.Article) error {
+	if article.CategoryIDs != nil {
+		err := setArticleCategories(ctx, tx, article.ID, article.CategoryIDs)
+		if err != nil {
+			return err
+		}
+	}
 
-	query := `
-				SELECT id, form_id, question_text, type, extension, created_at, updated_at
-				FROM questions
-				WHERE id = $1
-			`
-
-	row := m.DB.QueryRowContext(ctx, query, id)
+	if article.TagNames != nil {
+		err := setArticleTags(ctx, tx, article.ID, article.Tags)
+		if err != nil {
+			return err
+		}
+	}
 
-	var question models.Question
+	return nil
+}
 
-	err := row.Scan(
-		&question.ID,
-		&question.FormID,
-		&question.Question,
-		&question.Type,
-		&question.Extension,
-		&question.CreatedAt,
-		&question.UpdatedAt,
-	)
+// setArticleCategories replaces the categories of an article
+func setArticleCategories(ctx context.Context, tx *sql.Tx, articleID int, categoryIDs []int) error {
+	stmt := `delete from article_categories where article_id = $1`
 
+	_, err := tx.ExecContext(ctx, stmt, articleID)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	query = `
-				SELECT id, question_id, option_text
-				FROM options
-				WHERE question_id = $1
-			`
+	stmt = `insert into article_categories (article_id, category_id)
+			select $1::integer, id from categories where id = ANY($2)`
 
-	rows, err := m.DB.QueryContext(ctx, query, id)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, err
+	_, err = tx.ExecContext(ctx, stmt, articleID, categoryIDs)
+
+	return err
+}
+
+// setArticleTags replaces the tags of an article. Tags are matched by slug and the ones
+// that do not exist yet are created.
+func setArticleTags(ctx context.Context, tx *sql.Tx, articleID int, tags []*models.Tag) error {
+	stmt := `delete from article_tags where article_id = $1`
+
+	_, err := tx.ExecContext(ctx, stmt, articleID)
+	if err != nil {
+		return err
 	}
-	defer rows.Close()
 
-	var options []*models.Option
-	for rows.Next() {
-		var option models.Option
-		err := rows.Scan(
-			&option.ID,
-			&option.QuestionID,
-			&option.Option,
-		)
+	for _, tag := range tags {
+		// The no-op update makes returning give the id of an existing tag as well
+		stmt = `insert into tags (name, slug, created_at) values ($1, $2, $3)
+				on conflict (slug) do update set slug = excluded.slug
+				returning id`
+
+		err = tx.QueryRowContext(ctx, stmt, tag.Name, tag.Slug, time.Now()).Scan(&tag.ID)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		options = append(options, &option)
+		stmt = `insert into article_tags (article_id, tag_id) values ($1, $2) on conflict do nothing`
+
+		_, err = tx.ExecContext(ctx, stmt, articleID, tag.ID)
+		if err != nil {
+			return err
+		}
 	}
 
-	question.Options = options
+	return nil
+}
 
-	// Get answers for each question
-	answerQuery := `
-		SELECT id, user_id, form_id, question_id, answer_text
-		FROM answers
-		WHERE question_id = $1
-	`
+// RelatedArticles returns published articles sharing tags with an article, the ones sharing
+// the most tags first
+func (m *PostgresDBRepo) RelatedArticles(articleID int, limit int) ([]*models.Article, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
 
-	answerRows, err := m.DB.QueryContext(ctx, answerQuery, question.ID)
-	if err != nil && err != sql.ErrNoRows {
+	query := `select a.id, a.title, a.slug, a.image, a.status, a.created_at, a.updated_at, a.published_at
+			from articles a
+			join article_tags atg on atg.article_id = a.id
+			join article_tags mine on mine.tag_id = atg.tag_id and mine.article_id = $1
+			where a.id <> $1 and a.status in ('published', 'scheduled') and a.published_at <= $2
+			group by a.id
+			order by count(*) desc, a.published_at desc, a.id desc
+			limit $3`
+
+	rows, err := m.DB.QueryContext(ctx, query, articleID, time.Now().UTC(), limit)
+	if err != nil {
 		return nil, err
 	}
-	defer answerRows.Close()
+	defer rows.Close()
 
-	var answers []*models.Answer
-	for answerRows.Next() {
-		var answer models.Answer
-		err := answerRows.Scan(
-			&answer.ID,
-			&answer.UserID,
-			&answer.FormID,
-			&answer.QuestionID,
-			&answer.Answer,
+	var articles []*models.Article
+
+	for rows.Next() {
+		var article models.Article
+		err := rows.Scan(
+			&article.ID,
+			&article.Title,
+			&article.Slug,
+			&article.Image,
+			&article.Status,
+			&article.CreatedAt,
+			&article.UpdatedAt,
+			&article.PublishedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
-		answers = append(answers, &answer)
-	}
 
-	question.Answers = answers
+		articles = append(articles, &article)
+	}
 
-	return &question, nil
+	return articles, rows.Err()
 }
 
-func (m *PostgresDBRepo) QuestionsByForm(id int) ([]*models.Question, error) {
+// AllCategories returns the categories with the number of articles in each
+func (m *PostgresDBRepo) AllCategories() ([]*models.Category, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	query := `
-				SELECT id, form_id, question_text, type, extension, created_at, updated_at
-				FROM questions
-				WHERE form_id = $1
-				ORDER BY id
-			`
+	query := `select c.id, c.name, c.slug, COALESCE(c.description, ''), count(ac.article_id), c.created_at, c.updated_at
+			from categories c
+			left join article_categories ac on ac.category_id = c.id
+			group by c.id
+			order by c.name, c.id`
 
-	rows, err := m.DB.QueryContext(ctx, query, id)
+	rows, err := m.DB.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var questions []*models.Question
+	var categories []*models.Category
 
 	for rows.Next() {
-		var question models.Question
+		var category models.Category
 		err := rows.Scan(
-			&question.ID,
-			&question.FormID,
-			&question.Question,
-			&question.Type,
-			&question.Extension,
-			&question.CreatedAt,
-			&question.UpdatedAt,
+			&category.ID,
+			&category.Name,
+			&category.Slug,
+			&category.Description,
+			&category.Articles,
+			&category.CreatedAt,
+			&category.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
 
-		query = `
-					SELECT id, question_id, option_text
-					FROM options
-					WHERE question_id = $1
-				`
+		categories = append(categories, &category)
+	}
 
-		questionRows, err := m.DB.QueryContext(ctx, query, question.ID)
-		if err != nil && err != sql.ErrNoRows {
-			return nil, err
-		}
-		defer questionRows.Close()
+	return categories, rows.Err()
+}
 
-		var options []*models.Option
-		for questionRows.Next() {
-			var option models.Option
-			err := questionRows.Scan(
-				&option.ID,
-				&option.QuestionID,
-				&option.Option,
-			)
-			if err != nil {
-				return nil, err
-			}
+func (m *PostgresDBRepo) Category(id int) (*models.Category, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
 
-			options = append(options, &option)
-		}
+	query := `select c.id, c.name, c.slug, COALESCE(c.description, ''), count(ac.article_id), c.created_at, c.updated_at
+			from categories c
+			left join article_categories ac on ac.category_id = c.id
+			where c.id = $1
+			group by c.id`
+
+	var category models.Category
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&category.ID,
+		&category.Name,
+		&category.Slug,
+		&category.Description,
+		&category.Articles,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+	)
 
-		question.Options = options
+	if err != nil {
+		return nil, err
+	}
 
-		ext, err := m.GetQuestionExtension(question.ID)
-		if err != nil {
-			return nil, err
-		}
+	return &category, nil
+}
+
+// CategorySlugTaken reports whether another category than excludeID uses slug
+func (m *PostgresDBRepo) CategorySlugTaken(slug string, excludeID int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
 
-		question.QuestionExtension = ext
+	query := `select exists (select 1 from categories where slug = $1 and id <> $2)`
 
-		questions = append(questions, &question)
+	var taken bool
+
+	err := m.DB.QueryRowContext(ctx, query, slug, excludeID).Scan(&taken)
+	if err != nil {
+		return false, err
 	}
 
-	return questions, nil
+	return taken, nil
 }
 
-func (m *PostgresDBRepo) InsertQuestion(question models.Question) (int, error) {
+func (m *PostgresDBRepo) InsertCategory(category models.Category) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `insert into questions (form_id, question_text, type, extension, created_at, updated_at)
-			values ($1, $2, $3, $4, $5, $6) returning id`
+	stmt := `insert into categories (name, slug, description, created_at, updated_at)
+			values ($1, $2, $3, $4, $5) returning id`
 
 	var newID int
 
 	err := m.DB.QueryRowContext(ctx, stmt,
-		question.FormID,
-		question.Question,
-		question.Type,
-		question.Extension,
-		question.CreatedAt,
-		question.UpdatedAt,
+		category.Name,
+		category.Slug,
+		category.Description,
+		category.CreatedAt,
+		category.UpdatedAt,
 	).Scan(&newID)
 
 	if err != nil {
@@ -1296,377 +1768,343 @@ func (m *PostgresDBRepo) InsertQuestion(question models.Question) (int, error) {
 	return newID, nil
 }
 
-func (m *PostgresDBRepo) UpdateQuestion(question models.Question) error {
+func (m *PostgresDBRepo) UpdateCategory(category models.Category) error {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `update questions set question_text = $1, type = $2, extension = $3, updated_at = $4
-				where id = $5`
+	stmt := `update categories set name = $1, slug = $2, description = $3, updated_at = $4 where id = $5`
 
 	_, err := m.DB.ExecContext(ctx, stmt,
-		question.Question,
-		question.Type,
-		question.Extension,
-		question.UpdatedAt,
-		question.ID,
+		category.Name,
+		category.Slug,
+		category.Description,
+		category.UpdatedAt,
+		category.ID,
 	)
 
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return err
 }
 
-func (m *PostgresDBRepo) DeleteQuestion(id int) error {
+// DeleteCategory deletes a category, its articles stay and only lose the category
+func (m *PostgresDBRepo) DeleteCategory(id int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `delete from questions where id = $1`
+	stmt := `delete from categories where id = $1`
 
 	_, err := m.DB.ExecContext(ctx, stmt, id)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return err
 }
 
-func (m *PostgresDBRepo) UpdateQuestionOptions(id int, options []string) error {
+// AllTags returns the tags with the number of articles using each
+func (m *PostgresDBRepo) AllTags() ([]*models.Tag, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `delete from options where question_id = $1`
+	query := `select t.id, t.name, t.slug, count(atg.article_id), t.created_at
+			from tags t
+			left join article_tags atg on atg.tag_id = t.id
+			group by t.id
+			order by t.name, t.id`
 
-	_, err := m.DB.ExecContext(ctx, stmt, id)
+	rows, err := m.DB.QueryContext(ctx, query)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	for _, n := range options {
-		stmt := `insert into options (question_id, option_text) values ($1, $2)`
-		_, err := m.DB.ExecContext(ctx, stmt, id, n)
+	var tags []*models.Tag
+
+	for rows.Next() {
+		var tag models.Tag
+		err := rows.Scan(&tag.ID, &tag.Name, &tag.Slug, &tag.Articles, &tag.CreatedAt)
 		if err != nil {
-			return err
+			return nil, err
 		}
+
+		tags = append(tags, &tag)
 	}
 
-	return nil
+	return tags, rows.Err()
 }
 
-func (m *PostgresDBRepo) DeleteQuestionOptions(id int) error {
+func (m *PostgresDBRepo) Tag(id int) (*models.Tag, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `delete from options where question_id = $1`
+	query := `select t.id, t.name, t.slug, count(atg.article_id), t.created_at
+			from tags t
+			left join article_tags atg on atg.tag_id = t.id
+			where t.id = $1
+			group by t.id`
 
-	_, err := m.DB.ExecContext(ctx, stmt, id)
+	var tag models.Tag
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(&tag.ID, &tag.Name, &tag.Slug, &tag.Articles, &tag.CreatedAt)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return &tag, nil
 }
 
-func (m *PostgresDBRepo) GetQuestionExtension(id int) (*models.Extension, error) {
+// TagSlugTaken reports whether another tag than excludeID uses slug
+func (m *PostgresDBRepo) TagSlugTaken(slug string, excludeID int) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	query := `
-				SELECT id, question_id, followup_question_id, followup_option_value
-				FROM questions_extension
-				WHERE question_id = $1
-			`
-
-	row := m.DB.QueryRowContext(ctx, query, id)
-
-	var ext models.Extension
-
-	err := row.Scan(
-		&ext.ID,
-		&ext.QuestionID,
-		&ext.FollowUpQuestion,
-		&ext.FollowUpOption,
-	)
+	query := `select exists (select 1 from tags where slug = $1 and id <> $2)`
 
-	if err != nil && err != sql.ErrNoRows {
-		return nil, err
-	}
+	var taken bool
 
-	if err == sql.ErrNoRows {
-		return nil, nil
+	err := m.DB.QueryRowContext(ctx, query, slug, excludeID).Scan(&taken)
+	if err != nil {
+		return false, err
 	}
 
-	return &ext, nil
+	return taken, nil
 }
 
-func (m *PostgresDBRepo) UpdateQuestionExtension(extension *models.Extension) error {
+func (m *PostgresDBRepo) InsertTag(tag models.Tag) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `delete from questions_extension where question_id = $1`
-
-	_, err := m.DB.ExecContext(ctx, stmt, extension.QuestionID)
-	if err != nil {
-		return err
-	}
-
-	stmt = `insert into questions_extension (question_id, followup_question_id, followup_option_value)
-			values ($1, $2, $3)`
+	stmt := `insert into tags (name, slug, created_at) values ($1, $2, $3) returning id`
 
-	_, err = m.DB.ExecContext(ctx, stmt,
-		extension.QuestionID,
-		extension.FollowUpQuestion,
-		extension.FollowUpOption,
-	)
+	var newID int
 
+	err := m.DB.QueryRowContext(ctx, stmt, tag.Name, tag.Slug, tag.CreatedAt).Scan(&newID)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return newID, nil
 }
 
-func (m *PostgresDBRepo) DeleteQuestionExtension(id int) error {
+func (m *PostgresDBRepo) UpdateTag(tag models.Tag) error {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `delete from questions_extension where question_id = $1`
-
-	_, err := m.DB.ExecContext(ctx, stmt, id)
-	if err != nil {
-		return err
-	}
+	stmt := `update tags set name = $1, slug = $2 where id = $3`
 
-	return nil
+	_, err := m.DB.ExecContext(ctx, stmt, tag.Name, tag.Slug, tag.ID)
+	return err
 }
 
-func (m *PostgresDBRepo) InsertAnswers(answers []*models.Answer) error {
+// DeleteTag deletes a tag and removes it from every article
+func (m *PostgresDBRepo) DeleteTag(id int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	for _, answer := range answers {
-		stmt := `insert into answers (user_id, form_id, question_id, answer_text) VALUES ($1, $2, $3, $4)`
-		_, err := m.DB.ExecContext(ctx, stmt,
-			answer.UserID,
-			answer.FormID,
-			answer.QuestionID,
-			answer.Answer,
-		)
-		if err != nil {
-			return err
-		}
-	}
+	stmt := `delete from tags where id = $1`
 
-	return nil
+	_, err := m.DB.ExecContext(ctx, stmt, id)
+	return err
 }
 
-func (m *PostgresDBRepo) GroupAnswersByQuestion(formID int, questionID int) ([]*models.GroupAnswer, error) {
+// AllForms returns the live forms, or the template library when templates is true
+func (m *PostgresDBRepo) AllForms(templates bool) ([]*models.Form, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	query := `SELECT COUNT(id) as count_answers, answer_text, form_id, question_id
-				FROM answers
-				WHERE form_id = $1 AND question_id = $2
-				GROUP BY answer_text, form_id, question_id`
+	query := `select id, title, description, hidden, has_time_limit, is_template, allow_edits, start_date, end_date, created_at, updated_at
+				from forms where is_template = $1 order by id`
 
-	rows, err := m.DB.QueryContext(ctx, query, formID, questionID)
+	rows, err := m.DB.QueryContext(ctx, query, templates)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var groupAnswers []*models.GroupAnswer
+	var forms []*models.Form
 
 	for rows.Next() {
-		var groupAnswer models.GroupAnswer
+		var form models.Form
 		err := rows.Scan(
-			&groupAnswer.Count,
-			&groupAnswer.Answer,
-			&groupAnswer.FormID,
-			&groupAnswer.QuestionID,
+			&form.ID,
+			&form.Title,
+			&form.Description,
+			&form.Hidden,
+			&form.HasTimeLimit,
+			&form.IsTemplate,
+			&form.AllowEdits,
+			&form.StartDate,
+			&form.EndDate,
+			&form.CreatedAt,
+			&form.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
 
-		groupAnswers = append(groupAnswers, &groupAnswer)
+		forms = append(forms, &form)
 	}
 
-	return groupAnswers, nil
+	return forms, nil
 }
 
-func (m *PostgresDBRepo) GetAnswersByUser(id int) ([]*models.Answer, error) {
+func (m *PostgresDBRepo) Form(id int) (*models.Form, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	query := `SELECT id, user_id, form_id, question_id, answer_text
-				FROM answers
-				WHERE user_id = $1`
-
-	rows, err := m.DB.QueryContext(ctx, query, id)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var answers []*models.Answer
+	query := `
+				SELECT id, title, description, hidden, has_time_limit, is_template, allow_edits, start_date, end_date, created_at, updated_at
+				FROM forms
+				WHERE id = $1
+			`
 
-	for rows.Next() {
-		var answer models.Answer
-		err := rows.Scan(
-			&answer.ID,
-			&answer.UserID,
-			&answer.FormID,
-			&answer.QuestionID,
-			&answer.Answer,
-		)
+	row := m.DB.QueryRowContext(ctx, query, id)
 
-		if err != nil && err != sql.ErrNoRows {
-			return nil, err
-		}
+	var form models.Form
 
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
+	err := row.Scan(
+		&form.ID,
+		&form.Title,
+		&form.Description,
+		&form.Hidden,
+		&form.HasTimeLimit,
+		&form.IsTemplate,
+		&form.AllowEdits,
+		&form.StartDate,
+		&form.EndDate,
+		&form.CreatedAt,
+		&form.UpdatedAt,
+	)
 
-		answers = append(answers, &answer)
+	if err != nil {
+		return nil, err
 	}
 
-	return answers, nil
-}
-
-func (m *PostgresDBRepo) AllForums() ([]*models.Forum, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
-	defer cancel()
-
-	query := `SELECT f.id, f.forum_text, f.user_id, f.published_at, u.username, u.is_admin
-				FROM forums f
-				LEFT JOIN users u ON f.user_id = u.id
-				ORDER BY f.id DESC`
+	query = `
+				SELECT q.id, q.question_text, q.type, q.extension, q.required, q.settings, COALESCE(q.section_id, 0), q.position, q.created_at, q.updated_at
+				FROM questions q
+				LEFT JOIN form_sections s ON s.id = q.section_id
+				WHERE q.form_id = $1
+				ORDER BY COALESCE(s.position, 0), q.position, q.id
+			`
 
-	rows, err := m.DB.QueryContext(ctx, query)
-	if err != nil {
+	rows, err := m.DB.QueryContext(ctx, query, id)
+	if err != nil && err != sql.ErrNoRows {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var forums []*models.Forum
-	var isAdmin bool
-
+	var questions []*models.Question
 	for rows.Next() {
-		var forum models.Forum
+		var question models.Question
 		err := rows.Scan(
-			&forum.ID,
-			&forum.Forum,
-			&forum.UserID,
-			&forum.PublishedAt,
-			&forum.UserUsername,
-			&isAdmin,
+			&question.ID,
+			&question.Question,
+			&question.Type,
+			&question.Extension,
+			&question.Required,
+			&question.Settings,
+			&question.SectionID,
+			&question.Position,
+			&question.CreatedAt,
+			&question.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
 
-		likesNumber, err := m.GetForumLikesNumber(forum.ID)
-		if err != nil {
-			return nil, err
-		}
-
-		commentsNumber, err := m.GetForumCommentsNumber(forum.ID)
-		if err != nil {
-			return nil, err
-		}
-
-		comments, err := m.GetCommentsByForum(forum.ID)
-		if err != nil {
-			return nil, err
-		}
-
-		forum.LikesNumber = likesNumber
-		forum.CommentsNumber = commentsNumber
-		forum.Comments = comments
-
-		if !isAdmin {
-			alumniID, err := m.GetProfileByUserID(forum.UserID)
-			if err != nil {
-				return nil, err
-			}
-
-			name, err := m.GetAlumniNameByID(alumniID.AlumniID)
-			if err != nil {
-				return nil, err
-			}
-
-			photo, err := m.GetUserPhotoByID(forum.UserID)
-			if err != nil {
-				return nil, err
-			}
-
-			forum.UserName = name
-			forum.UserPhoto = photo
-		}
-
-		forums = append(forums, &forum)
+		questions = append(questions, &question)
 	}
 
-	return forums, nil
+	form.Questions = questions
+
+	return &form, nil
 }
 
-func (m *PostgresDBRepo) Forum(id int) (*models.Forum, error) {
+func (m *PostgresDBRepo) ShowForm(id int) (*models.Form, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
 	query := `
-				SELECT id, form_text, user_id, published_at
-				FROM forums
+				SELECT id, title, description, hidden, has_time_limit, is_template, allow_edits, start_date, end_date, created_at, updated_at
+				FROM forms
 				WHERE id = $1
 			`
 
 	row := m.DB.QueryRowContext(ctx, query, id)
 
-	var forum models.Forum
+	var form models.Form
 
 	err := row.Scan(
-		&forum.ID,
-		&forum.Forum,
-		&forum.UserID,
-		&forum.PublishedAt,
+		&form.ID,
+		&form.Title,
+		&form.Description,
+		&form.Hidden,
+		&form.HasTimeLimit,
+		&form.IsTemplate,
+		&form.AllowEdits,
+		&form.StartDate,
+		&form.EndDate,
+		&form.CreatedAt,
+		&form.UpdatedAt,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
-	comments, err := m.GetCommentsByForum(id)
+	fullQuestion, err := m.QuestionsByForm(form.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	likes, err := m.GetLikesByForum(id)
+	form.Questions = fullQuestion
+
+	sections, err := m.FormSections(form.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	forum.Likes = likes
-	forum.Comments = comments
+	// Questions without a section are grouped in an untitled section in front of the others
+	untitled := &models.Section{FormID: form.ID, Questions: []*models.Question{}}
 
-	return &forum, nil
+	bySection := make(map[int]*models.Section)
+	for _, section := range sections {
+		section.Questions = []*models.Question{}
+		bySection[section.ID] = section
+	}
+
+	for _, question := range fullQuestion {
+		section, ok := bySection[question.SectionID]
+		if !ok {
+			section = untitled
+		}
+		section.Questions = append(section.Questions, question)
+	}
+
+	if len(untitled.Questions) > 0 || len(sections) == 0 {
+		form.Sections = append(form.Sections, untitled)
+	}
+	form.Sections = append(form.Sections, sections...)
+
+	return &form, nil
 }
 
-func (m *PostgresDBRepo) InsertForum(forum models.Forum) (int, error) {
+func (m *PostgresDBRepo) InsertForm(form models.Form) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `insert into forums (forum_text, user_id, published_at)
-			values ($1, $2, $3) returning id`
+	stmt := `insert into forms (title, description, has_time_limit, start_date,
+			end_date, is_template, allow_edits, created_at, updated_at)
+			values ($1, $2, $3, $4, $5, $6, $7, $8, $9) returning id`
 
 	var newID int
 
 	err := m.DB.QueryRowContext(ctx, stmt,
-		forum.Forum,
-		forum.UserID,
-		forum.PublishedAt,
+		form.Title,
+		form.Description,
+		form.HasTimeLimit,
+		form.StartDate,
+		form.EndDate,
+		form.IsTemplate,
+		form.AllowEdits,
+		form.CreatedAt,
+		form.UpdatedAt,
 	).Scan(&newID)
 
 	if err != nil {
@@ -1676,13 +2114,27 @@ func (m *PostgresDBRepo) InsertForum(forum models.Forum) (int, error) {
 	return newID, nil
 }
 
-func (m *PostgresDBRepo) DeleteForum(id int) error {
+func (m *PostgresDBRepo) UpdateForm(form models.Form) error {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `delete from forums where id = $1`
+	stmt := `update forms set title = $1, description = $2, has_time_limit = $3, start_date = $4, end_date = $5, hidden = $6,
+				is_template = $7, allow_edits = $8, updated_at = $9
+				where id = $10`
+
+	_, err := m.DB.ExecContext(ctx, stmt,
+		form.Title,
+		form.Description,
+		form.HasTimeLimit,
+		form.StartDate,
+		form.EndDate,
+		form.Hidden,
+		form.IsTemplate,
+		form.AllowEdits,
+		form.UpdatedAt,
+		form.ID,
+	)
 
-	_, err := m.DB.ExecContext(ctx, stmt, id)
 	if err != nil {
 		return err
 	}
@@ -1690,706 +2142,3513 @@ func (m *PostgresDBRepo) DeleteForum(id int) error {
 	return nil
 }
 
-func (m *PostgresDBRepo) GetForumsByUser(id int) ([]*models.Forum, error) {
+func (m *PostgresDBRepo) DeleteForm(id int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	query := `SELECT f.id, f.forum_text, f.user_id, f.published_at, u.username, u.is_admin
-				FROM forums f
-				LEFT JOIN users u ON f.user_id = u.id
-				WHERE u.id = $1
-				ORDER BY f.id DESC`
+	stmt := `delete from forms where id = $1`
 
-	rows, err := m.DB.QueryContext(ctx, query, id)
+	_, err := m.DB.ExecContext(ctx, stmt, id)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
 
-	var forums []*models.Forum
-	var isAdmin bool
+	return nil
+}
 
-	for rows.Next() {
-		var forum models.Forum
-		err := rows.Scan(
-			&forum.ID,
-			&forum.Forum,
-			&forum.UserID,
-			&forum.PublishedAt,
-			&forum.UserUsername,
-			&isAdmin,
-		)
-		if err != nil {
-			return nil, err
-		}
+func (m *PostgresDBRepo) ShowFormAnswers(id int) (*models.Form, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
 
-		likesNumber, err := m.GetForumLikesNumber(forum.ID)
-		if err != nil {
-			return nil, err
-		}
+	query := `
+				SELECT id, title, description, hidden, has_time_limit, is_template, allow_edits, start_date, end_date, created_at, updated_at
+				FROM forms
+				WHERE id = $1
+			`
 
-		commentsNumber, err := m.GetForumCommentsNumber(forum.ID)
+	row := m.DB.QueryRowContext(ctx, query, id)
+
+	var form models.Form
+
+	err := row.Scan(
+		&form.ID,
+		&form.Title,
+		&form.Description,
+		&form.Hidden,
+		&form.HasTimeLimit,
+		&form.IsTemplate,
+		&form.AllowEdits,
+		&form.StartDate,
+		&form.EndDate,
+		&form.CreatedAt,
+		&form.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query = `
+				SELECT q.id, q.question_text, q.type, q.extension, q.required, q.settings, COALESCE(q.section_id, 0), q.position, q.created_at, q.updated_at
+				FROM questions q
+				LEFT JOIN form_sections s ON s.id = q.section_id
+				WHERE q.form_id = $1
+				ORDER BY COALESCE(s.position, 0), q.position, q.id
+			`
+
+	rows, err := m.DB.QueryContext(ctx, query, id)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var questions []*models.Question
+	for rows.Next() {
+		var question models.Question
+		err := rows.Scan(
+			&question.ID,
+			&question.Question,
+			&question.Type,
+			&question.Extension,
+			&question.Required,
+			&question.Settings,
+			&question.SectionID,
+			&question.Position,
+			&question.CreatedAt,
+			&question.UpdatedAt,
+		)
 		if err != nil {
 			return nil, err
 		}
 
-		comments, err := m.GetCommentsByForum(forum.ID)
-		if err != nil {
+		// Get answers for each question
+		answerGroups, err := m.GroupAnswersByQuestion(form.ID, question.ID)
+		if err != nil && err != sql.ErrNoRows {
 			return nil, err
 		}
-
-		forum.LikesNumber = likesNumber
-		forum.CommentsNumber = commentsNumber
-		forum.Comments = comments
-
-		if !isAdmin {
-			alumniID, err := m.GetProfileByUserID(forum.UserID)
-			if err != nil {
-				return nil, err
-			}
-
-			name, err := m.GetAlumniNameByID(alumniID.AlumniID)
-			if err != nil {
-				return nil, err
-			}
-
-			forum.UserName = name
+		if err == sql.ErrNoRows {
+			return nil, nil
 		}
 
-		forums = append(forums, &forum)
+		question.GroupAnswer = answerGroups
+
+		questions = append(questions, &question)
 	}
 
-	return forums, nil
+	form.Questions = questions
+
+	return &form, nil
 }
 
-func (m *PostgresDBRepo) GetForumLikesNumber(id int) (int, error) {
+func (m *PostgresDBRepo) Question(id int) (*models.Question, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
 	query := `
-				SELECT COUNT(id) as count_likes
-				FROM likes
-				WHERE forum_id = $1
+				SELECT id, form_id, question_text, type, extension, required, settings, COALESCE(section_id, 0), position, created_at, updated_at
+				FROM questions
+				WHERE id = $1
 			`
 
 	row := m.DB.QueryRowContext(ctx, query, id)
 
-	var likesNumber int
+	var question models.Question
 
 	err := row.Scan(
-		&likesNumber,
+		&question.ID,
+		&question.FormID,
+		&question.Question,
+		&question.Type,
+		&question.Extension,
+		&question.Required,
+		&question.Settings,
+		&question.SectionID,
+		&question.Position,
+		&question.CreatedAt,
+		&question.UpdatedAt,
 	)
 
+	if err != nil {
+		return nil, err
+	}
+
+	query = `
+				SELECT id, question_id, option_text
+				FROM options
+				WHERE question_id = $1
+			`
+
+	rows, err := m.DB.QueryContext(ctx, query, id)
 	if err != nil && err != sql.ErrNoRows {
-		return 0, err
+		return nil, err
 	}
+	defer rows.Close()
 
-	if err == sql.ErrNoRows {
-		return 0, nil
+	var options []*models.Option
+	for rows.Next() {
+		var option models.Option
+		err := rows.Scan(
+			&option.ID,
+			&option.QuestionID,
+			&option.Option,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, &option)
 	}
 
-	return likesNumber, nil
+	question.Options = options
+
+	// Get answers for each question
+	answerQuery := `
+		SELECT id, user_id, form_id, question_id, answer_text, COALESCE(answer_row, '')
+		FROM answers
+		WHERE question_id = $1
+	`
+
+	answerRows, err := m.DB.QueryContext(ctx, answerQuery, question.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer answerRows.Close()
+
+	var answers []*models.Answer
+	for answerRows.Next() {
+		var answer models.Answer
+		err := answerRows.Scan(
+			&answer.ID,
+			&answer.UserID,
+			&answer.FormID,
+			&answer.QuestionID,
+			&answer.Answer,
+			&answer.Row,
+		)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, &answer)
+	}
 
+	question.Answers = answers
+
+	return &question, nil
 }
 
-func (m *PostgresDBRepo) GetForumCommentsNumber(id int) (int, error) {
+func (m *PostgresDBRepo) QuestionsByForm(id int) ([]*models.Question, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
 	query := `
-				SELECT COUNT(id) as count_replies
-				FROM replies
-				WHERE forum_id = $1
+				SELECT q.id, q.form_id, q.question_text, q.type, q.extension, q.required, q.settings, COALESCE(q.section_id, 0), q.position,
+					q.created_at, q.updated_at
+				FROM questions q
+				LEFT JOIN form_sections s ON s.id = q.section_id
+				WHERE q.form_id = $1
+				ORDER BY COALESCE(s.position, 0), q.position, q.id
 			`
 
-	row := m.DB.QueryRowContext(ctx, query, id)
+	rows, err := m.DB.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	var commentsNumber int
+	var questions []*models.Question
 
-	err := row.Scan(
-		&commentsNumber,
-	)
+	for rows.Next() {
+		var question models.Question
+		err := rows.Scan(
+			&question.ID,
+			&question.FormID,
+			&question.Question,
+			&question.Type,
+			&question.Extension,
+			&question.Required,
+			&question.Settings,
+			&question.SectionID,
+			&question.Position,
+			&question.CreatedAt,
+			&question.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
 
-	if err != nil && err != sql.ErrNoRows {
-		return 0, err
-	}
+		query = `
+					SELECT id, question_id, option_text
+					FROM options
+					WHERE question_id = $1
+				`
 
-	if err == sql.ErrNoRows {
-		return 0, nil
-	}
+		questionRows, err := m.DB.QueryContext(ctx, query, question.ID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		defer questionRows.Close()
 
-	return commentsNumber, nil
+		var options []*models.Option
+		for questionRows.Next() {
+			var option models.Option
+			err := questionRows.Scan(
+				&option.ID,
+				&option.QuestionID,
+				&option.Option,
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			options = append(options, &option)
+		}
+
+		question.Options = options
+
+		rules, err := m.GetQuestionRules(question.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		question.Rules = rules
+		if len(rules) > 0 {
+			question.QuestionExtension = rules[0]
+		}
 
+		questions = append(questions, &question)
+	}
+
+	return questions, nil
 }
 
-func (m *PostgresDBRepo) InsertComment(comment models.Comment) (int, error) {
+func (m *PostgresDBRepo) InsertQuestion(question models.Question) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `insert into replies (forum_id, user_id, reply_text, published_at)
-			values ($1, $2, $3, $4) returning id`
+	// New questions are appended at the end of the form
+	stmt := `insert into questions (form_id, question_text, type, extension, required, settings, section_id, position, created_at, updated_at)
+			values ($1, $2, $3, $4, $5, $6, $7, (select COALESCE(max(position), 0) + 1 from questions where form_id = $1), $8, $9)
+			returning id`
 
 	var newID int
 
 	err := m.DB.QueryRowContext(ctx, stmt,
-		comment.ForumID,
-		comment.UserID,
-		comment.Comment,
-		comment.PublishedAt,
+		question.FormID,
+		question.Question,
+		question.Type,
+		question.Extension,
+		question.Required,
+		question.Settings,
+		nullInt(question.SectionID),
+		question.CreatedAt,
+		question.UpdatedAt,
 	).Scan(&newID)
 
-	if err != nil {
-		return 0, err
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+func (m *PostgresDBRepo) UpdateQuestion(question models.Question) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `update questions set question_text = $1, type = $2, extension = $3, required = $4, settings = $5, section_id = $6,
+				updated_at = $7
+				where id = $8`
+
+	_, err := m.DB.ExecContext(ctx, stmt,
+		question.Question,
+		question.Type,
+		question.Extension,
+		question.Required,
+		question.Settings,
+		nullInt(question.SectionID),
+		question.UpdatedAt,
+		question.ID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *PostgresDBRepo) DeleteQuestion(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `delete from questions where id = $1`
+
+	_, err := m.DB.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *PostgresDBRepo) UpdateQuestionOptions(id int, options []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `delete from options where question_id = $1`
+
+	_, err := m.DB.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range options {
+		stmt := `insert into options (question_id, option_text) values ($1, $2)`
+		_, err := m.DB.ExecContext(ctx, stmt, id, n)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *PostgresDBRepo) DeleteQuestionOptions(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `delete from options where question_id = $1`
+
+	_, err := m.DB.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *PostgresDBRepo) GetQuestionRules(id int) ([]*models.Extension, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `
+				SELECT id, question_id, COALESCE(followup_question_id, 0), COALESCE(followup_section_id, 0),
+					COALESCE(followup_option_value, ''), operator, action
+				FROM questions_extension
+				WHERE question_id = $1
+				ORDER BY id
+			`
+
+	rows, err := m.DB.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*models.Extension
+
+	for rows.Next() {
+		var ext models.Extension
+		err := rows.Scan(
+			&ext.ID,
+			&ext.QuestionID,
+			&ext.FollowUpQuestion,
+			&ext.FollowUpSection,
+			&ext.FollowUpOption,
+			&ext.Operator,
+			&ext.Action,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, &ext)
+	}
+
+	return rules, nil
+}
+
+// SetQuestionRules replaces all branching rules of a question in one transaction
+func (m *PostgresDBRepo) SetQuestionRules(questionID int, rules []*models.Extension) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt := `delete from questions_extension where question_id = $1`
+
+	_, err = tx.ExecContext(ctx, stmt, questionID)
+	if err != nil {
+		return err
+	}
+
+	stmt = `insert into questions_extension (question_id, followup_question_id, followup_section_id, followup_option_value, operator, action)
+			values ($1, $2, $3, $4, $5, $6)`
+
+	for _, rule := range rules {
+		_, err = tx.ExecContext(ctx, stmt,
+			questionID,
+			nullInt(rule.FollowUpQuestion),
+			nullInt(rule.FollowUpSection),
+			rule.FollowUpOption,
+			rule.Operator,
+			rule.Action,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (m *PostgresDBRepo) DeleteQuestionExtension(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `delete from questions_extension where question_id = $1`
+
+	_, err := m.DB.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// InsertSubmission saves a submission and all of its answers in one transaction.
+// It returns repository.ErrAlreadySubmitted when the user has already submitted the form.
+func (m *PostgresDBRepo) InsertSubmission(submission models.FormSubmission) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt := `insert into form_submissions (form_id, user_id, submitted_at) values ($1, $2, $3)
+			on conflict (form_id, user_id) do nothing
+			returning id`
+
+	var submissionID int
+
+	err = tx.QueryRowContext(ctx, stmt, submission.FormID, submission.UserID, submission.SubmittedAt).Scan(&submissionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, repository.ErrAlreadySubmitted
+		}
+		return 0, err
+	}
+
+	// The draft has been promoted to the submission
+	stmt = `delete from form_drafts where form_id = $1 and user_id = $2`
+
+	_, err = tx.ExecContext(ctx, stmt, submission.FormID, submission.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Campaigns that invited the user to this form count them as submitted
+	stmt = `update campaign_recipients set submitted_at = $3
+			where user_id = $2 and submitted_at is null
+			and campaign_id in (select id from survey_campaigns where form_id = $1)`
+
+	_, err = tx.ExecContext(ctx, stmt, submission.FormID, submission.UserID, submission.SubmittedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	err = insertSubmissionAnswers(ctx, tx, submission, submissionID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = insertSubmissionVersion(ctx, tx, submission, submissionID, models.SubmissionSubmitted, submission.Answers)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return submissionID, nil
+}
+
+// ReplaceSubmission swaps the answers of an existing submission for new ones in one
+// transaction. It returns sql.ErrNoRows when the user has not submitted the form.
+func (m *PostgresDBRepo) ReplaceSubmission(submission models.FormSubmission) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt := `update form_submissions set updated_at = $3
+			where form_id = $1 and user_id = $2
+			returning id`
+
+	var submissionID int
+
+	err = tx.QueryRowContext(ctx, stmt, submission.FormID, submission.UserID, submission.UpdatedAt).Scan(&submissionID)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt = `delete from answers where submission_id = $1`
+
+	_, err = tx.ExecContext(ctx, stmt, submissionID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = insertSubmissionAnswers(ctx, tx, submission, submissionID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = insertSubmissionVersion(ctx, tx, submission, submissionID, models.SubmissionEdited, submission.Answers)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return submissionID, nil
+}
+
+// WithdrawSubmission deletes the submission of a user together with its answers, so the
+// form can be answered again. The withdrawn answers are kept in the version history.
+// It returns sql.ErrNoRows when the user has not submitted the form.
+func (m *PostgresDBRepo) WithdrawSubmission(formID int, userID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `select id from form_submissions where form_id = $1 and user_id = $2 for update`
+
+	var submissionID int
+
+	err = tx.QueryRowContext(ctx, query, formID, userID).Scan(&submissionID)
+	if err != nil {
+		return err
+	}
+
+	query = `select question_id, answer_text, COALESCE(answer_row, '')
+			from answers
+			where submission_id = $1
+			order by id`
+
+	rows, err := tx.QueryContext(ctx, query, submissionID)
+	if err != nil {
+		return err
+	}
+
+	var answers []*models.Answer
+	for rows.Next() {
+		answer := models.Answer{FormID: formID}
+		err := rows.Scan(&answer.QuestionID, &answer.Answer, &answer.Row)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		answers = append(answers, &answer)
+	}
+	rows.Close()
+
+	submission := models.FormSubmission{FormID: formID, UserID: userID}
+
+	err = insertSubmissionVersion(ctx, tx, submission, submissionID, models.SubmissionWithdrawn, answers)
+	if err != nil {
+		return err
+	}
+
+	// The answers are removed by the cascade and the version keeps a null submission
+	stmt := `delete from form_submissions where id = $1`
+
+	_, err = tx.ExecContext(ctx, stmt, submissionID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SubmissionVersions returns the history of the submission of a user to a form, oldest first
+func (m *PostgresDBRepo) SubmissionVersions(formID int, userID int) ([]*models.SubmissionVersion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `
+				SELECT id, form_id, user_id, COALESCE(submission_id, 0), version, action, answers, created_at
+				FROM form_submission_versions
+				WHERE form_id = $1 AND user_id = $2
+				ORDER BY version
+			`
+
+	rows, err := m.DB.QueryContext(ctx, query, formID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []*models.SubmissionVersion
+	for rows.Next() {
+		var version models.SubmissionVersion
+		var answers []byte
+
+		err := rows.Scan(
+			&version.ID,
+			&version.FormID,
+			&version.UserID,
+			&version.SubmissionID,
+			&version.Version,
+			&version.Action,
+			&answers,
+			&version.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal(answers, &version.Answers)
+		if err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, &version)
+	}
+
+	return versions, nil
+}
+
+// insertSubmissionAnswers stores the answers of a submission within tx
+func insertSubmissionAnswers(ctx context.Context, tx *sql.Tx, submission models.FormSubmission, submissionID int) error {
+	stmt := `insert into answers (user_id, form_id, question_id, answer_text, submission_id, answer_row)
+			values ($1, $2, $3, $4, $5, NULLIF($6, ''))`
+
+	for _, answer := range submission.Answers {
+		_, err := tx.ExecContext(ctx, stmt,
+			submission.UserID,
+			submission.FormID,
+			answer.QuestionID,
+			answer.Answer,
+			submissionID,
+			answer.Row,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertSubmissionVersion records the next version of the submission of a user within tx
+func insertSubmissionVersion(ctx context.Context, tx *sql.Tx, submission models.FormSubmission, submissionID int, action string, answers []*models.Answer) error {
+	// Only what was answered is kept in the snapshot
+	snapshot := make([]*models.Answer, 0, len(answers))
+	for _, answer := range answers {
+		snapshot = append(snapshot, &models.Answer{
+			QuestionID: answer.QuestionID,
+			Answer:     answer.Answer,
+			Row:        answer.Row,
+		})
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	stmt := `insert into form_submission_versions (form_id, user_id, submission_id, version, action, answers, created_at)
+			values ($1, $2, $3,
+				(select COALESCE(max(version), 0) + 1 from form_submission_versions where form_id = $1 and user_id = $2),
+				$4, $5, $6)`
+
+	_, err = tx.ExecContext(ctx, stmt,
+		submission.FormID,
+		submission.UserID,
+		nullInt(submissionID),
+		action,
+		string(data),
+		time.Now(),
+	)
+
+	return err
+}
+
+func (m *PostgresDBRepo) GroupAnswersByQuestion(formID int, questionID int) ([]*models.GroupAnswer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `SELECT COUNT(id) as count_answers, answer_text, COALESCE(answer_row, ''), form_id, question_id
+				FROM answers
+				WHERE form_id = $1 AND question_id = $2
+				GROUP BY answer_row, answer_text, form_id, question_id
+				ORDER BY answer_row, count_answers DESC`
+
+	rows, err := m.DB.QueryContext(ctx, query, formID, questionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groupAnswers []*models.GroupAnswer
+
+	for rows.Next() {
+		var groupAnswer models.GroupAnswer
+		err := rows.Scan(
+			&groupAnswer.Count,
+			&groupAnswer.Answer,
+			&groupAnswer.Row,
+			&groupAnswer.FormID,
+			&groupAnswer.QuestionID,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		groupAnswers = append(groupAnswers, &groupAnswer)
+	}
+
+	return groupAnswers, nil
+}
+
+func (m *PostgresDBRepo) GetAnswersByUser(id int) ([]*models.Answer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `SELECT id, user_id, form_id, question_id, answer_text, COALESCE(answer_row, '')
+				FROM answers
+				WHERE user_id = $1`
+
+	rows, err := m.DB.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var answers []*models.Answer
+
+	for rows.Next() {
+		var answer models.Answer
+		err := rows.Scan(
+			&answer.ID,
+			&answer.UserID,
+			&answer.FormID,
+			&answer.QuestionID,
+			&answer.Answer,
+			&answer.Row,
+		)
+
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		answers = append(answers, &answer)
+	}
+
+	return answers, nil
+}
+
+func (m *PostgresDBRepo) AllForums() ([]*models.Forum, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `SELECT f.id, f.forum_text, f.user_id, f.published_at, u.username, u.is_admin
+				FROM forums f
+				LEFT JOIN users u ON f.user_id = u.id
+				ORDER BY f.id DESC`
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var forums []*models.Forum
+	var isAdmin bool
+
+	for rows.Next() {
+		var forum models.Forum
+		err := rows.Scan(
+			&forum.ID,
+			&forum.Forum,
+			&forum.UserID,
+			&forum.PublishedAt,
+			&forum.UserUsername,
+			&isAdmin,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		likesNumber, err := m.GetForumLikesNumber(forum.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		commentsNumber, err := m.GetForumCommentsNumber(forum.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		comments, err := m.GetCommentsByForum(forum.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		forum.LikesNumber = likesNumber
+		forum.CommentsNumber = commentsNumber
+		forum.Comments = comments
+
+		if !isAdmin {
+			alumniID, err := m.GetProfileByUserID(forum.UserID)
+			if err != nil {
+				return nil, err
+			}
+
+			name, err := m.GetAlumniNameByID(alumniID.AlumniID)
+			if err != nil {
+				return nil, err
+			}
+
+			photo, err := m.GetUserPhotoByID(forum.UserID)
+			if err != nil {
+				return nil, err
+			}
+
+			forum.UserName = name
+			forum.UserPhoto = photo
+		}
+
+		forums = append(forums, &forum)
+	}
+
+	return forums, nil
+}
+
+func (m *PostgresDBRepo) Forum(id int) (*models.Forum, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `
+				SELECT id, forum_text, user_id, published_at
+				FROM forums
+				WHERE id = $1
+			`
+
+	row := m.DB.QueryRowContext(ctx, query, id)
+
+	var forum models.Forum
+
+	err := row.Scan(
+		&forum.ID,
+		&forum.Forum,
+		&forum.UserID,
+		&forum.PublishedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := m.GetCommentsByForum(id)
+	if err != nil {
+		return nil, err
+	}
+
+	likes, err := m.GetLikesByForum(id)
+	if err != nil {
+		return nil, err
+	}
+
+	forum.Likes = likes
+	forum.Comments = comments
+
+	return &forum, nil
+}
+
+func (m *PostgresDBRepo) InsertForum(forum models.Forum) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `insert into forums (forum_text, user_id, published_at)
+			values ($1, $2, $3) returning id`
+
+	var newID int
+
+	err := m.DB.QueryRowContext(ctx, stmt,
+		forum.Forum,
+		forum.UserID,
+		forum.PublishedAt,
+	).Scan(&newID)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+func (m *PostgresDBRepo) DeleteForum(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `delete from forums where id = $1`
+
+	_, err := m.DB.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *PostgresDBRepo) GetForumsByUser(id int) ([]*models.Forum, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `SELECT f.id, f.forum_text, f.user_id, f.published_at, u.username, u.is_admin
+				FROM forums f
+				LEFT JOIN users u ON f.user_id = u.id
+				WHERE u.id = $1
+				ORDER BY f.id DESC`
+
+	rows, err := m.DB.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var forums []*models.Forum
+	var isAdmin bool
+
+	for rows.Next() {
+		var forum models.Forum
+		err := rows.Scan(
+			&forum.ID,
+			&forum.Forum,
+			&forum.UserID,
+			&forum.PublishedAt,
+			&forum.UserUsername,
+			&isAdmin,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		likesNumber, err := m.GetForumLikesNumber(forum.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		commentsNumber, err := m.GetForumCommentsNumber(forum.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		comments, err := m.GetCommentsByForum(forum.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		forum.LikesNumber = likesNumber
+		forum.CommentsNumber = commentsNumber
+		forum.Comments = comments
+
+		if !isAdmin {
+			alumniID, err := m.GetProfileByUserID(forum.UserID)
+			if err != nil {
+				return nil, err
+			}
+
+			name, err := m.GetAlumniNameByID(alumniID.AlumniID)
+			if err != nil {
+				return nil, err
+			}
+
+			forum.UserName = name
+		}
+
+		forums = append(forums, &forum)
+	}
+
+	return forums, nil
+}
+
+func (m *PostgresDBRepo) GetForumLikesNumber(id int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `
+				SELECT COUNT(id) as count_likes
+				FROM likes
+				WHERE forum_id = $1
+			`
+
+	row := m.DB.QueryRowContext(ctx, query, id)
+
+	var likesNumber int
+
+	err := row.Scan(
+		&likesNumber,
+	)
+
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return likesNumber, nil
+
+}
+
+func (m *PostgresDBRepo) GetForumCommentsNumber(id int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `
+				SELECT COUNT(id) as count_replies
+				FROM replies
+				WHERE forum_id = $1
+			`
+
+	row := m.DB.QueryRowContext(ctx, query, id)
+
+	var commentsNumber int
+
+	err := row.Scan(
+		&commentsNumber,
+	)
+
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return commentsNumber, nil
+
+}
+
+func (m *PostgresDBRepo) InsertComment(comment models.Comment) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `insert into replies (forum_id, user_id, reply_text, published_at)
+			values ($1, $2, $3, $4) returning id`
+
+	var newID int
+
+	err := m.DB.QueryRowContext(ctx, stmt,
+		comment.ForumID,
+		comment.UserID,
+		comment.Comment,
+		comment.PublishedAt,
+	).Scan(&newID)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+func (m *PostgresDBRepo) Comment(id int) (*models.Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `
+				SELECT id, forum_id, user_id, reply_text, published_at
+				FROM replies
+				WHERE id = $1
+			`
+
+	row := m.DB.QueryRowContext(ctx, query, id)
+
+	var comment models.Comment
+
+	err := row.Scan(
+		&comment.ID,
+		&comment.ForumID,
+		&comment.UserID,
+		&comment.Comment,
+		&comment.PublishedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+func (m *PostgresDBRepo) DeleteComment(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `delete from replies where id = $1`
+
+	_, err := m.DB.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *PostgresDBRepo) GetCommentsByForum(id int) ([]*models.Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `
+				SELECT id, forum_id, user_id, reply_text, published_at
+				FROM replies
+				WHERE forum_id = $1
+			`
+
+	rows, err := m.DB.QueryContext(ctx, query, id)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []*models.Comment
+
+	for rows.Next() {
+		var comment models.Comment
+		err := rows.Scan(
+			&comment.ID,
+			&comment.ForumID,
+			&comment.UserID,
+			&comment.Comment,
+			&comment.PublishedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		user, err := m.GetUserByID(comment.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !user.IsAdmin {
+			profile, err := m.GetProfileByUserID(comment.UserID)
+			if err != nil {
+				return nil, err
+			}
+
+			name, err := m.GetAlumniNameByID(profile.AlumniID)
+			if err != nil {
+				return nil, err
+			}
+
+			comment.UserName = name
+		} else {
+			comment.UserName = ""
+		}
+
+		photo, err := m.GetUserPhotoByID(comment.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		comment.UserUsername = user.Username
+		comment.UserPhoto = photo
+
+		comments = append(comments, &comment)
+	}
+
+	return comments, nil
+}
+
+func (m *PostgresDBRepo) InsertLike(like models.Like) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	// Query untuk mengecek apakah like sudah ada
+	checkStmt := `SELECT COUNT(*) FROM likes WHERE forum_id = $1 AND user_id = $2`
+	var count int
+	err := m.DB.QueryRowContext(ctx, checkStmt, like.ForumID, like.UserID).Scan(&count)
+	if err != nil {
+		return err
+	}
+
+	// Jika sudah ada like dengan user_id dan forum_id yang sama, kembalikan error
+	if count > 0 {
+		return nil
+	}
+
+	// Query untuk menambahkan like
+	insertStmt := `INSERT INTO likes (forum_id, user_id, created_at)
+					VALUES ($1, $2, $3)`
+
+	_, err = m.DB.ExecContext(ctx, insertStmt,
+		like.ForumID,
+		like.UserID,
+		like.CreatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *PostgresDBRepo) DeleteLike(userId int, forumId int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `delete from likes where user_id = $1 and forum_id = $2`
+
+	_, err := m.DB.ExecContext(ctx, stmt, userId, forumId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *PostgresDBRepo) GetLikesByUser(id int) ([]*models.Like, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `
+				SELECT id, forum_id, user_id, created_at
+				FROM likes
+				WHERE user_id = $1
+			`
+
+	rows, err := m.DB.QueryContext(ctx, query, id)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var likes []*models.Like
+
+	for rows.Next() {
+		var like models.Like
+		err := rows.Scan(
+			&like.ID,
+			&like.ForumID,
+			&like.UserID,
+			&like.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		likes = append(likes, &like)
+	}
+
+	return likes, nil
+}
+
+func (m *PostgresDBRepo) GetLikesByForum(id int) ([]*models.Like, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `
+				SELECT id, forum_id, user_id, created_at
+				FROM likes
+				WHERE forum_id = $1
+			`
+
+	rows, err := m.DB.QueryContext(ctx, query, id)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var likes []*models.Like
+
+	for rows.Next() {
+		var like models.Like
+		err := rows.Scan(
+			&like.ID,
+			&like.ForumID,
+			&like.UserID,
+			&like.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		likes = append(likes, &like)
+	}
+
+	return likes, nil
+}
+
+func (m *PostgresDBRepo) AllJobs() ([]*models.Job, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `select id, user_id, job_position, company, job_location, job_type, min_salary, max_salary, closed, description, created_at, updated_at from jobs order by id`
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*models.Job
+
+	for rows.Next() {
+		var job models.Job
+		err := rows.Scan(
+			&job.ID,
+			&job.UserID,
+			&job.JobPosition,
+			&job.Company,
+			&job.JobLocation,
+			&job.JobType,
+			&job.MinSalary,
+			&job.MaxSalary,
+			&job.Closed,
+			&job.Description,
+			&job.CreatedAt,
+			&job.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, &job)
+	}
+
+	return jobs, nil
+}
+
+func (m *PostgresDBRepo) Job(id int) (*models.Job, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `select id, user_id, job_position, company, job_location, job_type, min_salary, max_salary, closed, description, created_at, updated_at from jobs where id = $1`
+
+	row := m.DB.QueryRowContext(ctx, query, id)
+
+	var job models.Job
+
+	err := row.Scan(
+		&job.ID,
+		&job.UserID,
+		&job.JobPosition,
+		&job.Company,
+		&job.JobLocation,
+		&job.JobType,
+		&job.MinSalary,
+		&job.MaxSalary,
+		&job.Closed,
+		&job.Description,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (m *PostgresDBRepo) InsertJob(job models.Job) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `insert into jobs (user_id, job_position, company, job_location, job_type, min_salary, max_salary, description, created_at, updated_at)
+			values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) returning id`
+
+	var newID int
+
+	err := m.DB.QueryRowContext(ctx, stmt,
+		job.UserID,
+		job.JobPosition,
+		job.Company,
+		job.JobLocation,
+		job.JobType,
+		job.MinSalary,
+		job.MaxSalary,
+		job.Description,
+		job.CreatedAt,
+		job.UpdatedAt,
+	).Scan(&newID)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+func (m *PostgresDBRepo) UpdateJob(job models.Job) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `update jobs set job_position = $1, company = $2, job_location = $3, job_type = $4, min_salary = $5, max_salary = $6, description = $7, closed = $8, updated_at = $9 
+			where id = $10`
+
+	_, err := m.DB.ExecContext(ctx, stmt,
+		job.JobPosition,
+		job.Company,
+		job.JobLocation,
+		job.JobType,
+		job.MinSalary,
+		job.MaxSalary,
+		job.Description,
+		job.Closed,
+		job.UpdatedAt,
+		job.ID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *PostgresDBRepo) DeleteJob(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `delete from jobs where id = $1`
+
+	_, err := m.DB.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *PostgresDBRepo) InsertAlumniEducation(education models.AlumniEducation) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `insert into alumni_educations (user_id, school_name, school_degree, school_study_major, start_year, end_year, currently_studying, created_at, updated_at)
+			values ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := m.DB.ExecContext(ctx, stmt,
+		education.UserID,
+		education.School,
+		education.Degree,
+		education.StudyMajor,
+		education.StartYear,
+		education.EndYear,
+		education.CurrentlyStudying,
+		education.CreatedAt,
+		education.UpdatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *PostgresDBRepo) GetAlumniEducations(id int) ([]*models.AlumniEducation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `select id, user_id, school_name, school_degree, school_study_major, start_year, end_year, currently_studying, created_at, updated_at from alumni_educations where user_id = $1`
+
+	rows, err := m.DB.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var educations []*models.AlumniEducation
+
+	for rows.Next() {
+		var education models.AlumniEducation
+		err := rows.Scan(
+			&education.ID,
+			&education.UserID,
+			&education.School,
+			&education.Degree,
+			&education.StudyMajor,
+			&education.StartYear,
+			&education.EndYear,
+			&education.CurrentlyStudying,
+			&education.CreatedAt,
+			&education.UpdatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		educations = append(educations, &education)
+	}
+
+	return educations, nil
+}
+
+func (m *PostgresDBRepo) GetAlumniEducation(id int) (*models.AlumniEducation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `select id, user_id, school_name, school_degree, school_study_major, start_year, end_year, currently_studying, created_at, updated_at from alumni_educations where id = $1`
+
+	row := m.DB.QueryRowContext(ctx, query, id)
+
+	var education models.AlumniEducation
+
+	err := row.Scan(
+		&education.ID,
+		&education.UserID,
+		&education.School,
+		&education.Degree,
+		&education.StudyMajor,
+		&education.StartYear,
+		&education.EndYear,
+		&education.CurrentlyStudying,
+		&education.CreatedAt,
+		&education.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &education, nil
+}
+
+func (m *PostgresDBRepo) DeleteAlumniEducations(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `delete from alumni_educations where id = $1`
+
+	_, err := m.DB.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *PostgresDBRepo) InsertAlumniJob(alumnijob models.AlumniJob) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `insert into alumni_jobs (user_id, position, company, company_location, employment_type, start_year, end_year, currently_working, created_at, updated_at)
+			values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := m.DB.ExecContext(ctx, stmt,
+		alumnijob.UserID,
+		alumnijob.Position,
+		alumnijob.Company,
+		alumnijob.CompanyLocation,
+		alumnijob.EmploymentType,
+		alumnijob.StartYear,
+		alumnijob.EndYear,
+		alumnijob.CurrentlyWorking,
+		alumnijob.CreatedAt,
+		alumnijob.UpdatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *PostgresDBRepo) GetAlumniJobs(id int) ([]*models.AlumniJob, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `select id, user_id, position, company, company_location, employment_type, start_year, end_year, currently_working, created_at, updated_at from alumni_jobs where user_id = $1`
+
+	rows, err := m.DB.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var alumnijobs []*models.AlumniJob
+
+	for rows.Next() {
+		var alumnijob models.AlumniJob
+		err := rows.Scan(
+			&alumnijob.ID,
+			&alumnijob.UserID,
+			&alumnijob.Position,
+			&alumnijob.Company,
+			&alumnijob.CompanyLocation,
+			&alumnijob.EmploymentType,
+			&alumnijob.StartYear,
+			&alumnijob.EndYear,
+			&alumnijob.CurrentlyWorking,
+			&alumnijob.CreatedAt,
+			&alumnijob.UpdatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		alumnijobs = append(alumnijobs, &alumnijob)
+	}
+
+	return alumnijobs, nil
+}
+
+func (m *PostgresDBRepo) GetAlumniJob(id int) (*models.AlumniJob, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `select id, user_id, position, company, company_location, employment_type, start_year, end_year, currently_working, created_at, updated_at from alumni_jobs where id = $1`
+
+	row := m.DB.QueryRowContext(ctx, query, id)
+
+	var alumnijob models.AlumniJob
+
+	err := row.Scan(
+		&alumnijob.ID,
+		&alumnijob.UserID,
+		&alumnijob.Position,
+		&alumnijob.Company,
+		&alumnijob.CompanyLocation,
+		&alumnijob.EmploymentType,
+		&alumnijob.StartYear,
+		&alumnijob.EndYear,
+		&alumnijob.CurrentlyWorking,
+		&alumnijob.CreatedAt,
+		&alumnijob.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &alumnijob, nil
+}
+
+func (m *PostgresDBRepo) DeleteAlumniJobs(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `delete from alumni_jobs where id = $1`
+
+	_, err := m.DB.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// searchHeadline are the ts_headline options used to highlight matches in search snippets
+const searchHeadline = `StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10, MaxFragments=2`
+
+// searchSnippet wraps a text column so that tags are stripped and <, > and & are escaped
+// before ts_headline runs on it. The snippets are rendered as HTML, so the <mark> tags
+// added by ts_headline must be the only markup in them.
+func searchSnippet(column string) string {
+	return fmt.Sprintf(`replace(replace(replace(regexp_replace(%s, '<[^>]*>', ' ', 'g'), '&', '&amp;'), '<', '&lt;'), '>', '&gt;')`, column)
+}
+
+func (m *PostgresDBRepo) Search(query string, limit int) (*models.SearchResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	result := models.SearchResult{Query: query}
+
+	alumniQuery := fmt.Sprintf(`
+				SELECT a.id, a.name, '', COALESCE(u.username, ''),
+					ts_headline('simple', %s, q, $3),
+					ts_rank(to_tsvector('simple', COALESCE(a.name, '')::text), q) AS rank
+				FROM alumni a
+				LEFT JOIN alumni_profile ap ON ap.alumni_id = a.id
+				LEFT JOIN users u ON u.id = ap.user_id,
+				websearch_to_tsquery('simple', $1) q
+				WHERE to_tsvector('simple', COALESCE(a.name, '')::text) @@ q
+				ORDER BY rank DESC, a.id
+				LIMIT $2
+			`, searchSnippet("COALESCE(a.name, '')::text"))
+
+	hits, err := m.searchHits(ctx, alumniQuery, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	result.Alumni = hits
+
+	profilesQuery := fmt.Sprintf(`
+				SELECT ap.id, a.name, '', u.username,
+					ts_headline('simple', %s, q, $3),
+					ts_rank(to_tsvector('simple', COALESCE(ap.bio, '') || ' ' || COALESCE(ap.location, '')::text), q) AS rank
+				FROM alumni_profile ap
+				JOIN alumni a ON a.id = ap.alumni_id
+				JOIN users u ON u.id = ap.user_id,
+				websearch_to_tsquery('simple', $1) q
+				WHERE to_tsvector('simple', COALESCE(ap.bio, '') || ' ' || COALESCE(ap.location, '')::text) @@ q
+				ORDER BY rank DESC, ap.id
+				LIMIT $2
+			`, searchSnippet("(COALESCE(ap.bio, '') || ' ' || COALESCE(ap.location, '')::text)"))
+
+	hits, err = m.searchHits(ctx, profilesQuery, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	result.Profiles = hits
+
+	articlesQuery := fmt.Sprintf(`
+				SELECT ar.id, ar.title, ar.slug, '',
+					ts_headline('simple', %s, q, $3),
+					ts_rank(to_tsvector('simple', COALESCE(ar.title, '')::text || ' ' || COALESCE(ar.body, '')), q) AS rank
+				FROM articles ar,
+				websearch_to_tsquery('simple', $1) q
+				WHERE to_tsvector('simple', COALESCE(ar.title, '')::text || ' ' || COALESCE(ar.body, '')) @@ q
+					AND ar.status IN ('published', 'scheduled') AND ar.published_at <= $4
+				ORDER BY rank DESC, ar.id
+				LIMIT $2
+			`, searchSnippet("COALESCE(ar.body, '')"))
+
+	hits, err = m.searchHits(ctx, articlesQuery, query, limit, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	result.Articles = hits
+
+	forumsQuery := fmt.Sprintf(`
+				SELECT f.id, '', '', u.username,
+					ts_headline('simple', %s, q, $3),
+					ts_rank(to_tsvector('simple', COALESCE(f.forum_text, '')), q) AS rank
+				FROM forums f
+				JOIN users u ON u.id = f.user_id,
+				websearch_to_tsquery('simple', $1) q
+				WHERE to_tsvector('simple', COALESCE(f.forum_text, '')) @@ q
+				ORDER BY rank DESC, f.id DESC
+				LIMIT $2
+			`, searchSnippet("COALESCE(f.forum_text, '')"))
+
+	hits, err = m.searchHits(ctx, forumsQuery, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	result.Forums = hits
+
+	jobsQuery := fmt.Sprintf(`
+				SELECT j.id, COALESCE(j.job_position, '') || ' - ' || COALESCE(j.company, ''), '', '',
+					ts_headline('simple', %s, q, $3),
+					ts_rank(to_tsvector('simple', COALESCE(j.job_position, '')::text || ' ' || COALESCE(j.company, '')::text || ' ' || COALESCE(j.description, '')), q) AS rank
+				FROM jobs j,
+				websearch_to_tsquery('simple', $1) q
+				WHERE to_tsvector('simple', COALESCE(j.job_position, '')::text || ' ' || COALESCE(j.company, '')::text || ' ' || COALESCE(j.description, '')) @@ q
+				ORDER BY rank DESC, j.id DESC
+				LIMIT $2
+			`, searchSnippet("COALESCE(j.description, '')"))
+
+	hits, err = m.searchHits(ctx, jobsQuery, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	result.Jobs = hits
+
+	return &result, nil
+}
+
+// searchHits runs one of the search queries and scans its rows into hits
+func (m *PostgresDBRepo) searchHits(ctx context.Context, query string, q string, limit int, args ...interface{}) ([]*models.SearchHit, error) {
+	rows, err := m.DB.QueryContext(ctx, query, append([]interface{}{q, limit, searchHeadline}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := []*models.SearchHit{}
+
+	for rows.Next() {
+		var hit models.SearchHit
+		err := rows.Scan(
+			&hit.ID,
+			&hit.Title,
+			&hit.Slug,
+			&hit.UserUsername,
+			&hit.Snippet,
+			&hit.Rank,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		hits = append(hits, &hit)
+	}
+
+	return hits, rows.Err()
+}
+
+func (m *PostgresDBRepo) InsertSession(session models.Session) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `insert into sessions (user_id, user_agent, ip, created_at, last_used_at, expires_at)
+			values ($1, $2, $3, $4, $5, $6) returning id`
+
+	var newID int
+
+	err := m.DB.QueryRowContext(ctx, stmt,
+		session.UserID,
+		session.UserAgent,
+		session.IP,
+		session.CreatedAt,
+		session.LastUsedAt,
+		session.ExpiresAt,
+	).Scan(&newID)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+func (m *PostgresDBRepo) InsertRefreshToken(token models.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `insert into refresh_tokens (jti, session_id, user_id, created_at, expires_at)
+			values ($1, $2, $3, $4, $5)`
+
+	_, err := m.DB.ExecContext(ctx, stmt,
+		token.JTI,
+		token.SessionID,
+		token.UserID,
+		token.CreatedAt,
+		token.ExpiresAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RotateRefreshToken marks the refresh token identified by oldJTI as used and stores its replacement.
+// Presenting a token that was already rotated revokes its whole session.
+func (m *PostgresDBRepo) RotateRefreshToken(oldJTI string, newToken models.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The expiry is checked by Postgres, which reads the stored wall clock in the
+	// connection time zone, instead of comparing it with the clock of the API
+	query := `select rt.session_id, rt.user_id, rt.used_at, s.revoked_at, s.expires_at <= now()
+			from refresh_tokens rt
+			join sessions s on s.id = rt.session_id
+			where rt.jti = $1
+			for update of rt, s`
+
+	var sessionID, userID int
+	var usedAt, revokedAt sql.NullTime
+	var expired bool
+
+	err = tx.QueryRowContext(ctx, query, oldJTI).Scan(&sessionID, &userID, &usedAt, &revokedAt, &expired)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	if revokedAt.Valid || expired || sessionID != newToken.SessionID || userID != newToken.UserID {
+		return repository.ErrSessionRevoked
+	}
+
+	if usedAt.Valid {
+		_, err = tx.ExecContext(ctx, `update sessions set revoked_at = $1 where id = $2`, now, sessionID)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return err
+		}
+
+		return repository.ErrRefreshTokenReused
+	}
+
+	_, err = tx.ExecContext(ctx, `update refresh_tokens set used_at = $1 where jti = $2`, now, oldJTI)
+	if err != nil {
+		return err
+	}
+
+	stmt := `insert into refresh_tokens (jti, session_id, user_id, created_at, expires_at)
+			values ($1, $2, $3, $4, $5)`
+
+	_, err = tx.ExecContext(ctx, stmt,
+		newToken.JTI,
+		newToken.SessionID,
+		newToken.UserID,
+		newToken.CreatedAt,
+		newToken.ExpiresAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `update sessions set last_used_at = $1, expires_at = $2 where id = $3`, now, newToken.ExpiresAt, sessionID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *PostgresDBRepo) RevokeSession(id int, userID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `update sessions set revoked_at = $1 where id = $2 and user_id = $3 and revoked_at is null`
+
+	result, err := m.DB.ExecContext(ctx, stmt, time.Now(), id, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (m *PostgresDBRepo) RevokeSessionByRefreshToken(jti string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `update sessions set revoked_at = $1
+			where id = (select session_id from refresh_tokens where jti = $2) and revoked_at is null`
+
+	_, err := m.DB.ExecContext(ctx, stmt, time.Now(), jti)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *PostgresDBRepo) GetActiveSessions(userID int) ([]*models.Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `select id, user_id, COALESCE(user_agent, ''), COALESCE(ip, ''), created_at, last_used_at, expires_at
+			from sessions
+			where user_id = $1 and revoked_at is null and expires_at > $2
+			order by last_used_at desc`
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*models.Session{}
+
+	for rows.Next() {
+		var session models.Session
+		err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.UserAgent,
+			&session.IP,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+			&session.ExpiresAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, &session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// SessionActive reports whether a session is neither revoked nor expired. It is looked up
+// on every authenticated request, so it only reads the session row by its primary key.
+func (m *PostgresDBRepo) SessionActive(id int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `select exists (select 1 from sessions where id = $1 and revoked_at is null and expires_at > now())`
+
+	var active bool
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(&active)
+	if err != nil {
+		return false, err
+	}
+
+	return active, nil
+}
+
+// userRolesQuery selects the role ids of a user. Admins flagged as super admin in their
+// profile get the super_admin role even without a user_roles row.
+const userRolesQuery = `select role_id from user_roles where user_id = $1
+			union
+			select r.id from roles r join admin_profile ap on ap.user_id = $1
+			where r.name = 'super_admin' and ap.is_super_admin`
+
+func (m *PostgresDBRepo) GetUserRoles(userID int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `select name from roles where id in (` + userRolesQuery + `) order by name`
+
+	return m.queryStrings(ctx, query, userID)
+}
+
+func (m *PostgresDBRepo) GetUserPermissions(userID int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `select distinct p.name from permissions p
+			join role_permissions rp on rp.permission_id = p.id
+			where rp.role_id in (` + userRolesQuery + `)
+			order by p.name`
+
+	return m.queryStrings(ctx, query, userID)
+}
+
+// queryStrings runs a query selecting a single text column and returns its values
+func (m *PostgresDBRepo) queryStrings(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []string{}
+
+	for rows.Next() {
+		var value string
+		err := rows.Scan(&value)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
+
+func (m *PostgresDBRepo) AllRoles() ([]*models.Role, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	query := `select r.id, r.name, COALESCE(r.description, ''), COALESCE(string_agg(p.name, ',' order by p.name), '')
+			from roles r
+			left join role_permissions rp on rp.role_id = r.id
+			left join permissions p on p.id = rp.permission_id
+			group by r.id
+			order by r.id`
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []*models.Role
+
+	for rows.Next() {
+		var role models.Role
+		var permissions string
+		err := rows.Scan(
+			&role.ID,
+			&role.Name,
+			&role.Description,
+			&permissions,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		role.Permissions = []string{}
+		if permissions != "" {
+			role.Permissions = strings.Split(permissions, ",")
+		}
+
+		roles = append(roles, &role)
+	}
+
+	return roles, nil
+}
+
+// SetUserRoles replaces every role of a user with the given role names
+func (m *PostgresDBRepo) SetUserRoles(userID int, roles []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `delete from user_roles where user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range roles {
+		stmt := `insert into user_roles (user_id, role_id) select $1, id from roles where name = $2`
+
+		result, err := tx.ExecContext(ctx, stmt, userID, role)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affected == 0 {
+			return fmt.Errorf("unknown role %s", role)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// nullInt stores zero ids as NULL
+// isUniqueViolation reports whether err is a violation of the unique constraint named constraint
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == constraint
+}
+
+func nullInt(n int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(n), Valid: n != 0}
+}
+
+// nullJSON stores empty JSON documents as NULL
+func nullJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return string(data)
+}
+
+func (m *PostgresDBRepo) InsertAuditEntry(entry models.AuditEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `insert into audit_log (actor_id, action, entity_type, entity_id, before, after, ip, user_agent, created_at)
+			values ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := m.DB.ExecContext(ctx, stmt,
+		nullInt(entry.ActorID),
+		entry.Action,
+		entry.EntityType,
+		nullInt(entry.EntityID),
+		nullJSON(entry.Before),
+		nullJSON(entry.After),
+		entry.IP,
+		entry.UserAgent,
+		time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var auditSortColumns = map[string]string{
+	"created_at": "a.created_at",
+	"action":     "a.action",
+	"entity":     "a.entity_type",
+}
+
+func (m *PostgresDBRepo) AuditLog(opts models.QueryOptions) ([]*models.AuditEntry, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	var conditions []string
+	var args []interface{}
+
+	if actor := opts.Filter("actor"); actor != "" {
+		if n, err := strconv.Atoi(actor); err == nil {
+			args = append(args, n)
+			conditions = append(conditions, fmt.Sprintf("a.actor_id = $%d", len(args)))
+		} else {
+			args = append(args, actor)
+			conditions = append(conditions, fmt.Sprintf("u.username = $%d", len(args)))
+		}
+	}
+
+	if entityType := opts.Filter("entity_type"); entityType != "" {
+		args = append(args, entityType)
+		conditions = append(conditions, fmt.Sprintf("a.entity_type = $%d", len(args)))
+	}
+
+	if entityID := opts.Filter("entity_id"); entityID != "" {
+		n, err := strconv.Atoi(entityID)
+		if err != nil {
+			return nil, 0, errors.New("entity_id must be a number")
+		}
+		args = append(args, n)
+		conditions = append(conditions, fmt.Sprintf("a.entity_id = $%d", len(args)))
+	}
+
+	if from := opts.Filter("from"); from != "" {
+		t, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return nil, 0, errors.New("from must be a date formatted as YYYY-MM-DD")
+		}
+		args = append(args, t)
+		conditions = append(conditions, fmt.Sprintf("a.created_at >= $%d", len(args)))
+	}
+
+	if to := opts.Filter("to"); to != "" {
+		t, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return nil, 0, errors.New("to must be a date formatted as YYYY-MM-DD")
+		}
+		// The whole end day is included
+		args = append(args, t.AddDate(0, 0, 1))
+		conditions = append(conditions, fmt.Sprintf("a.created_at < $%d", len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	orderBy, err := sortClause(opts.Sort, auditSortColumns, "a.id DESC")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	countQuery := `SELECT COUNT(a.id) FROM audit_log a
+				LEFT JOIN users u ON u.id = a.actor_id ` + where
+
+	var total int
+	err = m.DB.QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`SELECT a.id, COALESCE(a.actor_id, 0), COALESCE(u.username, ''), a.action, a.entity_type,
+				COALESCE(a.entity_id, 0), COALESCE(a.before::text, ''), COALESCE(a.after::text, ''),
+				COALESCE(a.ip, ''), COALESCE(a.user_agent, ''), a.created_at
+				FROM audit_log a
+				LEFT JOIN users u ON u.id = a.actor_id
+				%s
+				ORDER BY %s
+				LIMIT $%d OFFSET $%d`, where, orderBy, len(args)+1, len(args)+2)
+
+	args = append(args, opts.PerPage, opts.Offset())
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []*models.AuditEntry
+
+	for rows.Next() {
+		var entry models.AuditEntry
+		var before, after string
+		err := rows.Scan(
+			&entry.ID,
+			&entry.ActorID,
+			&entry.ActorUsername,
+			&entry.Action,
+			&entry.EntityType,
+			&entry.EntityID,
+			&before,
+			&after,
+			&entry.IP,
+			&entry.UserAgent,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if before != "" {
+			entry.Before = json.RawMessage(before)
+		}
+		if after != "" {
+			entry.After = json.RawMessage(after)
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	return entries, total, nil
+}
+
+// breakdownColumns are the alumni columns the responses of a form can be broken down by
+var breakdownColumns = map[string]string{
+	"graduation_year": "a.graduation_year::text",
+	"class":           "a.class",
+	"gender":          "a.gender",
+}
+
+// FormAnalytics counts the respondents of a form, breaks them down by alumni data and
+// aggregates the answers of every question
+func (m *PostgresDBRepo) FormAnalytics(formID int) (*models.FormAnalytics, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbLongTimeOut)
+	defer cancel()
+
+	analytics := models.FormAnalytics{FormID: formID}
+
+	// Submissions are the record of who answered, withdrawn ones are deleted with their answers
+	query := `SELECT COUNT(*) FROM form_submissions WHERE form_id = $1`
+
+	err := m.DB.QueryRowContext(ctx, query, formID).Scan(&analytics.Respondents)
+	if err != nil {
+		return nil, err
+	}
+
+	breakdowns := map[string]*[]*models.ResponseBreakdown{
+		"graduation_year": &analytics.ByGraduationYear,
+		"class":           &analytics.ByClass,
+		"gender":          &analytics.ByGender,
+	}
+
+	for key, target := range breakdowns {
+		query := fmt.Sprintf(`SELECT COALESCE(%s, ''), COUNT(a.id), COUNT(r.user_id)
+				FROM alumni a
+				LEFT JOIN alumni_profile ap ON ap.alumni_id = a.id
+				LEFT JOIN (SELECT DISTINCT user_id FROM form_submissions WHERE form_id = $1) r ON r.user_id = ap.user_id
+				GROUP BY 1
+				ORDER BY 1`, breakdownColumns[key])
+
+		rows, err := m.DB.QueryContext(ctx, query, formID)
+		if err != nil {
+			return nil, err
+		}
+
+		var groups []*models.ResponseBreakdown
+		for rows.Next() {
+			var group models.ResponseBreakdown
+			err := rows.Scan(&group.Group, &group.Alumni, &group.Respondents)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+
+			group.ResponseRate = models.Rate(group.Respondents, group.Alumni)
+			groups = append(groups, &group)
+		}
+
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		*target = groups
+	}
+
+	query = `SELECT q.id, q.question_text, q.type, COUNT(DISTINCT ans.user_id)
+			FROM questions q
+			LEFT JOIN form_sections s ON s.id = q.section_id
+			LEFT JOIN answers ans ON ans.question_id = q.id
+			WHERE q.form_id = $1
+			GROUP BY q.id, q.question_text, q.type, s.position, q.position
+			ORDER BY COALESCE(s.position, 0), q.position, q.id`
+
+	rows, err := m.DB.QueryContext(ctx, query, formID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[int]*models.QuestionStats)
+
+	for rows.Next() {
+		var question models.QuestionStats
+		err := rows.Scan(&question.QuestionID, &question.Question, &question.Type, &question.Responses)
+		if err != nil {
+			return nil, err
+		}
+
+		stats[question.QuestionID] = &question
+		analytics.Questions = append(analytics.Questions, &question)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Answers of questions picking from options are counted per option
+	query = `SELECT ans.question_id, ans.answer_text, COALESCE(ans.answer_row, ''), COUNT(ans.id)
+			FROM answers ans
+			JOIN questions q ON q.id = ans.question_id
+			WHERE q.form_id = $1 AND q.type IN ('multiple_choice', 'checkboxes', 'dropdown', 'grid', 'linear_scale')
+			GROUP BY ans.question_id, ans.answer_row, ans.answer_text
+			ORDER BY ans.question_id, ans.answer_row, COUNT(ans.id) DESC`
+
+	answerRows, err := m.DB.QueryContext(ctx, query, formID)
+	if err != nil {
+		return nil, err
+	}
+	defer answerRows.Close()
+
+	for answerRows.Next() {
+		answer := models.GroupAnswer{FormID: formID}
+		err := answerRows.Scan(&answer.QuestionID, &answer.Answer, &answer.Row, &answer.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		if question, ok := stats[answer.QuestionID]; ok {
+			question.Answers = append(question.Answers, &answer)
+		}
+	}
+
+	if err = answerRows.Err(); err != nil {
+		return nil, err
+	}
+
+	query = `SELECT s.question_id, COUNT(*), AVG(s.value), percentile_cont(0.5) WITHIN GROUP (ORDER BY s.value), MIN(s.value), MAX(s.value)
+			FROM (
+				SELECT ans.question_id, ans.answer_text::float8 AS value
+				FROM answers ans
+				JOIN questions q ON q.id = ans.question_id
+				WHERE q.form_id = $1 AND q.type IN ('linear_scale', 'number')
+					AND ans.answer_text ~ '^-?[0-9]+(\.[0-9]+)?$'
+			) s
+			GROUP BY s.question_id`
+
+	summaryRows, err := m.DB.QueryContext(ctx, query, formID)
+	if err != nil {
+		return nil, err
+	}
+	defer summaryRows.Close()
+
+	for summaryRows.Next() {
+		var questionID int
+		var summary models.NumericSummary
+		err := summaryRows.Scan(&questionID, &summary.Count, &summary.Mean, &summary.Median, &summary.Min, &summary.Max)
+		if err != nil {
+			return nil, err
+		}
+
+		if question, ok := stats[questionID]; ok {
+			question.Summary = &summary
+		}
+	}
+
+	if err = summaryRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &analytics, nil
+}
+
+// CrossTab counts the respondents of a form for every pair of answers to two of its questions
+func (m *PostgresDBRepo) CrossTab(formID int, rowQuestionID int, columnQuestionID int) (*models.CrossTab, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbLongTimeOut)
+	defer cancel()
+
+	query := `SELECT r.answer_text, c.answer_text, COUNT(DISTINCT r.user_id)
+			FROM answers r
+			JOIN answers c ON c.user_id = r.user_id AND c.form_id = r.form_id
+			WHERE r.form_id = $1 AND r.question_id = $2 AND c.question_id = $3
+			GROUP BY r.answer_text, c.answer_text
+			ORDER BY r.answer_text, c.answer_text`
+
+	rows, err := m.DB.QueryContext(ctx, query, formID, rowQuestionID, columnQuestionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	crossTab := models.CrossTab{
+		RowQuestionID:    rowQuestionID,
+		ColumnQuestionID: columnQuestionID,
+		Cells:            []*models.CrossTabCell{},
+	}
+
+	for rows.Next() {
+		var cell models.CrossTabCell
+		err := rows.Scan(&cell.Row, &cell.Column, &cell.Count)
+		if err != nil {
+			return nil, err
+		}
+
+		crossTab.Cells = append(crossTab.Cells, &cell)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return newID, nil
+	return &crossTab, nil
 }
 
-func (m *PostgresDBRepo) GetCommentsByForum(id int) ([]*models.Comment, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+// FormRespondents returns one respondent per submission of a form together with their
+// answers. Submissions without any answers are included so the export matches the analytics.
+func (m *PostgresDBRepo) FormRespondents(formID int) ([]*models.Respondent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbLongTimeOut)
 	defer cancel()
 
-	query := `
-				SELECT id, forum_id, user_id, reply_text, published_at
-				FROM replies
-				WHERE forum_id = $1
-			`
-
-	rows, err := m.DB.QueryContext(ctx, query, id)
-	if err != nil && err != sql.ErrNoRows {
+	query := `SELECT s.id, u.id, u.username, COALESCE(a.name, ''), COALESCE(a.nisn, ''), COALESCE(a.graduation_year, 0), COALESCE(a.class, ''),
+				ans.id, ans.question_id, ans.answer_text, ans.answer_row
+			FROM form_submissions s
+			JOIN users u ON u.id = s.user_id
+			LEFT JOIN alumni_profile ap ON ap.user_id = u.id
+			LEFT JOIN alumni a ON a.id = ap.alumni_id
+			LEFT JOIN answers ans ON ans.submission_id = s.id
+			WHERE s.form_id = $1
+			ORDER BY a.graduation_year, a.class, a.name, u.id, s.id, ans.id`
+
+	rows, err := m.DB.QueryContext(ctx, query, formID)
+	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var comments []*models.Comment
+	var respondents []*models.Respondent
+	bySubmission := make(map[int]*models.Respondent)
 
 	for rows.Next() {
-		var comment models.Comment
+		var submissionID int
+		var respondent models.Respondent
+		var answerID, questionID sql.NullInt64
+		var answerText, answerRow sql.NullString
 		err := rows.Scan(
-			&comment.ID,
-			&comment.ForumID,
-			&comment.UserID,
-			&comment.Comment,
-			&comment.PublishedAt,
+			&submissionID,
+			&respondent.UserID,
+			&respondent.Username,
+			&respondent.Name,
+			&respondent.NISN,
+			&respondent.Year,
+			&respondent.Class,
+			&answerID,
+			&questionID,
+			&answerText,
+			&answerRow,
 		)
 		if err != nil {
 			return nil, err
 		}
 
-		user, err := m.GetUserByID(comment.UserID)
-		if err != nil {
-			return nil, err
-		}
-
-		if !user.IsAdmin {
-			profile, err := m.GetProfileByUserID(comment.UserID)
-			if err != nil {
-				return nil, err
-			}
-
-			name, err := m.GetAlumniNameByID(profile.AlumniID)
-			if err != nil {
-				return nil, err
-			}
-
-			comment.UserName = name
-		} else {
-			comment.UserName = ""
+		current, ok := bySubmission[submissionID]
+		if !ok {
+			current = &respondent
+			bySubmission[submissionID] = current
+			respondents = append(respondents, current)
 		}
 
-		photo, err := m.GetUserPhotoByID(comment.UserID)
-		if err != nil {
-			return nil, err
+		// Submissions without answers only have the respondent columns
+		if !answerID.Valid {
+			continue
 		}
 
-		comment.UserUsername = user.Username
-		comment.UserPhoto = photo
+		current.Answers = append(current.Answers, &models.Answer{
+			ID:           int(answerID.Int64),
+			UserID:       current.UserID,
+			FormID:       formID,
+			QuestionID:   int(questionID.Int64),
+			Answer:       answerText.String,
+			SubmissionID: submissionID,
+			Row:          answerRow.String,
+		})
+	}
 
-		comments = append(comments, &comment)
+	if err = rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return comments, nil
+	return respondents, nil
 }
 
-func (m *PostgresDBRepo) InsertLike(like models.Like) error {
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+// DuplicateForm deep-copies a form with its sections, questions, options and branching rules
+// in one transaction. Rules are remapped to the copied questions. The copy starts hidden.
+func (m *PostgresDBRepo) DuplicateForm(id int, title string, isTemplate bool) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbLongTimeOut)
 	defer cancel()
 
-	// Query untuk mengecek apakah like sudah ada
-	checkStmt := `SELECT COUNT(*) FROM likes WHERE forum_id = $1 AND user_id = $2`
-	var count int
-	err := m.DB.QueryRowContext(ctx, checkStmt, like.ForumID, like.UserID).Scan(&count)
+	tx, err := m.DB.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	defer tx.Rollback()
 
-	// Jika sudah ada like dengan user_id dan forum_id yang sama, kembalikan error
-	if count > 0 {
-		return nil
-	}
+	now := time.Now()
 
-	// Query untuk menambahkan like
-	insertStmt := `INSERT INTO likes (forum_id, user_id, created_at)
-					VALUES ($1, $2, $3)`
+	stmt := `insert into forms (title, description, has_time_limit, start_date, end_date, hidden, is_template, allow_edits, created_at, updated_at)
+			select $2, description, has_time_limit, start_date, end_date, true, $3, allow_edits, $4, $4
+			from forms where id = $1
+			returning id`
 
-	_, err = m.DB.ExecContext(ctx, insertStmt,
-		like.ForumID,
-		like.UserID,
-		like.CreatedAt,
-	)
+	var newFormID int
 
+	err = tx.QueryRowContext(ctx, stmt, id, title, isTemplate, now).Scan(&newFormID)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
-}
-
-func (m *PostgresDBRepo) DeleteLike(userId int, forumId int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
-	defer cancel()
-
-	stmt := `delete from likes where user_id = $1 and forum_id = $2`
+	query := `select id from form_sections where form_id = $1 order by id`
 
-	_, err := m.DB.ExecContext(ctx, stmt, userId, forumId)
+	sectionRows, err := tx.QueryContext(ctx, query, id)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
-}
+	var sectionIDs []int
+	for sectionRows.Next() {
+		var sectionID int
+		err := sectionRows.Scan(&sectionID)
+		if err != nil {
+			sectionRows.Close()
+			return 0, err
+		}
+		sectionIDs = append(sectionIDs, sectionID)
+	}
+	sectionRows.Close()
 
-func (m *PostgresDBRepo) GetLikesByUser(id int) ([]*models.Like, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
-	defer cancel()
+	// old section id -> copied section id
+	sectionMap := make(map[int]int)
 
-	query := `
-				SELECT id, forum_id, user_id, created_at
-				FROM likes
-				WHERE user_id = $1
-			`
+	stmt = `insert into form_sections (form_id, title, description, position, created_at, updated_at)
+			select $2, title, description, position, $3, $3
+			from form_sections where id = $1
+			returning id`
 
-	rows, err := m.DB.QueryContext(ctx, query, id)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, err
+	for _, sectionID := range sectionIDs {
+		var newSectionID int
+		err = tx.QueryRowContext(ctx, stmt, sectionID, newFormID, now).Scan(&newSectionID)
+		if err != nil {
+			return 0, err
+		}
+		sectionMap[sectionID] = newSectionID
 	}
-	defer rows.Close()
 
-	var likes []*models.Like
+	query = `select id, COALESCE(section_id, 0) from questions where form_id = $1 order by id`
+
+	rows, err := tx.QueryContext(ctx, query, id)
+	if err != nil {
+		return 0, err
+	}
 
+	var questionIDs []int
+	questionSections := make(map[int]int)
 	for rows.Next() {
-		var like models.Like
-		err := rows.Scan(
-			&like.ID,
-			&like.ForumID,
-			&like.UserID,
-			&like.CreatedAt,
-		)
+		var questionID, sectionID int
+		err := rows.Scan(&questionID, &sectionID)
 		if err != nil {
-			return nil, err
+			rows.Close()
+			return 0, err
 		}
+		questionIDs = append(questionIDs, questionID)
+		questionSections[questionID] = sectionID
+	}
+	rows.Close()
 
-		likes = append(likes, &like)
+	// old question id -> copied question id
+	questionMap := make(map[int]int)
+
+	stmt = `insert into questions (form_id, question_text, type, extension, required, settings, section_id, position, created_at, updated_at)
+			select $2, question_text, type, extension, required, settings, $3, position, $4, $4
+			from questions where id = $1
+			returning id`
+
+	for _, questionID := range questionIDs {
+		var newQuestionID int
+		err = tx.QueryRowContext(ctx, stmt, questionID, newFormID, nullInt(sectionMap[questionSections[questionID]]), now).Scan(&newQuestionID)
+		if err != nil {
+			return 0, err
+		}
+		questionMap[questionID] = newQuestionID
 	}
 
-	return likes, nil
-}
+	for oldID, newID := range questionMap {
+		stmt = `insert into options (question_id, option_text)
+				select $2, option_text from options where question_id = $1 order by id`
 
-func (m *PostgresDBRepo) GetLikesByForum(id int) ([]*models.Like, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
-	defer cancel()
+		_, err = tx.ExecContext(ctx, stmt, oldID, newID)
+		if err != nil {
+			return 0, err
+		}
+	}
 
-	query := `
-				SELECT id, forum_id, user_id, created_at
-				FROM likes
-				WHERE forum_id = $1
-			`
+	query = `select question_id, COALESCE(followup_question_id, 0), COALESCE(followup_section_id, 0),
+				COALESCE(followup_option_value, ''), operator, action
+			from questions_extension
+			where question_id in (select id from questions where form_id = $1)
+			order by id`
 
-	rows, err := m.DB.QueryContext(ctx, query, id)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, err
+	ruleRows, err := tx.QueryContext(ctx, query, id)
+	if err != nil {
+		return 0, err
 	}
-	defer rows.Close()
 
-	var likes []*models.Like
+	var rules []*models.Extension
+	for ruleRows.Next() {
+		var rule models.Extension
+		err := ruleRows.Scan(&rule.QuestionID, &rule.FollowUpQuestion, &rule.FollowUpSection, &rule.FollowUpOption, &rule.Operator, &rule.Action)
+		if err != nil {
+			ruleRows.Close()
+			return 0, err
+		}
+		rules = append(rules, &rule)
+	}
+	ruleRows.Close()
 
-	for rows.Next() {
-		var like models.Like
-		err := rows.Scan(
-			&like.ID,
-			&like.ForumID,
-			&like.UserID,
-			&like.CreatedAt,
-		)
+	stmt = `insert into questions_extension (question_id, followup_question_id, followup_section_id, followup_option_value, operator, action)
+			values ($1, $2, $3, $4, $5, $6)`
+
+	for _, rule := range rules {
+		targetQuestion, okQuestion := questionMap[rule.FollowUpQuestion]
+		targetSection, okSection := sectionMap[rule.FollowUpSection]
+		if !okQuestion && !okSection {
+			// The rule points outside of the form, it cannot be copied
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx, stmt, questionMap[rule.QuestionID], nullInt(targetQuestion), nullInt(targetSection), rule.FollowUpOption, rule.Operator, rule.Action)
 		if err != nil {
-			return nil, err
+			return 0, err
 		}
+	}
 
-		likes = append(likes, &like)
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
 	}
 
-	return likes, nil
+	return newFormID, nil
 }
 
-func (m *PostgresDBRepo) AllJobs() ([]*models.Job, error) {
+// FormSections returns the sections of a form in the order they are shown
+func (m *PostgresDBRepo) FormSections(formID int) ([]*models.Section, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	query := `select id, user_id, job_position, company, job_location, job_type, min_salary, max_salary, closed, description, created_at, updated_at from jobs order by id`
+	query := `
+				SELECT id, form_id, COALESCE(title, ''), COALESCE(description, ''), position, created_at, updated_at
+				FROM form_sections
+				WHERE form_id = $1
+				ORDER BY position, id
+			`
 
-	rows, err := m.DB.QueryContext(ctx, query)
+	rows, err := m.DB.QueryContext(ctx, query, formID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var jobs []*models.Job
-
+	var sections []*models.Section
 	for rows.Next() {
-		var job models.Job
+		var section models.Section
 		err := rows.Scan(
-			&job.ID,
-			&job.UserID,
-			&job.JobPosition,
-			&job.Company,
-			&job.JobLocation,
-			&job.JobType,
-			&job.MinSalary,
-			&job.MaxSalary,
-			&job.Closed,
-			&job.Description,
-			&job.CreatedAt,
-			&job.UpdatedAt,
+			&section.ID,
+			&section.FormID,
+			&section.Title,
+			&section.Description,
+			&section.Position,
+			&section.CreatedAt,
+			&section.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
 
-		jobs = append(jobs, &job)
+		sections = append(sections, &section)
 	}
 
-	return jobs, nil
+	return sections, nil
 }
 
-func (m *PostgresDBRepo) Job(id int) (*models.Job, error) {
+func (m *PostgresDBRepo) Section(id int) (*models.Section, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	query := `select id, user_id, job_position, company, job_location, job_type, min_salary, max_salary, closed, description, created_at, updated_at from jobs where id = $1`
+	query := `
+				SELECT id, form_id, COALESCE(title, ''), COALESCE(description, ''), position, created_at, updated_at
+				FROM form_sections
+				WHERE id = $1
+			`
 
 	row := m.DB.QueryRowContext(ctx, query, id)
 
-	var job models.Job
+	var section models.Section
 
 	err := row.Scan(
-		&job.ID,
-		&job.UserID,
-		&job.JobPosition,
-		&job.Company,
-		&job.JobLocation,
-		&job.JobType,
-		&job.MinSalary,
-		&job.MaxSalary,
-		&job.Closed,
-		&job.Description,
-		&job.CreatedAt,
-		&job.UpdatedAt,
+		&section.ID,
+		&section.FormID,
+		&section.Title,
+		&section.Description,
+		&section.Position,
+		&section.CreatedAt,
+		&section.UpdatedAt,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &job, nil
+	return &section, nil
 }
 
-func (m *PostgresDBRepo) InsertJob(job models.Job) (int, error) {
+func (m *PostgresDBRepo) InsertSection(section models.Section) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `insert into jobs (user_id, job_position, company, job_location, job_type, min_salary, max_salary, description, created_at, updated_at)
-			values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) returning id`
+	// New sections are appended at the end of the form
+	stmt := `insert into form_sections (form_id, title, description, position, created_at, updated_at)
+			values ($1, $2, $3, (select COALESCE(max(position), 0) + 1 from form_sections where form_id = $1), $4, $5)
+			returning id`
 
 	var newID int
 
 	err := m.DB.QueryRowContext(ctx, stmt,
-		job.UserID,
-		job.JobPosition,
-		job.Company,
-		job.JobLocation,
-		job.JobType,
-		job.MinSalary,
-		job.MaxSalary,
-		job.Description,
-		job.CreatedAt,
-		job.UpdatedAt,
+		section.FormID,
+		section.Title,
+		section.Description,
+		section.CreatedAt,
+		section.UpdatedAt,
 	).Scan(&newID)
 
-	if err != nil {
-		return 0, err
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+func (m *PostgresDBRepo) UpdateSection(section models.Section) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `update form_sections set title = $1, description = $2, updated_at = $3
+				where id = $4`
+
+	_, err := m.DB.ExecContext(ctx, stmt,
+		section.Title,
+		section.Description,
+		section.UpdatedAt,
+		section.ID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteSection removes a section, its questions move to the untitled section
+func (m *PostgresDBRepo) DeleteSection(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	stmt := `delete from form_sections where id = $1`
+
+	_, err := m.DB.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReorderQuestions stores a new order of the sections and questions of a form in one
+// transaction. Sections take the order they are listed in, questions are numbered across
+// the whole form and moved into the section they are listed under.
+func (m *PostgresDBRepo) ReorderQuestions(formID int, order []*models.QuestionOrder) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	sectionStmt := `update form_sections set position = $1 where id = $2 and form_id = $3`
+	questionStmt := `update questions set section_id = $1, position = $2 where id = $3 and form_id = $4`
+
+	sectionPosition := 0
+	questionPosition := 0
+
+	for _, group := range order {
+		if group.SectionID != 0 {
+			sectionPosition++
+			_, err = tx.ExecContext(ctx, sectionStmt, sectionPosition, group.SectionID, formID)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, questionID := range group.QuestionIDs {
+			questionPosition++
+			_, err = tx.ExecContext(ctx, questionStmt, nullInt(group.SectionID), questionPosition, questionID, formID)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
-	return newID, nil
+	return tx.Commit()
 }
 
-func (m *PostgresDBRepo) UpdateJob(job models.Job) error {
+// Draft returns the draft a user saved for a form
+func (m *PostgresDBRepo) Draft(formID int, userID int) (*models.FormDraft, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `update jobs set job_position = $1, company = $2, job_location = $3, job_type = $4, min_salary = $5, max_salary = $6, description = $7, closed = $8, updated_at = $9 
-			where id = $10`
+	query := `
+				SELECT id, form_id, user_id, answers, created_at, updated_at
+				FROM form_drafts
+				WHERE form_id = $1 AND user_id = $2
+			`
 
-	_, err := m.DB.ExecContext(ctx, stmt,
-		job.JobPosition,
-		job.Company,
-		job.JobLocation,
-		job.JobType,
-		job.MinSalary,
-		job.MaxSalary,
-		job.Description,
-		job.Closed,
-		job.UpdatedAt,
-		job.ID,
+	row := m.DB.QueryRowContext(ctx, query, formID, userID)
+
+	var draft models.FormDraft
+	var answers []byte
+
+	err := row.Scan(
+		&draft.ID,
+		&draft.FormID,
+		&draft.UserID,
+		&answers,
+		&draft.CreatedAt,
+		&draft.UpdatedAt,
 	)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	err = json.Unmarshal(answers, &draft.Answers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &draft, nil
 }
 
-func (m *PostgresDBRepo) DeleteJob(id int) error {
+// SaveDraft creates or replaces the draft of a user for a form. Forms the user has
+// already submitted cannot get a draft anymore.
+func (m *PostgresDBRepo) SaveDraft(draft models.FormDraft) error {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `delete from jobs where id = $1`
+	answers, err := json.Marshal(draft.Answers)
+	if err != nil {
+		return err
+	}
 
-	_, err := m.DB.ExecContext(ctx, stmt, id)
+	stmt := `insert into form_drafts (form_id, user_id, answers, created_at, updated_at)
+			select $1, $2, $3, $4, $4
+			where not exists (select 1 from form_submissions where form_id = $1 and user_id = $2)
+			on conflict (form_id, user_id) do update set answers = excluded.answers, updated_at = excluded.updated_at
+			returning id`
+
+	var id int
+
+	err = m.DB.QueryRowContext(ctx, stmt, draft.FormID, draft.UserID, string(answers), draft.UpdatedAt).Scan(&id)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return repository.ErrAlreadySubmitted
+		}
 		return err
 	}
 
 	return nil
 }
 
-func (m *PostgresDBRepo) InsertAlumniEducation(education models.AlumniEducation) error {
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
-	defer cancel()
+// recipientsFrom builds the FROM and WHERE of the alumni with an account matching filter who
+// have not submitted formID yet. The arguments start at $1.
+func recipientsFrom(formID int, filter models.RecipientFilter) (string, []interface{}) {
+	args := []interface{}{formID}
+	conditions := []string{"NOT EXISTS (SELECT 1 FROM form_submissions s WHERE s.form_id = $1 AND s.user_id = u.id)"}
 
-	stmt := `insert into alumni_educations (user_id, school_name, school_degree, school_study_major, start_year, end_year, currently_studying, created_at, updated_at)
-			values ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	if filter.GraduationYearFrom != 0 {
+		args = append(args, filter.GraduationYearFrom)
+		conditions = append(conditions, fmt.Sprintf("a.graduation_year >= $%d", len(args)))
+	}
 
-	_, err := m.DB.ExecContext(ctx, stmt,
-		education.UserID,
-		education.School,
-		education.Degree,
-		education.StudyMajor,
-		education.StartYear,
-		education.EndYear,
-		education.CurrentlyStudying,
-		education.CreatedAt,
-		education.UpdatedAt,
-	)
+	if filter.GraduationYearTo != 0 {
+		args = append(args, filter.GraduationYearTo)
+		conditions = append(conditions, fmt.Sprintf("a.graduation_year <= $%d", len(args)))
+	}
 
-	if err != nil {
-		return err
+	if len(filter.Classes) > 0 {
+		var placeholders []string
+		for _, class := range filter.Classes {
+			args = append(args, class)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+		}
+		conditions = append(conditions, "a.class IN ("+strings.Join(placeholders, ", ")+")")
 	}
 
-	return nil
+	if filter.Gender != "" {
+		args = append(args, strings.ToUpper(filter.Gender))
+		conditions = append(conditions, fmt.Sprintf("a.gender = $%d", len(args)))
+	}
+
+	if filter.Location != "" {
+		args = append(args, "%"+filter.Location+"%")
+		conditions = append(conditions, fmt.Sprintf("ap.location ILIKE $%d", len(args)))
+	}
+
+	from := `FROM alumni a
+				JOIN alumni_profile ap ON ap.alumni_id = a.id
+				JOIN users u ON u.id = ap.user_id
+				WHERE ` + strings.Join(conditions, " AND ")
+
+	return from, args
 }
 
-func (m *PostgresDBRepo) GetAlumniEducations(id int) ([]*models.AlumniEducation, error) {
+// CampaignRecipients returns the alumni a campaign for formID with filter would be sent to
+func (m *PostgresDBRepo) CampaignRecipients(formID int, filter models.RecipientFilter) ([]*models.Recipient, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	query := `select id, user_id, school_name, school_degree, school_study_major, start_year, end_year, currently_studying, created_at, updated_at from alumni_educations where user_id = $1`
+	from, args := recipientsFrom(formID, filter)
 
-	rows, err := m.DB.QueryContext(ctx, query, id)
+	query := `SELECT u.id, COALESCE(a.name, ''), COALESCE(u.email, ''), COALESCE(a.phone, ''), COALESCE(a.graduation_year, 0), COALESCE(a.class, '')
+				` + from + `
+				ORDER BY a.graduation_year, a.class, a.name`
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var educations []*models.AlumniEducation
-
+	var recipients []*models.Recipient
 	for rows.Next() {
-		var education models.AlumniEducation
+		var recipient models.Recipient
 		err := rows.Scan(
-			&education.ID,
-			&education.UserID,
-			&education.School,
-			&education.Degree,
-			&education.StudyMajor,
-			&education.StartYear,
-			&education.EndYear,
-			&education.CurrentlyStudying,
-			&education.CreatedAt,
-			&education.UpdatedAt,
+			&recipient.UserID,
+			&recipient.Name,
+			&recipient.Email,
+			&recipient.Phone,
+			&recipient.Year,
+			&recipient.Class,
 		)
-
 		if err != nil {
 			return nil, err
 		}
 
-		educations = append(educations, &education)
+		recipients = append(recipients, &recipient)
 	}
 
-	return educations, nil
+	return recipients, nil
 }
 
-func (m *PostgresDBRepo) GetAlumniEducation(id int) (*models.AlumniEducation, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+// InsertCampaign stores a campaign, its recipients and the queue of its invitations and
+// reminders in one transaction. It returns the campaign id and the number of recipients.
+func (m *PostgresDBRepo) InsertCampaign(campaign models.Campaign) (int, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbLongTimeOut)
 	defer cancel()
 
-	query := `select id, user_id, school_name, school_degree, school_study_major, start_year, end_year, currently_studying, created_at, updated_at from alumni_educations where id = $1`
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
 
-	row := m.DB.QueryRowContext(ctx, query, id)
+	reminderDays, err := json.Marshal(campaign.ReminderDays)
+	if err != nil {
+		return 0, 0, err
+	}
 
-	var education models.AlumniEducation
+	stmt := `insert into survey_campaigns (form_id, name, channel, message, filters, start_at, reminder_days, created_by, created_at)
+			values ($1, $2, $3, $4, $5, $6, $7, $8, $9) returning id`
+
+	var campaignID int
+
+	err = tx.QueryRowContext(ctx, stmt,
+		campaign.FormID,
+		campaign.Name,
+		campaign.Channel,
+		campaign.Message,
+		campaign.Filter,
+		campaign.StartAt,
+		string(reminderDays),
+		nullInt(campaign.CreatedBy),
+		campaign.CreatedAt,
+	).Scan(&campaignID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	from, args := recipientsFrom(campaign.FormID, campaign.Filter)
+	args = append(args, campaignID)
+
+	// Every recipient gets a token for the link in their messages, used to track opens
+	stmt = fmt.Sprintf(`insert into campaign_recipients (campaign_id, user_id, token)
+			select $%d, u.id, replace(gen_random_uuid()::text, '-', '')
+			%s`, len(args), from)
+
+	result, err := tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	recipients, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	stmt = `insert into campaign_messages (recipient_id, kind, scheduled_at)
+			select id, $2, $3 from campaign_recipients where campaign_id = $1`
+
+	_, err = tx.ExecContext(ctx, stmt, campaignID, models.MessageInvitation, campaign.StartAt)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, days := range campaign.ReminderDays {
+		_, err = tx.ExecContext(ctx, stmt, campaignID, models.MessageReminder, campaign.StartAt.AddDate(0, 0, days))
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return campaignID, int(recipients), nil
+}
+
+// campaignStatsColumns counts the recipients of the campaign aliased c by status
+const campaignStatsColumns = `(SELECT COUNT(*) FROM campaign_recipients r WHERE r.campaign_id = c.id),
+				(SELECT COUNT(*) FROM campaign_recipients r WHERE r.campaign_id = c.id AND r.delivered_at IS NOT NULL),
+				(SELECT COUNT(*) FROM campaign_recipients r WHERE r.campaign_id = c.id AND r.opened_at IS NOT NULL),
+				(SELECT COUNT(*) FROM campaign_recipients r WHERE r.campaign_id = c.id AND r.submitted_at IS NOT NULL),
+				(SELECT COUNT(*) FROM campaign_recipients r WHERE r.campaign_id = c.id AND r.delivered_at IS NULL AND r.last_error IS NOT NULL)`
+
+func scanCampaign(row interface{ Scan(...interface{}) error }) (*models.Campaign, error) {
+	var campaign models.Campaign
+	var reminderDays []byte
+	var stats models.CampaignStats
 
 	err := row.Scan(
-		&education.ID,
-		&education.UserID,
-		&education.School,
-		&education.Degree,
-		&education.StudyMajor,
-		&education.StartYear,
-		&education.EndYear,
-		&education.CurrentlyStudying,
-		&education.CreatedAt,
-		&education.UpdatedAt,
+		&campaign.ID,
+		&campaign.FormID,
+		&campaign.Name,
+		&campaign.Channel,
+		&campaign.Message,
+		&campaign.Filter,
+		&campaign.StartAt,
+		&reminderDays,
+		&campaign.CreatedBy,
+		&campaign.CreatedAt,
+		&stats.Recipients,
+		&stats.Delivered,
+		&stats.Opened,
+		&stats.Submitted,
+		&stats.Failed,
 	)
-
 	if err != nil {
 		return nil, err
 	}
 
-	return &education, nil
+	if len(reminderDays) > 0 {
+		err = json.Unmarshal(reminderDays, &campaign.ReminderDays)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	campaign.Stats = &stats
+
+	return &campaign, nil
 }
 
-func (m *PostgresDBRepo) DeleteAlumniEducations(id int) error {
+// Campaigns returns the campaigns of a form with their delivery statistics, newest first
+func (m *PostgresDBRepo) Campaigns(formID int) ([]*models.Campaign, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `delete from alumni_educations where id = $1`
+	query := `SELECT c.id, c.form_id, c.name, c.channel, COALESCE(c.message, ''), c.filters, c.start_at, c.reminder_days,
+				COALESCE(c.created_by, 0), c.created_at,
+				` + campaignStatsColumns + `
+				FROM survey_campaigns c
+				WHERE c.form_id = $1
+				ORDER BY c.id DESC`
 
-	_, err := m.DB.ExecContext(ctx, stmt, id)
+	rows, err := m.DB.QueryContext(ctx, query, formID)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	return nil
+	var campaigns []*models.Campaign
+	for rows.Next() {
+		campaign, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		campaigns = append(campaigns, campaign)
+	}
+
+	return campaigns, nil
 }
 
-func (m *PostgresDBRepo) InsertAlumniJob(alumnijob models.AlumniJob) error {
+// Campaign returns a campaign with its delivery statistics
+func (m *PostgresDBRepo) Campaign(id int) (*models.Campaign, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `insert into alumni_jobs (user_id, position, company, company_location, employment_type, start_year, end_year, currently_working, created_at, updated_at)
-			values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	query := `SELECT c.id, c.form_id, c.name, c.channel, COALESCE(c.message, ''), c.filters, c.start_at, c.reminder_days,
+				COALESCE(c.created_by, 0), c.created_at,
+				` + campaignStatsColumns + `
+				FROM survey_campaigns c
+				WHERE c.id = $1`
 
-	_, err := m.DB.ExecContext(ctx, stmt,
-		alumnijob.UserID,
-		alumnijob.Position,
-		alumnijob.Company,
-		alumnijob.CompanyLocation,
-		alumnijob.EmploymentType,
-		alumnijob.StartYear,
-		alumnijob.EndYear,
-		alumnijob.CurrentlyWorking,
-		alumnijob.CreatedAt,
-		alumnijob.UpdatedAt,
-	)
+	return scanCampaign(m.DB.QueryRowContext(ctx, query, id))
+}
+
+// CampaignRecipientStatus returns the delivery status of every recipient of a campaign
+func (m *PostgresDBRepo) CampaignRecipientStatus(campaignID int) ([]*models.CampaignRecipient, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
+	defer cancel()
 
+	query := `SELECT r.user_id, COALESCE(a.name, ''), COALESCE(u.email, ''), COALESCE(a.phone, ''), COALESCE(a.graduation_year, 0),
+				COALESCE(a.class, ''), r.delivered_at, r.opened_at, r.submitted_at, COALESCE(r.last_error, '')
+				FROM campaign_recipients r
+				JOIN users u ON u.id = r.user_id
+				LEFT JOIN alumni_profile ap ON ap.user_id = u.id
+				LEFT JOIN alumni a ON a.id = ap.alumni_id
+				WHERE r.campaign_id = $1
+				ORDER BY a.graduation_year, a.class, a.name`
+
+	rows, err := m.DB.QueryContext(ctx, query, campaignID)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	return nil
+	var recipients []*models.CampaignRecipient
+	for rows.Next() {
+		var recipient models.CampaignRecipient
+		err := rows.Scan(
+			&recipient.UserID,
+			&recipient.Name,
+			&recipient.Email,
+			&recipient.Phone,
+			&recipient.Year,
+			&recipient.Class,
+			&recipient.DeliveredAt,
+			&recipient.OpenedAt,
+			&recipient.SubmittedAt,
+			&recipient.LastError,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		recipients = append(recipients, &recipient)
+	}
+
+	return recipients, nil
 }
 
-func (m *PostgresDBRepo) GetAlumniJobs(id int) ([]*models.AlumniJob, error) {
+// ClaimDueCampaignMessages marks up to limit queued messages scheduled before now as being
+// sent and returns them. Locked rows are skipped so several instances never send twice.
+// Messages left sending for longer than lease, because the process died or the outcome
+// could not be stored, are claimed again until they were tried maxAttempts times, after
+// which they count as failed.
+func (m *PostgresDBRepo) ClaimDueCampaignMessages(now time.Time, limit int, lease time.Duration, maxAttempts int) ([]*models.CampaignMessage, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	query := `select id, user_id, position, company, company_location, employment_type, start_year, end_year, currently_working, created_at, updated_at from alumni_jobs where user_id = $1`
+	expired := now.Add(-lease)
 
-	rows, err := m.DB.QueryContext(ctx, query, id)
+	stmt := `WITH given_up AS (
+				UPDATE campaign_messages SET status = $1, error = $2, sent_at = $3
+				WHERE status = $4 AND claimed_at <= $5 AND attempts >= $6
+				RETURNING recipient_id
+			)
+			UPDATE campaign_recipients r SET last_error = $2
+			FROM given_up
+			WHERE r.id = given_up.recipient_id`
+
+	_, err := m.DB.ExecContext(ctx, stmt, models.MessageFailed, "sending did not finish", now, models.MessageSending, expired, maxAttempts)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var alumnijobs []*models.AlumniJob
+	query := `WITH due AS (
+				SELECT m.id, r.campaign_id, m.recipient_id, m.kind, m.scheduled_at, c.channel, COALESCE(c.message, '') AS message,
+					c.form_id, COALESCE(f.title, '') AS title, r.token, r.user_id, COALESCE(a.name, u.username, '') AS name,
+					COALESCE(u.email, '') AS email, COALESCE(a.phone, '') AS phone, COALESCE(a.graduation_year, 0) AS graduation_year,
+					COALESCE(a.class, '') AS class,
+					EXISTS (SELECT 1 FROM form_submissions s WHERE s.form_id = c.form_id AND s.user_id = r.user_id) AS submitted
+				FROM campaign_messages m
+				JOIN campaign_recipients r ON r.id = m.recipient_id
+				JOIN survey_campaigns c ON c.id = r.campaign_id
+				JOIN forms f ON f.id = c.form_id
+				JOIN users u ON u.id = r.user_id
+				LEFT JOIN alumni_profile ap ON ap.user_id = u.id
+				LEFT JOIN alumni a ON a.id = ap.alumni_id
+				WHERE ((m.status = $3 AND m.scheduled_at <= $1) OR (m.status = $4 AND m.claimed_at <= $5))
+					AND m.attempts < $6
+				ORDER BY m.scheduled_at
+				LIMIT $2
+				FOR UPDATE OF m SKIP LOCKED
+			)
+			UPDATE campaign_messages m SET status = $4, claimed_at = $1, attempts = m.attempts + 1
+			FROM due
+			WHERE m.id = due.id
+			RETURNING due.id, due.campaign_id, due.recipient_id, due.kind, due.scheduled_at, due.channel, due.message, due.form_id,
+				due.title, due.token, due.user_id, due.name, due.email, due.phone, due.graduation_year, due.class, due.submitted`
+
+	rows, err := m.DB.QueryContext(ctx, query, now, limit, models.MessageQueued, models.MessageSending, expired, maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
+	var messages []*models.CampaignMessage
 	for rows.Next() {
-		var alumnijob models.AlumniJob
+		var message models.CampaignMessage
 		err := rows.Scan(
-			&alumnijob.ID,
-			&alumnijob.UserID,
-			&alumnijob.Position,
-			&alumnijob.Company,
-			&alumnijob.CompanyLocation,
-			&alumnijob.EmploymentType,
-			&alumnijob.StartYear,
-			&alumnijob.EndYear,
-			&alumnijob.CurrentlyWorking,
-			&alumnijob.CreatedAt,
-			&alumnijob.UpdatedAt,
+			&message.ID,
+			&message.CampaignID,
+			&message.RecipientID,
+			&message.Kind,
+			&message.ScheduledAt,
+			&message.Channel,
+			&message.Message,
+			&message.FormID,
+			&message.FormTitle,
+			&message.Token,
+			&message.Recipient.UserID,
+			&message.Recipient.Name,
+			&message.Recipient.Email,
+			&message.Recipient.Phone,
+			&message.Recipient.Year,
+			&message.Recipient.Class,
+			&message.Submitted,
 		)
-
 		if err != nil {
 			return nil, err
 		}
 
-		alumnijobs = append(alumnijobs, &alumnijob)
+		messages = append(messages, &message)
 	}
 
-	return alumnijobs, nil
+	return messages, rows.Err()
 }
 
-func (m *PostgresDBRepo) GetAlumniJob(id int) (*models.AlumniJob, error) {
+// CompleteCampaignMessage stores the outcome of sending a message. Sent messages mark
+// their recipient as delivered, failed ones keep the error on the recipient.
+func (m *PostgresDBRepo) CompleteCampaignMessage(message *models.CampaignMessage, status string, sendErr string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	query := `select id, user_id, position, company, company_location, employment_type, start_year, end_year, currently_working, created_at, updated_at from alumni_jobs where id = $1`
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	row := m.DB.QueryRowContext(ctx, query, id)
+	now := time.Now()
 
-	var alumnijob models.AlumniJob
+	stmt := `update campaign_messages set status = $1, sent_at = $2, error = NULLIF($3, '') where id = $4`
 
-	err := row.Scan(
-		&alumnijob.ID,
-		&alumnijob.UserID,
-		&alumnijob.Position,
-		&alumnijob.Company,
-		&alumnijob.CompanyLocation,
-		&alumnijob.EmploymentType,
-		&alumnijob.StartYear,
-		&alumnijob.EndYear,
-		&alumnijob.CurrentlyWorking,
-		&alumnijob.CreatedAt,
-		&alumnijob.UpdatedAt,
-	)
+	_, err = tx.ExecContext(ctx, stmt, status, now, sendErr, message.ID)
+	if err != nil {
+		return err
+	}
 
+	switch status {
+	case models.MessageSent:
+		stmt = `update campaign_recipients set delivered_at = COALESCE(delivered_at, $1), last_error = NULL where id = $2`
+		_, err = tx.ExecContext(ctx, stmt, now, message.RecipientID)
+	case models.MessageFailed:
+		stmt = `update campaign_recipients set last_error = $1 where id = $2`
+		_, err = tx.ExecContext(ctx, stmt, sendErr, message.RecipientID)
+	}
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return &alumnijob, nil
+	return tx.Commit()
 }
 
-func (m *PostgresDBRepo) DeleteAlumniJobs(id int) error {
+// OpenInvitation records that the link of an invitation was opened and returns its form id
+func (m *PostgresDBRepo) OpenInvitation(token string) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeOut)
 	defer cancel()
 
-	stmt := `delete from alumni_jobs where id = $1`
+	stmt := `update campaign_recipients r set opened_at = COALESCE(r.opened_at, $1)
+			from survey_campaigns c
+			where c.id = r.campaign_id and r.token = $2
+			returning c.form_id`
 
-	_, err := m.DB.ExecContext(ctx, stmt, id)
+	var formID int
+
+	err := m.DB.QueryRowContext(ctx, stmt, time.Now(), token).Scan(&formID)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return formID, nil
 }
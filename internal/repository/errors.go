@@ -0,0 +1,18 @@
+package repository
+
+import "errors"
+
+var (
+	// ErrSessionRevoked is returned when a refresh token belongs to a revoked or expired session
+	ErrSessionRevoked = errors.New("session has been revoked")
+
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is presented again.
+	// The whole session is revoked when this happens.
+	ErrRefreshTokenReused = errors.New("refresh token has already been used")
+
+	// ErrAlreadySubmitted is returned when a user submits a form they have already submitted
+	ErrAlreadySubmitted = errors.New("form has already been submitted")
+
+	// ErrSlugTaken is returned when another article took the slug of an article being saved
+	ErrSlugTaken = errors.New("slug is already used by another article")
+)
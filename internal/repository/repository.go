@@ -3,6 +3,7 @@ package repository
 import (
 	"alumnihub/internal/models"
 	"database/sql"
+	"time"
 )
 
 type DatabaseRepo interface {
@@ -16,12 +17,32 @@ type DatabaseRepo interface {
 	GetUserIDByUsername(username string) (int, error)
 	GetUserPhotoByID(id int) (string, error)
 
-	AllAlumni() ([]*models.Alumni, error)
+	InsertUserToken(token models.UserToken) error
+	ResetUserPassword(hash string, password string) (int, error)
+	VerifyUserEmail(hash string) (int, error)
+
+	InsertSession(session models.Session) (int, error)
+	InsertRefreshToken(token models.RefreshToken) error
+	RotateRefreshToken(oldJTI string, newToken models.RefreshToken) error
+	RevokeSession(id int, userID int) error
+	RevokeSessionByRefreshToken(jti string) error
+	GetActiveSessions(userID int) ([]*models.Session, error)
+	SessionActive(id int) (bool, error)
+
+	AllRoles() ([]*models.Role, error)
+	GetUserRoles(userID int) ([]string, error)
+	GetUserPermissions(userID int) ([]string, error)
+	SetUserRoles(userID int, roles []string) error
+
+	AllAlumni(opts models.QueryOptions) ([]*models.Alumni, int, error)
 	Alumni(id int) (*models.Alumni, error)
-	InsertAlumni(alumni models.Alumni) error
+	InsertAlumni(alumni models.Alumni) (int, error)
 	UpdateAlumni(alumni models.Alumni) error
 	DeleteAlumni(id int) error
 	GetAlumniByNISN(nisn string) (*models.Alumni, error)
+	GetExistingNISNs(nisns []string) (map[string]bool, error)
+	GetExistingNIS(nis []string) (map[string]string, error)
+	ImportAlumni(alumniList []models.Alumni, upsert bool) (int, int, error)
 	GetAlumniNameByID(id int) (string, error)
 	CountAlumni() (int, error)
 	CountAlumniAccount() (int, error)
@@ -35,14 +56,33 @@ type DatabaseRepo interface {
 	UpdateAdminProfile(profile models.Profile) error
 	GetAdminProfileByUserID(id int) (*models.Profile, error)
 
-	AllArticles() ([]*models.Article, error)
+	AllArticles(publishedOnly bool, category, tag string) ([]*models.Article, error)
 	Article(id int) (*models.Article, error)
-	ArticleBySlug(slug string) (*models.Article, error)
-	InsertArticle(article models.Article) (int, error)
-	UpdateArticle(article models.Article) error
+	ArticleBySlug(slug string, publishedOnly bool) (*models.Article, error)
+	InsertArticle(article models.Article, authorID int) (int, error)
+	UpdateArticle(article models.Article, authorID int) error
+	ArticleRevisions(articleID int) ([]*models.ArticleRevision, error)
+	ArticleRevision(articleID int, revision int) (*models.ArticleRevision, error)
+	UniqueArticleSlug(base string, excludeID int) (string, error)
+	ArticleSlugRedirect(slug string, publishedOnly bool) (string, error)
 	DeleteArticle(id int) error
+	PublishScheduledArticles(now time.Time) (int, error)
+	RelatedArticles(articleID int, limit int) ([]*models.Article, error)
+
+	AllCategories() ([]*models.Category, error)
+	Category(id int) (*models.Category, error)
+	CategorySlugTaken(slug string, excludeID int) (bool, error)
+	InsertCategory(category models.Category) (int, error)
+	UpdateCategory(category models.Category) error
+	DeleteCategory(id int) error
+	AllTags() ([]*models.Tag, error)
+	Tag(id int) (*models.Tag, error)
+	TagSlugTaken(slug string, excludeID int) (bool, error)
+	InsertTag(tag models.Tag) (int, error)
+	UpdateTag(tag models.Tag) error
+	DeleteTag(id int) error
 
-	AllForms() ([]*models.Form, error)
+	AllForms(templates bool) ([]*models.Form, error)
 	Form(id int) (*models.Form, error)
 	ShowForm(id int) (*models.Form, error)
 	InsertForm(form models.Form) (int, error)
@@ -52,15 +92,35 @@ type DatabaseRepo interface {
 
 	Question(id int) (*models.Question, error)
 	QuestionsByForm(id int) ([]*models.Question, error)
+	ReorderQuestions(formID int, order []*models.QuestionOrder) error
+	FormSections(formID int) ([]*models.Section, error)
+	Section(id int) (*models.Section, error)
+	InsertSection(section models.Section) (int, error)
+	UpdateSection(section models.Section) error
+	DeleteSection(id int) error
 	InsertQuestion(question models.Question) (int, error)
 	UpdateQuestion(question models.Question) error
 	DeleteQuestion(id int) error
 	UpdateQuestionOptions(id int, options []string) error
 	DeleteQuestionOptions(id int) error
-	UpdateQuestionExtension(extension *models.Extension) error
+	SetQuestionRules(questionID int, rules []*models.Extension) error
 	DeleteQuestionExtension(id int) error
 
-	InsertAnswers(answers []*models.Answer) error
+	InsertSubmission(submission models.FormSubmission) (int, error)
+	ReplaceSubmission(submission models.FormSubmission) (int, error)
+	WithdrawSubmission(formID int, userID int) error
+	SubmissionVersions(formID int, userID int) ([]*models.SubmissionVersion, error)
+
+	CampaignRecipients(formID int, filter models.RecipientFilter) ([]*models.Recipient, error)
+	InsertCampaign(campaign models.Campaign) (int, int, error)
+	Campaigns(formID int) ([]*models.Campaign, error)
+	Campaign(id int) (*models.Campaign, error)
+	CampaignRecipientStatus(campaignID int) ([]*models.CampaignRecipient, error)
+	ClaimDueCampaignMessages(now time.Time, limit int, lease time.Duration, maxAttempts int) ([]*models.CampaignMessage, error)
+	CompleteCampaignMessage(message *models.CampaignMessage, status string, sendErr string) error
+	OpenInvitation(token string) (int, error)
+	Draft(formID int, userID int) (*models.FormDraft, error)
+	SaveDraft(draft models.FormDraft) error
 	GroupAnswersByQuestion(forumID int, questionID int) ([]*models.GroupAnswer, error)
 	GetAnswersByUser(id int) ([]*models.Answer, error)
 
@@ -73,6 +133,8 @@ type DatabaseRepo interface {
 	GetForumCommentsNumber(id int) (int, error)
 	InsertComment(comment models.Comment) (int, error)
 	GetCommentsByForum(id int) ([]*models.Comment, error)
+	Comment(id int) (*models.Comment, error)
+	DeleteComment(id int) error
 	InsertLike(like models.Like) error
 	DeleteLike(userId int, forumId int) error
 	GetLikesByUser(id int) ([]*models.Like, error)
@@ -93,4 +155,14 @@ type DatabaseRepo interface {
 	GetAlumniJobs(id int) ([]*models.AlumniJob, error)
 	GetAlumniJob(id int) (*models.AlumniJob, error)
 	DeleteAlumniJobs(id int) error
+
+	Search(query string, limit int) (*models.SearchResult, error)
+
+	InsertAuditEntry(entry models.AuditEntry) error
+	AuditLog(opts models.QueryOptions) ([]*models.AuditEntry, int, error)
+
+	FormAnalytics(formID int) (*models.FormAnalytics, error)
+	CrossTab(formID int, rowQuestionID int, columnQuestionID int) (*models.CrossTab, error)
+	FormRespondents(formID int) ([]*models.Respondent, error)
+	DuplicateForm(id int, title string, isTemplate bool) (int, error)
 }
@@ -31,6 +31,7 @@ CREATE TABLE public.users (
     password character varying(255),
     is_admin boolean,
     photo character varying(255),
+    email_verified_at timestamp default NULL,
     created_at timestamp,
     updated_at timestamp
 );
@@ -90,13 +91,13 @@ CREATE TABLE public.admin_profile (
 -- Name: articles; Type: TABLE; Schema: public; Owner: -
 --
 
-CREATE TYPE public.article_status AS ENUM ('draft', 'published');
+CREATE TYPE public.article_status AS ENUM ('draft', 'review', 'scheduled', 'published');
 CREATE TABLE public.articles (
     id integer NOT NULL,
     title character varying(512),
     slug character varying(255),
     body text,
-    status public.article_status,
+    status public.article_status DEFAULT 'draft',
     image character varying(255) DEFAULT 'public/no-image.png',
     created_at timestamp,
     updated_at timestamp,
@@ -116,6 +117,23 @@ CREATE TABLE public.forms (
     end_date timestamp without time zone,
     has_time_limit boolean,
     hidden boolean default false,
+    is_template boolean NOT NULL DEFAULT false,
+    allow_edits boolean NOT NULL DEFAULT false,
+    created_at timestamp without time zone,
+    updated_at timestamp without time zone
+);
+
+
+--
+-- Name: form_sections; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.form_sections (
+    id integer NOT NULL,
+    form_id integer NOT NULL,
+    title character varying(255),
+    description text,
+    "position" integer NOT NULL DEFAULT 0,
     created_at timestamp without time zone,
     updated_at timestamp without time zone
 );
@@ -125,13 +143,17 @@ CREATE TABLE public.forms (
 -- Name: questions; Type: TABLE; Schema: public; Owner: -
 --
 
-CREATE TYPE public.question_type AS ENUM ('multiple_choice', 'short_answer', 'long_answer');
+CREATE TYPE public.question_type AS ENUM ('multiple_choice', 'short_answer', 'long_answer', 'checkboxes', 'dropdown', 'linear_scale', 'date', 'number', 'grid');
 CREATE TABLE public.questions (
     id integer NOT NULL,
     form_id integer,
     question_text text,
     type public.question_type,
     extension boolean default false,
+    required boolean default false,
+    settings jsonb,
+    section_id integer,
+    "position" integer NOT NULL DEFAULT 0,
     created_at timestamp without time zone,
     updated_at timestamp without time zone
 );
@@ -157,7 +179,9 @@ CREATE TABLE public.answers (
     user_id integer NOT NULL,
     form_id integer NOT NULL,
     question_id integer NOT NULL,
-    answer_text text
+    answer_text text,
+    submission_id integer,
+    answer_row character varying(255)
 );
 
 
@@ -168,8 +192,12 @@ CREATE TABLE public.answers (
 CREATE TABLE public.questions_extension (
     id integer NOT NULL,
     question_id integer NOT NULL,
-    followup_question_id integer NOT NULL,
-    followup_option_value character varying(255)
+    followup_question_id integer,
+    followup_section_id integer,
+    followup_option_value character varying(255),
+    operator character varying(16) NOT NULL DEFAULT 'equals',
+    action character varying(16) NOT NULL DEFAULT 'show',
+    CONSTRAINT questions_extension_target_check CHECK (((followup_question_id IS NULL) <> (followup_section_id IS NULL)))
 );
 
 
@@ -267,6 +295,281 @@ CREATE TABLE public.alumni_jobs (
 );
 
 
+--
+-- Name: user_tokens; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.user_tokens (
+    id integer NOT NULL,
+    user_id integer NOT NULL,
+    token_hash character varying(64) UNIQUE NOT NULL,
+    scope character varying(32) NOT NULL,
+    expires_at timestamp NOT NULL,
+    used_at timestamp default NULL,
+    created_at timestamp
+);
+
+
+--
+-- Name: sessions; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.sessions (
+    id integer NOT NULL,
+    user_id integer NOT NULL,
+    user_agent text,
+    ip character varying(64),
+    created_at timestamp,
+    last_used_at timestamp,
+    expires_at timestamp NOT NULL,
+    revoked_at timestamp default NULL
+);
+
+
+--
+-- Name: refresh_tokens; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.refresh_tokens (
+    id integer NOT NULL,
+    jti character varying(64) UNIQUE NOT NULL,
+    session_id integer NOT NULL,
+    user_id integer NOT NULL,
+    created_at timestamp,
+    expires_at timestamp NOT NULL,
+    used_at timestamp default NULL
+);
+
+
+--
+-- Name: roles; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.roles (
+    id integer NOT NULL,
+    name character varying(64) UNIQUE NOT NULL,
+    description text
+);
+
+
+--
+-- Name: permissions; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.permissions (
+    id integer NOT NULL,
+    name character varying(64) UNIQUE NOT NULL,
+    description text
+);
+
+
+--
+-- Name: role_permissions; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.role_permissions (
+    role_id integer NOT NULL,
+    permission_id integer NOT NULL
+);
+
+
+--
+-- Name: user_roles; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.user_roles (
+    user_id integer NOT NULL,
+    role_id integer NOT NULL
+);
+
+
+--
+-- Name: audit_log; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.audit_log (
+    id integer NOT NULL,
+    actor_id integer,
+    action character varying(64) NOT NULL,
+    entity_type character varying(64) NOT NULL,
+    entity_id integer,
+    before jsonb,
+    after jsonb,
+    ip character varying(64),
+    user_agent text,
+    created_at timestamp NOT NULL DEFAULT now()
+);
+
+
+--
+-- Name: form_submissions; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.form_submissions (
+    id integer NOT NULL,
+    form_id integer NOT NULL,
+    user_id integer NOT NULL,
+    submitted_at timestamp NOT NULL,
+    updated_at timestamp
+);
+
+
+--
+-- Name: form_submission_versions; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.form_submission_versions (
+    id integer NOT NULL,
+    form_id integer NOT NULL,
+    user_id integer NOT NULL,
+    submission_id integer,
+    version integer NOT NULL,
+    action character varying(16) NOT NULL,
+    answers jsonb NOT NULL DEFAULT '[]'::jsonb,
+    created_at timestamp without time zone NOT NULL
+);
+
+
+--
+-- Name: form_drafts; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.form_drafts (
+    id integer NOT NULL,
+    form_id integer NOT NULL,
+    user_id integer NOT NULL,
+    answers jsonb NOT NULL DEFAULT '[]'::jsonb,
+    created_at timestamp without time zone,
+    updated_at timestamp without time zone
+);
+
+
+--
+-- Name: survey_campaigns; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.survey_campaigns (
+    id integer NOT NULL,
+    form_id integer NOT NULL,
+    name character varying(255) NOT NULL,
+    channel character varying(16) NOT NULL,
+    message text,
+    filters jsonb,
+    start_at timestamp without time zone NOT NULL,
+    reminder_days jsonb,
+    created_by integer,
+    created_at timestamp without time zone NOT NULL
+);
+
+
+--
+-- Name: campaign_recipients; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.campaign_recipients (
+    id integer NOT NULL,
+    campaign_id integer NOT NULL,
+    user_id integer NOT NULL,
+    token character varying(64) NOT NULL,
+    delivered_at timestamp without time zone,
+    opened_at timestamp without time zone,
+    submitted_at timestamp without time zone,
+    last_error text
+);
+
+
+--
+-- Name: campaign_messages; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.campaign_messages (
+    id integer NOT NULL,
+    recipient_id integer NOT NULL,
+    kind character varying(16) NOT NULL,
+    status character varying(16) NOT NULL DEFAULT 'queued',
+    scheduled_at timestamp without time zone NOT NULL,
+    claimed_at timestamp without time zone,
+    attempts integer NOT NULL DEFAULT 0,
+    sent_at timestamp without time zone,
+    error text
+);
+
+
+--
+-- Name: article_slugs; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.article_slugs (
+    id integer NOT NULL,
+    article_id integer NOT NULL,
+    slug character varying(255) NOT NULL,
+    created_at timestamp without time zone
+);
+
+
+--
+-- Name: categories; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.categories (
+    id integer NOT NULL,
+    name character varying(255) NOT NULL,
+    slug character varying(255) NOT NULL,
+    description text,
+    created_at timestamp without time zone,
+    updated_at timestamp without time zone
+);
+
+
+--
+-- Name: tags; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.tags (
+    id integer NOT NULL,
+    name character varying(64) NOT NULL,
+    slug character varying(64) NOT NULL,
+    created_at timestamp without time zone
+);
+
+
+--
+-- Name: article_categories; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.article_categories (
+    article_id integer NOT NULL,
+    category_id integer NOT NULL
+);
+
+
+--
+-- Name: article_tags; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.article_tags (
+    article_id integer NOT NULL,
+    tag_id integer NOT NULL
+);
+
+
+--
+-- Name: article_revisions; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.article_revisions (
+    id integer NOT NULL,
+    article_id integer NOT NULL,
+    revision integer NOT NULL,
+    title character varying(512),
+    slug character varying(255),
+    body text,
+    status public.article_status,
+    author_id integer,
+    created_at timestamp without time zone
+);
+
+
 --
 -- Name: users_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
@@ -492,79 +795,317 @@ ALTER TABLE public.alumni_jobs ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY
 
 
 --
--- Name: users users_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: user_tokens_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.users
-    ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+ALTER TABLE public.user_tokens ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.user_tokens_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
 
 
 --
--- Name: alumni alumni_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: sessions_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.alumni
-    ADD CONSTRAINT alumni_pkey PRIMARY KEY (id);
+ALTER TABLE public.sessions ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.sessions_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
 
 
 --
--- Name: alumni_profile alumni_profile_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: refresh_tokens_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.alumni_profile
-    ADD CONSTRAINT alumni_profile_pkey PRIMARY KEY (id);
+ALTER TABLE public.refresh_tokens ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.refresh_tokens_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
 
 
 --
--- Name: admin_profile alumni_profile_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: roles_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.admin_profile
-    ADD CONSTRAINT admin_profile_pkey PRIMARY KEY (id);
+ALTER TABLE public.roles ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.roles_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
 
 
 --
--- Name: articles articles_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: permissions_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.articles
-    ADD CONSTRAINT articles_pkey PRIMARY KEY (id);
+ALTER TABLE public.permissions ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.permissions_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
 
 
 --
--- Name: forms forms_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: audit_log_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.forms
-    ADD CONSTRAINT forms_pkey PRIMARY KEY (id);
+ALTER TABLE public.audit_log ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.audit_log_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
 
 
 --
--- Name: questions questions_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: form_submissions_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.questions
-    ADD CONSTRAINT questions_pkey PRIMARY KEY (id);
+ALTER TABLE public.form_submissions ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.form_submissions_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
 
 
 --
--- Name: options options_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: form_sections_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.options
-    ADD CONSTRAINT options_pkey PRIMARY KEY (id);
+ALTER TABLE public.form_sections ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.form_sections_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
 
 
 --
--- Name: answers answers_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: form_drafts_id_seq; Type: SEQUENCE; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.answers
-    ADD CONSTRAINT answers_pkey PRIMARY KEY (id);
+ALTER TABLE public.form_drafts ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.form_drafts_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
 
 
 --
--- Name: answers answers_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: form_submission_versions_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+ALTER TABLE public.form_submission_versions ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.form_submission_versions_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
+
+
+--
+-- Name: survey_campaigns_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+ALTER TABLE public.survey_campaigns ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.survey_campaigns_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
+
+
+--
+-- Name: campaign_recipients_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+ALTER TABLE public.campaign_recipients ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.campaign_recipients_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
+
+
+--
+-- Name: campaign_messages_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+ALTER TABLE public.campaign_messages ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.campaign_messages_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
+
+
+--
+-- Name: article_slugs_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+ALTER TABLE public.article_slugs ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.article_slugs_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
+
+
+--
+-- Name: categories_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+ALTER TABLE public.categories ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.categories_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
+
+
+--
+-- Name: tags_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+ALTER TABLE public.tags ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.tags_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
+
+
+--
+-- Name: article_revisions_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+ALTER TABLE public.article_revisions ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.article_revisions_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
+
+
+--
+-- Name: users users_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.users
+    ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: alumni alumni_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.alumni
+    ADD CONSTRAINT alumni_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: alumni_profile alumni_profile_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.alumni_profile
+    ADD CONSTRAINT alumni_profile_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: admin_profile alumni_profile_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.admin_profile
+    ADD CONSTRAINT admin_profile_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: articles articles_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.articles
+    ADD CONSTRAINT articles_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: forms forms_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.forms
+    ADD CONSTRAINT forms_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: questions questions_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.questions
+    ADD CONSTRAINT questions_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: options options_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.options
+    ADD CONSTRAINT options_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: answers answers_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.answers
+    ADD CONSTRAINT answers_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: answers answers_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
 
 ALTER TABLE ONLY public.questions_extension
@@ -572,195 +1113,817 @@ ALTER TABLE ONLY public.questions_extension
 
 
 --
--- Name: forums forums_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: forums forums_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.forums
+    ADD CONSTRAINT forums_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: replies replies_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.replies
+    ADD CONSTRAINT replies_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: likes likes_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.likes
+    ADD CONSTRAINT likes_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: likes likes_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.jobs
+    ADD CONSTRAINT jobs_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: alumni_educations alumni_educations_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.alumni_educations
+    ADD CONSTRAINT alumni_educations_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: alumni_jobs alumni_jobs_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.alumni_jobs
+    ADD CONSTRAINT alumni_jobs_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: user_tokens user_tokens_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.user_tokens
+    ADD CONSTRAINT user_tokens_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: sessions sessions_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.sessions
+    ADD CONSTRAINT sessions_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: refresh_tokens refresh_tokens_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.refresh_tokens
+    ADD CONSTRAINT refresh_tokens_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: roles roles_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.roles
+    ADD CONSTRAINT roles_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: permissions permissions_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.permissions
+    ADD CONSTRAINT permissions_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: role_permissions role_permissions_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.role_permissions
+    ADD CONSTRAINT role_permissions_pkey PRIMARY KEY (role_id, permission_id);
+
+
+--
+-- Name: user_roles user_roles_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.user_roles
+    ADD CONSTRAINT user_roles_pkey PRIMARY KEY (user_id, role_id);
+
+
+--
+-- Name: audit_log audit_log_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.audit_log
+    ADD CONSTRAINT audit_log_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: form_submissions form_submissions_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.form_submissions
+    ADD CONSTRAINT form_submissions_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: form_submissions form_submissions_form_id_user_id_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.form_submissions
+    ADD CONSTRAINT form_submissions_form_id_user_id_key UNIQUE (form_id, user_id);
+
+
+--
+-- Name: form_sections form_sections_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.form_sections
+    ADD CONSTRAINT form_sections_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: form_drafts form_drafts_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.form_drafts
+    ADD CONSTRAINT form_drafts_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: form_drafts form_drafts_form_id_user_id_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.form_drafts
+    ADD CONSTRAINT form_drafts_form_id_user_id_key UNIQUE (form_id, user_id);
+
+
+--
+-- Name: form_submission_versions form_submission_versions_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.form_submission_versions
+    ADD CONSTRAINT form_submission_versions_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: survey_campaigns survey_campaigns_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.survey_campaigns
+    ADD CONSTRAINT survey_campaigns_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: campaign_recipients campaign_recipients_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.campaign_recipients
+    ADD CONSTRAINT campaign_recipients_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: campaign_messages campaign_messages_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.campaign_messages
+    ADD CONSTRAINT campaign_messages_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: campaign_recipients campaign_recipients_campaign_id_user_id_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.campaign_recipients
+    ADD CONSTRAINT campaign_recipients_campaign_id_user_id_key UNIQUE (campaign_id, user_id);
+
+
+--
+-- Name: campaign_recipients campaign_recipients_token_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.campaign_recipients
+    ADD CONSTRAINT campaign_recipients_token_key UNIQUE (token);
+
+
+--
+-- Name: article_slugs article_slugs_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.article_slugs
+    ADD CONSTRAINT article_slugs_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: articles articles_slug_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.articles
+    ADD CONSTRAINT articles_slug_key UNIQUE (slug);
+
+
+--
+-- Name: article_slugs article_slugs_slug_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.article_slugs
+    ADD CONSTRAINT article_slugs_slug_key UNIQUE (slug);
+
+
+--
+-- Name: categories categories_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.categories
+    ADD CONSTRAINT categories_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: tags tags_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.tags
+    ADD CONSTRAINT tags_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: categories categories_slug_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.categories
+    ADD CONSTRAINT categories_slug_key UNIQUE (slug);
+
+
+--
+-- Name: tags tags_slug_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.tags
+    ADD CONSTRAINT tags_slug_key UNIQUE (slug);
+
+
+--
+-- Name: article_categories article_categories_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.article_categories
+    ADD CONSTRAINT article_categories_pkey PRIMARY KEY (article_id, category_id);
+
+
+--
+-- Name: article_tags article_tags_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.article_tags
+    ADD CONSTRAINT article_tags_pkey PRIMARY KEY (article_id, tag_id);
+
+
+--
+-- Name: article_revisions article_revisions_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.article_revisions
+    ADD CONSTRAINT article_revisions_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: article_revisions article_revisions_article_id_revision_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.article_revisions
+    ADD CONSTRAINT article_revisions_article_id_revision_key UNIQUE (article_id, revision);
+
+
+--
+-- Name: alumni_profile alumni_profile_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.alumni_profile
+    ADD CONSTRAINT alumni_profile_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: alumni alumni_profile_alumni_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.alumni_profile
+    ADD CONSTRAINT alumni_profile_alumni_id_fkey FOREIGN KEY (alumni_id) REFERENCES public.alumni(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: admin_profile admin_profile_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.admin_profile
+    ADD CONSTRAINT admin_profile_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: questions questions_form_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.questions
+    ADD CONSTRAINT questions_form_id_fkey FOREIGN KEY (form_id) REFERENCES public.forms(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: options options_question_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.options
+    ADD CONSTRAINT options_question_id_fkey FOREIGN KEY (question_id) REFERENCES public.questions(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: answers answers_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.answers
+    ADD CONSTRAINT answers_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: answers answers_forms_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.answers
+    ADD CONSTRAINT answers_form_id_fkey FOREIGN KEY (form_id) REFERENCES public.forms(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: answers answers_question_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.answers
+    ADD CONSTRAINT answers_question_id_fkey FOREIGN KEY (question_id) REFERENCES public.questions(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: answers answers_question_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.questions_extension
+    ADD CONSTRAINT questions_extension_question_id_fkey FOREIGN KEY (question_id) REFERENCES public.questions(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: answers answers_question_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.questions_extension
+    ADD CONSTRAINT questions_extension_followup_question_id_fkey FOREIGN KEY (followup_question_id) REFERENCES public.questions(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: questions_extension questions_extension_followup_section_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.questions_extension
+    ADD CONSTRAINT questions_extension_followup_section_id_fkey FOREIGN KEY (followup_section_id) REFERENCES public.form_sections(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: questions_extension_question_id_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX questions_extension_question_id_idx ON public.questions_extension USING btree (question_id);
+
+
+--
+-- Name: forums forums_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.forums
+    ADD CONSTRAINT forums_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: replies replies_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.replies
+    ADD CONSTRAINT replies_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: replies replies_forum_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.forums
-    ADD CONSTRAINT forums_pkey PRIMARY KEY (id);
+ALTER TABLE ONLY public.replies
+    ADD CONSTRAINT replies_forum_id_fkey FOREIGN KEY (forum_id) REFERENCES public.forums(id) ON UPDATE CASCADE ON DELETE CASCADE;
 
 
 --
--- Name: replies replies_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: likes likes_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.replies
-    ADD CONSTRAINT replies_pkey PRIMARY KEY (id);
+ALTER TABLE ONLY public.likes
+    ADD CONSTRAINT likes_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
 
 
 --
--- Name: likes likes_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: likes likes_forum_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
 
 ALTER TABLE ONLY public.likes
-    ADD CONSTRAINT likes_pkey PRIMARY KEY (id);
+    ADD CONSTRAINT likes_forum_id_fkey FOREIGN KEY (forum_id) REFERENCES public.forums(id) ON UPDATE CASCADE ON DELETE CASCADE;
 
 
 --
--- Name: likes likes_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: likes job_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
 
 ALTER TABLE ONLY public.jobs
-    ADD CONSTRAINT jobs_pkey PRIMARY KEY (id);
+    ADD CONSTRAINT jobs_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
 
 
 --
--- Name: alumni_educations alumni_educations_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: alumni_educations alumni_educations_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
 
 ALTER TABLE ONLY public.alumni_educations
-    ADD CONSTRAINT alumni_educations_pkey PRIMARY KEY (id);
+    ADD CONSTRAINT alumni_educations_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
 
 
 --
--- Name: alumni_jobs alumni_jobs_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: alumni_jobs alumni_jobs_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
 
 ALTER TABLE ONLY public.alumni_jobs
-    ADD CONSTRAINT alumni_jobs_pkey PRIMARY KEY (id);
+    ADD CONSTRAINT alumni_jobs_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
 
 
 --
--- Name: alumni_profile alumni_profile_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: alumni_graduation_year_idx; Type: INDEX; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.alumni_profile
-    ADD CONSTRAINT alumni_profile_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+CREATE INDEX alumni_graduation_year_idx ON public.alumni USING btree (graduation_year);
 
 
 --
--- Name: alumni alumni_profile_alumni_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: alumni_class_idx; Type: INDEX; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.alumni_profile
-    ADD CONSTRAINT alumni_profile_alumni_id_fkey FOREIGN KEY (alumni_id) REFERENCES public.alumni(id) ON UPDATE CASCADE ON DELETE CASCADE;
+CREATE INDEX alumni_class_idx ON public.alumni USING btree (class);
 
 
 --
--- Name: admin_profile admin_profile_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: alumni_search_idx; Type: INDEX; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.admin_profile
-    ADD CONSTRAINT admin_profile_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+CREATE INDEX alumni_search_idx ON public.alumni USING gin (to_tsvector('simple'::regconfig, COALESCE(name, ''::character varying)::text));
 
 
 --
--- Name: questions questions_form_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: alumni_profile_search_idx; Type: INDEX; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.questions
-    ADD CONSTRAINT questions_form_id_fkey FOREIGN KEY (form_id) REFERENCES public.forms(id) ON UPDATE CASCADE ON DELETE CASCADE;
+CREATE INDEX alumni_profile_search_idx ON public.alumni_profile USING gin (to_tsvector('simple'::regconfig, COALESCE(bio, ''::text) || ' '::text || COALESCE(location, ''::character varying)::text));
 
 
 --
--- Name: options options_question_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: articles_search_idx; Type: INDEX; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.options
-    ADD CONSTRAINT options_question_id_fkey FOREIGN KEY (question_id) REFERENCES public.questions(id) ON UPDATE CASCADE ON DELETE CASCADE;
+CREATE INDEX articles_search_idx ON public.articles USING gin (to_tsvector('simple'::regconfig, COALESCE(title, ''::character varying)::text || ' '::text || COALESCE(body, ''::text)));
 
 
 --
--- Name: answers answers_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: forums_search_idx; Type: INDEX; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.answers
-    ADD CONSTRAINT answers_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+CREATE INDEX forums_search_idx ON public.forums USING gin (to_tsvector('simple'::regconfig, COALESCE(forum_text, ''::text)));
 
 
 --
--- Name: answers answers_forms_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: jobs_search_idx; Type: INDEX; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.answers
-    ADD CONSTRAINT answers_form_id_fkey FOREIGN KEY (form_id) REFERENCES public.forms(id) ON UPDATE CASCADE ON DELETE CASCADE;
+CREATE INDEX jobs_search_idx ON public.jobs USING gin (to_tsvector('simple'::regconfig, COALESCE(job_position, ''::character varying)::text || ' '::text || COALESCE(company, ''::character varying)::text || ' '::text || COALESCE(description, ''::text)));
 
 
 --
--- Name: answers answers_question_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: user_tokens user_tokens_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.user_tokens
+    ADD CONSTRAINT user_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: sessions sessions_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.sessions
+    ADD CONSTRAINT sessions_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: refresh_tokens refresh_tokens_session_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.refresh_tokens
+    ADD CONSTRAINT refresh_tokens_session_id_fkey FOREIGN KEY (session_id) REFERENCES public.sessions(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: refresh_tokens refresh_tokens_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.refresh_tokens
+    ADD CONSTRAINT refresh_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: role_permissions role_permissions_role_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.role_permissions
+    ADD CONSTRAINT role_permissions_role_id_fkey FOREIGN KEY (role_id) REFERENCES public.roles(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: role_permissions role_permissions_permission_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.role_permissions
+    ADD CONSTRAINT role_permissions_permission_id_fkey FOREIGN KEY (permission_id) REFERENCES public.permissions(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: user_roles user_roles_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.user_roles
+    ADD CONSTRAINT user_roles_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: user_roles user_roles_role_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.user_roles
+    ADD CONSTRAINT user_roles_role_id_fkey FOREIGN KEY (role_id) REFERENCES public.roles(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: audit_log_entity_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX audit_log_entity_idx ON public.audit_log USING btree (entity_type, entity_id);
+
+
+--
+-- Name: audit_log_actor_id_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX audit_log_actor_id_idx ON public.audit_log USING btree (actor_id);
+
+
+--
+-- Name: audit_log_created_at_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX audit_log_created_at_idx ON public.audit_log USING btree (created_at);
+
+
+--
+-- Name: audit_log audit_log_actor_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.audit_log
+    ADD CONSTRAINT audit_log_actor_id_fkey FOREIGN KEY (actor_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE SET NULL;
+
+
+--
+-- Name: form_submissions form_submissions_form_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.form_submissions
+    ADD CONSTRAINT form_submissions_form_id_fkey FOREIGN KEY (form_id) REFERENCES public.forms(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: form_submissions form_submissions_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.form_submissions
+    ADD CONSTRAINT form_submissions_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: answers answers_submission_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
 
 ALTER TABLE ONLY public.answers
-    ADD CONSTRAINT answers_question_id_fkey FOREIGN KEY (question_id) REFERENCES public.questions(id) ON UPDATE CASCADE ON DELETE CASCADE;
+    ADD CONSTRAINT answers_submission_id_fkey FOREIGN KEY (submission_id) REFERENCES public.form_submissions(id) ON UPDATE CASCADE ON DELETE CASCADE;
 
 
 --
--- Name: answers answers_question_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: form_sections form_sections_form_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.questions_extension
-    ADD CONSTRAINT questions_extension_question_id_fkey FOREIGN KEY (question_id) REFERENCES public.questions(id) ON UPDATE CASCADE ON DELETE CASCADE;
+ALTER TABLE ONLY public.form_sections
+    ADD CONSTRAINT form_sections_form_id_fkey FOREIGN KEY (form_id) REFERENCES public.forms(id) ON UPDATE CASCADE ON DELETE CASCADE;
 
 
 --
--- Name: answers answers_question_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: questions questions_section_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.questions_extension
-    ADD CONSTRAINT questions_extension_followup_question_id_fkey FOREIGN KEY (followup_question_id) REFERENCES public.questions(id) ON UPDATE CASCADE ON DELETE CASCADE;
+ALTER TABLE ONLY public.questions
+    ADD CONSTRAINT questions_section_id_fkey FOREIGN KEY (section_id) REFERENCES public.form_sections(id) ON UPDATE CASCADE ON DELETE SET NULL;
 
 
 --
--- Name: forums forums_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: questions_form_id_position_idx; Type: INDEX; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.forums
-    ADD CONSTRAINT forums_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+CREATE INDEX questions_form_id_position_idx ON public.questions USING btree (form_id, "position");
 
 
 --
--- Name: replies replies_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: form_drafts form_drafts_form_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.replies
-    ADD CONSTRAINT replies_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+ALTER TABLE ONLY public.form_drafts
+    ADD CONSTRAINT form_drafts_form_id_fkey FOREIGN KEY (form_id) REFERENCES public.forms(id) ON UPDATE CASCADE ON DELETE CASCADE;
 
 
 --
--- Name: replies replies_forum_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: form_drafts form_drafts_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.replies
-    ADD CONSTRAINT replies_forum_id_fkey FOREIGN KEY (forum_id) REFERENCES public.forums(id) ON UPDATE CASCADE ON DELETE CASCADE;
+ALTER TABLE ONLY public.form_drafts
+    ADD CONSTRAINT form_drafts_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
 
 
 --
--- Name: likes likes_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: form_submission_versions form_submission_versions_form_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.likes
-    ADD CONSTRAINT likes_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+ALTER TABLE ONLY public.form_submission_versions
+    ADD CONSTRAINT form_submission_versions_form_id_fkey FOREIGN KEY (form_id) REFERENCES public.forms(id) ON UPDATE CASCADE ON DELETE CASCADE;
 
 
 --
--- Name: likes likes_forum_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: form_submission_versions form_submission_versions_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.likes
-    ADD CONSTRAINT likes_forum_id_fkey FOREIGN KEY (forum_id) REFERENCES public.forums(id) ON UPDATE CASCADE ON DELETE CASCADE;
+ALTER TABLE ONLY public.form_submission_versions
+    ADD CONSTRAINT form_submission_versions_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
 
 
 --
--- Name: likes job_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: form_submission_versions form_submission_versions_submission_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.jobs
-    ADD CONSTRAINT jobs_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+ALTER TABLE ONLY public.form_submission_versions
+    ADD CONSTRAINT form_submission_versions_submission_id_fkey FOREIGN KEY (submission_id) REFERENCES public.form_submissions(id) ON UPDATE CASCADE ON DELETE SET NULL;
 
 
 --
--- Name: alumni_educations alumni_educations_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: form_submission_versions_form_id_user_id_idx; Type: INDEX; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.alumni_educations
-    ADD CONSTRAINT alumni_educations_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+CREATE INDEX form_submission_versions_form_id_user_id_idx ON public.form_submission_versions USING btree (form_id, user_id, version);
 
 
 --
--- Name: alumni_jobs alumni_jobs_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+-- Name: survey_campaigns survey_campaigns_form_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY public.alumni_jobs
-    ADD CONSTRAINT alumni_jobs_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+ALTER TABLE ONLY public.survey_campaigns
+    ADD CONSTRAINT survey_campaigns_form_id_fkey FOREIGN KEY (form_id) REFERENCES public.forms(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: survey_campaigns survey_campaigns_created_by_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.survey_campaigns
+    ADD CONSTRAINT survey_campaigns_created_by_fkey FOREIGN KEY (created_by) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE SET NULL;
+
+
+--
+-- Name: campaign_recipients campaign_recipients_campaign_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.campaign_recipients
+    ADD CONSTRAINT campaign_recipients_campaign_id_fkey FOREIGN KEY (campaign_id) REFERENCES public.survey_campaigns(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: campaign_recipients campaign_recipients_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.campaign_recipients
+    ADD CONSTRAINT campaign_recipients_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: campaign_messages campaign_messages_recipient_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.campaign_messages
+    ADD CONSTRAINT campaign_messages_recipient_id_fkey FOREIGN KEY (recipient_id) REFERENCES public.campaign_recipients(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: campaign_messages_due_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX campaign_messages_due_idx ON public.campaign_messages USING btree (scheduled_at) WHERE ((status)::text = 'queued'::text);
+
+
+--
+-- Name: campaign_messages_sending_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX campaign_messages_sending_idx ON public.campaign_messages USING btree (claimed_at) WHERE ((status)::text = 'sending'::text);
+
+
+--
+-- Name: articles_status_published_at_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX articles_status_published_at_idx ON public.articles USING btree (status, published_at);
+
+
+--
+-- Name: article_slugs article_slugs_article_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.article_slugs
+    ADD CONSTRAINT article_slugs_article_id_fkey FOREIGN KEY (article_id) REFERENCES public.articles(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: article_categories article_categories_article_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.article_categories
+    ADD CONSTRAINT article_categories_article_id_fkey FOREIGN KEY (article_id) REFERENCES public.articles(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: article_categories article_categories_category_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.article_categories
+    ADD CONSTRAINT article_categories_category_id_fkey FOREIGN KEY (category_id) REFERENCES public.categories(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: article_tags article_tags_article_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.article_tags
+    ADD CONSTRAINT article_tags_article_id_fkey FOREIGN KEY (article_id) REFERENCES public.articles(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: article_tags article_tags_tag_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.article_tags
+    ADD CONSTRAINT article_tags_tag_id_fkey FOREIGN KEY (tag_id) REFERENCES public.tags(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: article_tags_tag_id_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX article_tags_tag_id_idx ON public.article_tags USING btree (tag_id);
+
+
+--
+-- Name: article_categories_category_id_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX article_categories_category_id_idx ON public.article_categories USING btree (category_id);
+
+
+--
+-- Name: article_revisions article_revisions_article_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.article_revisions
+    ADD CONSTRAINT article_revisions_article_id_fkey FOREIGN KEY (article_id) REFERENCES public.articles(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: article_revisions article_revisions_author_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.article_revisions
+    ADD CONSTRAINT article_revisions_author_id_fkey FOREIGN KEY (author_id) REFERENCES public.users(id) ON UPDATE CASCADE ON DELETE SET NULL;
 
 
 --
@@ -778,8 +1941,8 @@ COPY public.alumni (nisn, nis, name, gender, phone, graduation_year, class) FROM
 -- Data for Name: users; Type: TABLE DATA; Schema: public; Owner: -
 --
 
-COPY public.users (username, email, password, is_admin, created_at, updated_at) FROM stdin;
-admin	admin@gmail.com	$2a$12$qDysuB7aGhgtRCI08kP24OMVK3snloIpSRzhvbIBIusaGpdQ5vNIa	true	2022-09-23 00:00:00	2022-09-23 00:00:00
+COPY public.users (username, email, password, is_admin, email_verified_at, created_at, updated_at) FROM stdin;
+admin	admin@gmail.com	$2a$12$qDysuB7aGhgtRCI08kP24OMVK3snloIpSRzhvbIBIusaGpdQ5vNIa	true	2022-09-23 00:00:00	2022-09-23 00:00:00	2022-09-23 00:00:00
 \.
 
 
@@ -801,6 +1964,57 @@ Kepala Sekolah Ganti	wawancara-aplikasi-alumni	<p style="text-align:justify;"><s
 \.
 
 
+--
+-- Data for Name: roles; Type: TABLE DATA; Schema: public; Owner: -
+--
+
+COPY public.roles (name, description) FROM stdin;
+super_admin	Full access to every administrative feature
+content_editor	Writes and publishes articles
+survey_manager	Manages forms, questions and survey answers
+moderator	Moderates forums and job postings
+alumni	Registered alumnus
+\.
+
+
+--
+-- Data for Name: permissions; Type: TABLE DATA; Schema: public; Owner: -
+--
+
+COPY public.permissions (name, description) FROM stdin;
+dashboard:read	View the admin dashboard
+alumni:write	Create, import, update and delete alumni records
+articles:write	Create, update and delete articles
+forms:write	Create, update and delete forms and questions
+answers:read	View and export survey answers
+forums:moderate	Delete any forum thread or reply
+jobs:moderate	Update or delete any job posting
+users:manage	Assign roles and unlock user accounts
+audit:read	View the audit log of administrative actions
+\.
+
+
+--
+-- Data for Name: role_permissions; Type: TABLE DATA; Schema: public; Owner: -
+--
+
+INSERT INTO public.role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM public.roles r, public.permissions p
+WHERE r.name = 'super_admin'
+   OR (r.name = 'content_editor' AND p.name IN ('dashboard:read', 'articles:write'))
+   OR (r.name = 'survey_manager' AND p.name IN ('dashboard:read', 'forms:write', 'answers:read'))
+   OR (r.name = 'moderator' AND p.name IN ('dashboard:read', 'forums:moderate', 'jobs:moderate'));
+
+
+--
+-- Data for Name: user_roles; Type: TABLE DATA; Schema: public; Owner: -
+--
+
+INSERT INTO public.user_roles (user_id, role_id)
+SELECT u.id, r.id FROM public.users u, public.roles r
+WHERE (u.is_admin AND r.name = 'super_admin') OR (NOT u.is_admin AND r.name = 'alumni');
+
+
 --
 -- PostgreSQL database dump complete
 --